@@ -0,0 +1,46 @@
+package api
+
+import (
+	"golang-microservice-template/audit"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/snapshot"
+	. "golang-microservice-template/utils"
+	"golang-microservice-template/webhook"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAdminRoutesRequireAuthentication checks that the routes only served to authorized callers do not exist
+// if authentication is disabled, even though their dependencies are set.
+func TestAdminRoutesRequireAuthentication(t *testing.T) {
+	router := NewRouter(
+		WithAuditStore(audit.NewMemoryStore(10)),
+		WithWebhooks(webhook.NewService(webhook.NewMemoryStore(10), SystemClock(), pizza.EventTypes, false, false)),
+		WithSnapshots(snapshot.NewManager(pizza.NewService(), filepath.Join(t.TempDir(), "snapshot.json"), Log)),
+	)
+
+	for _, rc := range []routeCase{
+		{method: http.MethodGet, path: "/v1/audit"},
+		{method: http.MethodPost, path: "/v1/webhooks"},
+		{method: http.MethodGet, path: "/v1/webhooks"},
+		{method: http.MethodGet, path: "/v1/webhooks/1"},
+		{method: http.MethodPatch, path: "/v1/webhooks/1"},
+		{method: http.MethodDelete, path: "/v1/webhooks/1"},
+		{method: http.MethodGet, path: "/v1/webhooks/1/deliveries"},
+		{method: http.MethodGet, path: "/v1/admin/snapshots"},
+		{method: http.MethodPost, path: "/v1/admin/snapshots"},
+		{method: http.MethodPost, path: "/v1/admin/snapshots/restore"},
+	} {
+		t.Run(rc.method+" "+rc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, httptest.NewRequest(rc.method, rc.path, nil))
+
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/snapshot"
+	"golang-microservice-template/sse"
+	. "golang-microservice-template/utils"
+	"golang-microservice-template/webhook"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "authorization-test-secret"
+
+// allRoles are the roles a token may be granted.
+var allRoles = []string{auth.RoleMenuEditor, auth.RoleAdmin}
+
+// routeCase is a route of the route table, the request that succeeds if the caller is allowed and its status.
+type routeCase struct {
+	method string
+	path   string
+	body   string
+	policy auth.Policy
+	status int
+}
+
+var routeCases = []routeCase{
+	{http.MethodGet, "/v1/pizza/stream", "", readPizza, http.StatusOK},
+	{http.MethodPost, "/v1/pizza", `{"name":"funghi","ingredients":[]}`, writePizza, http.StatusCreated},
+	{http.MethodGet, "/v1/pizza", "", readPizza, http.StatusOK},
+	{http.MethodGet, "/v1/pizza/:name", "", readPizza, http.StatusOK},
+	{http.MethodGet, "/v1/pizza/:name/revisions", "", readPizza, http.StatusOK},
+	{http.MethodPost, "/v1/pizza/:name/revisions/:revision/revert", "", writePizza, http.StatusOK},
+	{http.MethodPatch, "/v1/pizza/:name", `{"name":"margherita","ingredients":[]}`, writePizza, http.StatusOK},
+	{http.MethodDelete, "/v1/pizza/:name", "", deletePizza, http.StatusNoContent},
+	{http.MethodGet, "/v1/audit", "", readAudit, http.StatusOK},
+	// the WebSocket handshake fails after authorization, the request is no upgrade
+	{http.MethodGet, "/v1/ws", "", readPizza, http.StatusBadRequest},
+	{http.MethodPost, "/v1/webhooks", `{"url":"http://203.0.113.10/hook","events":["` + pizza.EventPizzaCreated + `"]}`, adminHooks, http.StatusCreated},
+	{http.MethodGet, "/v1/webhooks", "", adminHooks, http.StatusOK},
+	{http.MethodGet, "/v1/webhooks/:id", "", adminHooks, http.StatusOK},
+	{http.MethodPatch, "/v1/webhooks/:id", `{"enabled":false}`, adminHooks, http.StatusOK},
+	{http.MethodDelete, "/v1/webhooks/:id", "", adminHooks, http.StatusNoContent},
+	{http.MethodGet, "/v1/webhooks/:id/deliveries", "", adminHooks, http.StatusOK},
+	{http.MethodGet, "/v1/admin/metrics", "", readMetrics, http.StatusOK},
+	{http.MethodGet, "/v1/admin/snapshots", "", adminSnaps, http.StatusOK},
+	{http.MethodPost, "/v1/admin/snapshots", "", adminSnaps, http.StatusOK},
+	{http.MethodPost, "/v1/admin/snapshots/restore", "{{snapshot}}", adminSnaps, http.StatusOK},
+	{http.MethodPost, "/v1/admin/apikeys", `{"owner":"ci","scopes":["` + auth.ScopePizzaRead + `"]}`, adminKeys, http.StatusCreated},
+	{http.MethodGet, "/v1/admin/apikeys", "", adminKeys, http.StatusOK},
+	{http.MethodDelete, "/v1/admin/apikeys/:id", "", adminKeys, http.StatusOK},
+	{http.MethodPost, "/v1/admin/apikeys/:id/rotate", "", adminKeys, http.StatusCreated},
+}
+
+// authorizationFixture is a router with every optional route group and the resources the routes refer to.
+type authorizationFixture struct {
+	router   Router
+	hook     string
+	key      string
+	snapshot string
+}
+
+func newAuthorizationFixture(t *testing.T) *authorizationFixture {
+	clock := SystemClock()
+	validator, err := auth.NewJWTValidator(config.JWTConfig{Enabled: true, Algorithm: "HS256", Secret: testSecret}, clock)
+	require.NoError(t, err)
+
+	pizzas := pizza.NewService()
+	_, err = pizzas.Create(audit.Origin{}, &pizza.PizzaDto{Name: "margherita", Ingredient: []pizza.IngredientDto{}})
+	require.NoError(t, err)
+	_, err = pizzas.Update(audit.Origin{}, "margherita", &pizza.PizzaDto{Name: "margherita", Ingredient: []pizza.IngredientDto{}})
+	require.NoError(t, err)
+	current, err := pizzas.Snapshot()
+	require.NoError(t, err)
+	snapshotBody, err := json.Marshal(current)
+	require.NoError(t, err)
+
+	hooks := webhook.NewService(webhook.NewMemoryStore(10), clock, pizza.EventTypes, false, false)
+	hook, err := hooks.Create("http://203.0.113.10/hook", []string{pizza.EventPizzaCreated})
+	require.NoError(t, err)
+
+	keys := apikey.NewService(apikey.NewMemoryStore(), clock)
+	key, _, err := keys.Create("ci", []string{auth.ScopePizzaRead}, 0)
+	require.NoError(t, err)
+
+	router := NewRouter(
+		WithPizzaService(pizzas),
+		WithClock(clock),
+		WithTokenValidator(validator),
+		WithAPIKeys(keys),
+		WithWebhooks(hooks),
+		WithAuditStore(audit.NewMemoryStore(10)),
+		WithStream(sse.NewBroker(10)),
+		WithSnapshots(snapshot.NewManager(pizzas, filepath.Join(t.TempDir(), "snapshot.json"), Log)),
+	)
+
+	return &authorizationFixture{router: router, hook: hook.ID, key: key.ID, snapshot: string(snapshotBody)}
+}
+
+// request sends the request of the route with the given Authorization header, if any.
+func (f *authorizationFixture) request(rc routeCase, authorization string) *httptest.ResponseRecorder {
+	path := rc.path
+	switch {
+	case strings.HasPrefix(path, "/v1/webhooks"):
+		path = strings.Replace(path, ":id", f.hook, 1)
+	case strings.HasPrefix(path, "/v1/admin/apikeys"):
+		path = strings.Replace(path, ":id", f.key, 1)
+	}
+	path = strings.NewReplacer(":name", "margherita", ":revision", "1").Replace(path)
+	body := strings.Replace(rc.body, "{{snapshot}}", f.snapshot, 1)
+
+	req := httptest.NewRequest(rc.method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	if strings.HasSuffix(path, "/stream") {
+		// the event stream only ends with the request
+		ctx, cancel := context.WithTimeout(req.Context(), 50*time.Millisecond)
+		defer cancel()
+		req = req.WithContext(ctx)
+	}
+
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func bearer(t *testing.T, roles []string, scopes []string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "tester",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+		"scope": strings.Join(scopes, " "),
+	})
+	signed, err := token.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+	return "Bearer " + signed
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func without(values []string, excluded []string) []string {
+	result := []string{}
+	for _, v := range values {
+		if !contains(excluded, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func assertAllowed(t *testing.T, rc routeCase, rec *httptest.ResponseRecorder) {
+	assert.Equal(t, rc.status, rec.Code, rec.Body.String())
+}
+
+func assertForbidden(t *testing.T, rc routeCase, rec *httptest.ResponseRecorder) {
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), fmt.Sprintf("%s %s requires", rc.method, rc.path))
+}
+
+func assertUnauthenticated(t *testing.T, rec *httptest.ResponseRecorder) {
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+}
+
+func TestRouteCasesCoverRouteTable(t *testing.T) {
+	router := newAuthorizationFixture(t).router.(*router)
+
+	covered := map[string]bool{}
+	for _, rc := range routeCases {
+		covered[rc.method+" "+rc.path] = true
+	}
+	for _, registered := range router.echo.Routes() {
+		// groups with middlewares register catch-all routes that are never authorized
+		if !strings.HasPrefix(registered.Path, "/v1") || strings.HasPrefix(registered.Name, "github.com/labstack/echo.(*Group).Use") {
+			continue
+		}
+		assert.True(t, covered[registered.Method+" "+registered.Path], "%s %s has no authorization test", registered.Method, registered.Path)
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	for _, rc := range routeCases {
+		rc := rc
+		t.Run(rc.method+" "+rc.path, func(t *testing.T) {
+			t.Run("anonymous", func(t *testing.T) {
+				rec := newAuthorizationFixture(t).request(rc, "")
+				if rc.policy.Public {
+					assertAllowed(t, rc, rec)
+				} else {
+					assertUnauthenticated(t, rec)
+				}
+			})
+
+			for _, scope := range auth.Scopes {
+				scope := scope
+				t.Run("scope "+scope, func(t *testing.T) {
+					rec := newAuthorizationFixture(t).request(rc, bearer(t, nil, []string{scope}))
+					if rc.policy.Public || contains(rc.policy.Scopes, scope) {
+						assertAllowed(t, rc, rec)
+					} else {
+						assertForbidden(t, rc, rec)
+					}
+				})
+			}
+
+			for _, role := range allRoles {
+				role := role
+				t.Run("role "+role, func(t *testing.T) {
+					rec := newAuthorizationFixture(t).request(rc, bearer(t, []string{role}, nil))
+					if rc.policy.Public || contains(rc.policy.Roles, role) {
+						assertAllowed(t, rc, rec)
+					} else {
+						assertForbidden(t, rc, rec)
+					}
+				})
+			}
+
+			if !rc.policy.Public {
+				t.Run("all other scopes", func(t *testing.T) {
+					rec := newAuthorizationFixture(t).request(rc, bearer(t, nil, without(auth.Scopes, rc.policy.Scopes)))
+					assertForbidden(t, rc, rec)
+				})
+			}
+		})
+	}
+}
+
+func TestRequestsWithoutTokenContinueAnonymously(t *testing.T) {
+	fixture := newAuthorizationFixture(t)
+	list := routeCase{method: http.MethodGet, path: "/v1/pizza", policy: readPizza, status: http.StatusOK}
+	create := routeCase{method: http.MethodPost, path: "/v1/pizza", body: `{"name":"funghi","ingredients":[]}`, policy: writePizza}
+
+	assertAllowed(t, list, fixture.request(list, ""))
+	assertUnauthenticated(t, fixture.request(create, ""))
+
+	// a header that is present must hold a valid token, even for public routes
+	assert.Equal(t, http.StatusUnauthorized, fixture.request(list, "Basic dGVzdGVyOnNlY3JldA==").Code)
+	assert.Equal(t, http.StatusUnauthorized, fixture.request(list, "Bearer invalid").Code)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"golang-microservice-template/config"
+	"sync"
+
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+)
+
+// cors applies the CORS settings of the configuration and can be updated at runtime.
+type cors struct {
+	middleware echo.MiddlewareFunc
+	origins    []string
+	sync.RWMutex
+}
+
+func newCORS(cfg config.CORSConfig) *cors {
+	c := &cors{}
+	c.update(cfg)
+	return c
+}
+
+// update replaces the CORS settings. CORS is disabled if no origin is allowed.
+func (c *cors) update(cfg config.CORSConfig) {
+	var m echo.MiddlewareFunc
+	if len(cfg.AllowOrigins) > 0 {
+		m = middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins:     cfg.AllowOrigins,
+			AllowMethods:     cfg.AllowMethods,
+			AllowHeaders:     cfg.AllowHeaders,
+			ExposeHeaders:    cfg.ExposeHeaders,
+			AllowCredentials: cfg.AllowCredentials,
+			MaxAge:           cfg.MaxAge,
+		})
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	c.middleware = m
+	c.origins = cfg.AllowOrigins
+}
+
+// allows returns true if browser clients of the origin may call the service.
+func (c *cors) allows(origin string) bool {
+	c.RLock()
+	defer c.RUnlock()
+
+	for _, o := range c.origins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		c.RLock()
+		m := c.middleware
+		c.RUnlock()
+
+		if m == nil {
+			return next(ctx)
+		}
+		return m(next)(ctx)
+	}
+}
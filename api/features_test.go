@@ -0,0 +1,61 @@
+package api
+
+import (
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyFeatureIsReloaded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig := func(readOnly string) {
+		require.NoError(t, ioutil.WriteFile(path, []byte("features:\n  readOnly: "+readOnly+"\n"), 0600))
+	}
+	writeConfig("false")
+
+	args := []string{"-config", path}
+	cfg, err := config.Load(args)
+	require.NoError(t, err)
+	manager := config.NewManager(cfg, args)
+
+	service := pizza.NewService(pizza.WithReadOnly(func() bool {
+		return manager.Current().FeatureEnabled(config.FeatureReadOnly)
+	}))
+	router := NewRouter(WithConfig(cfg), WithConfigManager(manager), WithPizzaService(service))
+
+	create := func(name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/pizza", strings.NewReader(`{"name":"`+name+`","ingredients":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	get := func(name string) int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/pizza/"+name, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusCreated, create("margherita").Code)
+
+	writeConfig("true")
+	require.NoError(t, manager.Reload())
+
+	rec := create("funghi")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), pizza.ErrReadOnly)
+	assert.Equal(t, http.StatusOK, get("margherita"))
+
+	writeConfig("false")
+	require.NoError(t, manager.Reload())
+
+	assert.Equal(t, http.StatusCreated, create("funghi").Code)
+}
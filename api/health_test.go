@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"golang-microservice-template/health"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLegacyHealthUsesReadinessChecks(t *testing.T) {
+	healthy := true
+	registry := health.NewRegistry(time.Second, 0, SystemClock())
+	registry.Register(health.NewChecker("dependency", func(context.Context) error {
+		if !healthy {
+			return errors.New("unreachable")
+		}
+		return nil
+	}))
+	router := NewRouter(WithHealthRegistry(registry))
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		return rec
+	}
+
+	rec := get()
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+
+	healthy = false
+	assert.Equal(t, http.StatusServiceUnavailable, get().Code)
+
+	registry.SetShuttingDown()
+	healthy = true
+	assert.Equal(t, http.StatusServiceUnavailable, get().Code)
+}
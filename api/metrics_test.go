@@ -0,0 +1,39 @@
+package api
+
+import (
+	"encoding/json"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/cache"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRouteRequiresAuthentication(t *testing.T) {
+	router := NewRouter(WithCache(cache.NewInstrumentedCache("pizza", cache.NewLRUCache(10, SystemClock()))))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/metrics", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMetricsServeOnlyCacheStatistics(t *testing.T) {
+	cache.NewInstrumentedCache("pizza", cache.NewLRUCache(10, SystemClock()))
+	fixture := newAuthorizationFixture(t)
+	rc := routeCase{method: http.MethodGet, path: "/v1/admin/metrics"}
+
+	rec := fixture.request(rc, bearer(t, nil, []string{auth.ScopeMetricsRead}))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	metrics := map[string]map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &metrics))
+	assert.Contains(t, metrics, "pizza")
+	assert.Contains(t, metrics["pizza"], "hitRatio")
+	assert.NotContains(t, rec.Body.String(), "memstats")
+	assert.NotContains(t, rec.Body.String(), "cmdline")
+}
@@ -2,7 +2,9 @@
 
 package api
 
+import context "context"
 import echo "github.com/labstack/echo"
+import http "net/http"
 import mock "github.com/stretchr/testify/mock"
 
 // MockRouter is an autogenerated mock type for the Router type
@@ -38,9 +40,51 @@ func (_m *MockRouter) Index(_a0 echo.Context) error {
 	return r0
 }
 
-// Shutdown provides a mock function with given fields:
-func (_m *MockRouter) Shutdown() {
-	_m.Called()
+// Liveness provides a mock function with given fields: _a0
+func (_m *MockRouter) Liveness(_a0 echo.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Readiness provides a mock function with given fields: _a0
+func (_m *MockRouter) Readiness(_a0 echo.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ServeHTTP provides a mock function with given fields: w, req
+func (_m *MockRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	_m.Called(w, req)
+}
+
+// Shutdown provides a mock function with given fields: ctx
+func (_m *MockRouter) Shutdown(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
 // Start provides a mock function with given fields: address
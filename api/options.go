@@ -0,0 +1,145 @@
+package api
+
+import (
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/cache"
+	"golang-microservice-template/config"
+	"golang-microservice-template/health"
+	"golang-microservice-template/idempotency"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/ratelimit"
+	"golang-microservice-template/snapshot"
+	"golang-microservice-template/sse"
+	"golang-microservice-template/tlsutil"
+	. "golang-microservice-template/utils"
+	"golang-microservice-template/webhook"
+)
+
+// RouterOption sets a dependency of the router.
+type RouterOption func(*router)
+
+// WithConfig sets the service configuration, defaults to config.Default().
+func WithConfig(cfg *config.Config) RouterOption {
+	return func(r *router) {
+		r.config = cfg
+	}
+}
+
+// WithConfigManager applies configuration changes at runtime, e.g. the CORS settings.
+func WithConfigManager(manager config.Manager) RouterOption {
+	return func(r *router) {
+		r.configManager = manager
+	}
+}
+
+// WithIdempotencyStore enables the Idempotency-Key support of all POST and PATCH routes.
+func WithIdempotencyStore(store idempotency.Store) RouterOption {
+	return func(r *router) {
+		r.idempotency = store
+	}
+}
+
+// WithPizzaService sets the service that executes the pizza use cases of the REST and WebSocket APIs,
+// defaults to a service with an in-memory repository.
+func WithPizzaService(service pizza.Service) RouterOption {
+	return func(r *router) {
+		r.pizzas = service
+	}
+}
+
+// WithController sets the controller that handles the pizza routes,
+// defaults to a controller of the pizza service.
+func WithController(controller pizza.Controller) RouterOption {
+	return func(r *router) {
+		r.controller = controller
+	}
+}
+
+// WithHealthRegistry sets the registry of readiness checks, defaults to an empty registry.
+func WithHealthRegistry(registry health.Registry) RouterOption {
+	return func(r *router) {
+		r.health = registry
+	}
+}
+
+// WithLogger sets the logger, defaults to the global Log instance.
+func WithLogger(log LogWriter) RouterOption {
+	return func(r *router) {
+		r.log = log
+	}
+}
+
+// WithTokenValidator enables the bearer token authentication of all /v1 routes.
+func WithTokenValidator(validator auth.TokenValidator) RouterOption {
+	return func(r *router) {
+		r.tokenValidator = validator
+	}
+}
+
+// WithAPIKeys enables the API key authentication of all /v1 routes and the key administration routes.
+func WithAPIKeys(service apikey.Service) RouterOption {
+	return func(r *router) {
+		r.apiKeys = service
+	}
+}
+
+// WithClientVerifier enables the client certificate authentication of all /v1 routes,
+// see config.MTLSConfig. It requires HTTPS.
+func WithClientVerifier(verifier tlsutil.ClientVerifier) RouterOption {
+	return func(r *router) {
+		r.clientVerifier = verifier
+	}
+}
+
+// WithCache enables the response cache of the pizza read routes. The pizza service must invalidate
+// the same cache, see pizza.WithCache.
+func WithCache(c cache.Cache) RouterOption {
+	return func(r *router) {
+		r.cache = c
+	}
+}
+
+// WithSnapshots enables the admin routes to write, download and restore snapshots of the pizzas.
+func WithSnapshots(manager snapshot.Manager) RouterOption {
+	return func(r *router) {
+		r.snapshots = manager
+	}
+}
+
+// WithAuditStore enables the route to query the audit log.
+func WithAuditStore(store audit.Store) RouterOption {
+	return func(r *router) {
+		r.auditStore = store
+	}
+}
+
+// WithWebhooks enables the routes to manage webhook subscriptions.
+func WithWebhooks(service webhook.Service) RouterOption {
+	return func(r *router) {
+		r.webhooks = service
+	}
+}
+
+// WithStream enables the Server-Sent Events stream and the WebSocket API of pizza changes.
+// The broker is closed on shutdown.
+func WithStream(broker sse.Broker) RouterOption {
+	return func(r *router) {
+		r.stream = broker
+	}
+}
+
+// WithClock sets the clock that provides the current time.
+func WithClock(clock Clock) RouterOption {
+	return func(r *router) {
+		r.clock = clock
+	}
+}
+
+// WithRateLimiter enables the rate limiting of all /v1 routes.
+func WithRateLimiter(limiter ratelimit.Limiter) RouterOption {
+	return func(r *router) {
+		r.rateLimiter = limiter
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRouterUsesInjectedController(t *testing.T) {
+	controller := &pizza.MockController{}
+	controller.On("GetAll", mock.Anything).Return(func(ctx echo.Context) error {
+		return ctx.String(http.StatusTeapot, "mocked")
+	})
+	router := NewRouter(WithController(controller))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/pizza", nil))
+
+	assert.Equal(t, http.StatusTeapot, rec.Code)
+	assert.Equal(t, "mocked", rec.Body.String())
+	controller.AssertExpectations(t)
+}
+
+func TestRouterUsesInjectedService(t *testing.T) {
+	service := &pizza.MockService{}
+	service.On("Get", "margherita").Return(&pizza.PizzaDto{Name: "margherita", Revision: 7}, nil)
+	router := NewRouter(WithPizzaService(service))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/pizza/margherita", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"revision":7`)
+	service.AssertExpectations(t)
+}
+
+func TestRouterDefaultServiceUsesConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Pizza.MaxIngredients = 1
+	router := NewRouter(WithConfig(cfg))
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/pizza", strings.NewReader(`{"name":"margherita","ingredients":[{"name":"a"},{"name":"b"}]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "must not have more than 1 ingredients")
+}
+
+func TestRouterUsesInjectedClock(t *testing.T) {
+	at := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	clock := &MockClock{}
+	clock.On("Now").Return(at)
+	service := pizza.NewService(pizza.WithClock(clock))
+	router := NewRouter(WithPizzaService(service), WithClock(clock))
+	req := httptest.NewRequest(http.MethodPost, "/v1/pizza", strings.NewReader(`{"name":"margherita","ingredients":[]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"updatedAt":"2020-01-31T12:00:00Z"`)
+}
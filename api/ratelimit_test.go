@@ -0,0 +1,48 @@
+package api
+
+import (
+	"golang-microservice-template/auth"
+	"golang-microservice-template/config"
+	"golang-microservice-template/ratelimit"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailedAuthenticationCountsAgainstTheAddress(t *testing.T) {
+	clock := &MockClock{}
+	clock.On("Now").Return(time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC))
+	validator, err := auth.NewJWTValidator(config.JWTConfig{Enabled: true, Algorithm: "HS256", Secret: testSecret}, SystemClock())
+	require.NoError(t, err)
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), clock, map[string]ratelimit.Limit{
+		ratelimit.ClassAddress: {Rate: 1, Burst: 3},
+		ratelimit.ClassRead:    {Rate: 100, Burst: 100},
+	})
+	router := NewRouter(WithTokenValidator(validator), WithRateLimiter(limiter), WithClock(clock))
+
+	request := func(remoteAddr, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pizza", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, request("192.0.2.1:1234", "Bearer guessed").Code)
+	}
+	rec := request("192.0.2.1:1234", "Bearer guessed")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "guessing is limited before the token is checked")
+	assert.NotEmpty(t, rec.Header().Get(ratelimit.HeaderRetryAfter))
+	assert.Equal(t, http.StatusTooManyRequests, request("192.0.2.1:4321", bearer(t, nil, nil)).Code,
+		"a valid token does not bypass the limit of the address")
+
+	assert.Equal(t, http.StatusUnauthorized, request("192.0.2.2:1234", "Bearer guessed").Code, "other addresses have their own bucket")
+	assert.Equal(t, http.StatusOK, request("192.0.2.3:1234", bearer(t, nil, nil)).Code)
+}
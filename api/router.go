@@ -2,9 +2,24 @@ package api
 
 import (
 	"context"
+	"fmt"
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/cache"
+	"golang-microservice-template/config"
+	"golang-microservice-template/health"
+	"golang-microservice-template/idempotency"
 	"golang-microservice-template/pizza"
+	"golang-microservice-template/ratelimit"
+	"golang-microservice-template/snapshot"
+	"golang-microservice-template/sse"
+	"golang-microservice-template/tlsutil"
 	. "golang-microservice-template/utils"
+	"golang-microservice-template/webhook"
+	"golang-microservice-template/ws"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/labstack/echo"
@@ -13,33 +28,104 @@ import (
 
 // Router is used to start and set up an HTTP server.
 type Router interface {
-	// Health returns HTTP status 204 to indicate a healthy service.
+	// Health returns HTTP status 204 if the service is ready and 503 otherwise, like Readiness without the report.
+	//
+	// Deprecated: probes should use Readiness or Liveness, which report the checks.
 	Health(echo.Context) error
+	// Liveness returns HTTP status 200 as long as the process is able to serve requests at all.
+	Liveness(echo.Context) error
+	// Readiness returns a report of all dependency checks with HTTP status 200 if the service is ready
+	// to receive traffic and 503 if a check fails or the service is shutting down.
+	Readiness(echo.Context) error
 	// Index returns a message indicating that the service is running.
 	Index(echo.Context) error
 	// Start starts listening for incoming requests on the specified address/port.
+	// The server uses HTTPS if a certificate is configured and requests client certificates if a
+	// ClientVerifier is set.
 	Start(address string) error
-	// Graceful server shutdown
-	Shutdown()
+	// ServeHTTP handles a request without starting a server, e.g. with httptest.
+	ServeHTTP(w http.ResponseWriter, req *http.Request)
+	// Shutdown marks the service as not ready, waits for the drain period and stops the server gracefully.
+	// Event streams are closed, other in-flight requests are finished until the context is done.
+	Shutdown(ctx context.Context) error
 }
 
 type router struct {
-	echo *echo.Echo
+	echo           *echo.Echo
+	health         health.Registry
+	pizzas         pizza.Service
+	controller     pizza.Controller
+	tokenValidator auth.TokenValidator
+	apiKeys        apikey.Service
+	clientVerifier tlsutil.ClientVerifier
+	auditStore     audit.Store
+	webhooks       webhook.Service
+	stream         sse.Broker
+	idempotency    idempotency.Store
+	cache          cache.Cache
+	snapshots      snapshot.Manager
+	rateLimiter    ratelimit.Limiter
+	configManager  config.Manager
+	cors           *cors
+	webSockets     sync.WaitGroup
+	clock          Clock
+	log            LogWriter
+	config         *config.Config
 }
 
-// NewRouter initializes a new router.
-func NewRouter() Router {
+// NewRouter initializes a new router. Dependencies that are not given as option
+// are set to pre-defined values, see RouterOption.
+func NewRouter(options ...RouterOption) Router {
 	r := &router{}
+	for _, option := range options {
+		option(r)
+	}
+
+	if r.config == nil {
+		r.config = config.Default()
+	}
+	if r.pizzas == nil {
+		r.pizzas = pizza.NewService(pizza.WithConfig(r.config.Pizza))
+	}
+	if r.controller == nil {
+		r.controller = pizza.NewController(r.pizzas)
+	}
+	if r.log == nil {
+		r.log = Log
+	}
+	if r.clock == nil {
+		r.clock = SystemClock()
+	}
+	if r.health == nil {
+		r.health = health.NewRegistry(r.config.Health.CheckTimeout, r.config.Health.CacheTTL, r.clock)
+	}
+
 	r.echo = echo.New()
 	r.echo.HideBanner = true
 
-	if Environment() == ENV_DEV {
+	if r.config.Environment == ENV_DEV {
 		r.echo.Debug = true
 	}
 
+	r.cors = newCORS(r.config.CORS)
+	if r.configManager != nil {
+		r.configManager.OnChange(func(_, next *config.Config) {
+			r.cors.update(next.CORS)
+		})
+	}
+
 	r.echo.Pre(middleware.RemoveTrailingSlash())
 	r.echo.Use(middleware.Recover())
 	r.echo.Use(middleware.RequestID())
+	r.echo.Use(ratelimit.ClientIP(r.trustedProxies()))
+	r.echo.Use(r.cors.handle)
+	r.echo.Use(middleware.SecureWithConfig(middleware.SecureConfig{
+		XSSProtection:         middleware.DefaultSecureConfig.XSSProtection,
+		ContentTypeNosniff:    middleware.DefaultSecureConfig.ContentTypeNosniff,
+		XFrameOptions:         r.config.Security.FrameOptions,
+		HSTSMaxAge:            r.config.Security.HSTSMaxAge,
+		ContentSecurityPolicy: r.config.Security.ContentSecurityPolicy,
+	}))
 
 	r.echo.Validator = NewValidator()
 
@@ -51,39 +137,298 @@ func NewRouter() Router {
 }
 
 func (r *router) Start(address string) error {
-	return r.echo.Start(address)
+	if !r.config.Server.TLS.Enabled() {
+		return r.echo.Start(address)
+	}
+
+	tlsConfig, err := tlsutil.NewServerConfig(r.config.Server.TLS, r.clientVerifier != nil)
+	if err != nil {
+		return err
+	}
+
+	server := r.echo.TLSServer
+	server.Addr = address
+	server.TLSConfig = tlsConfig
+
+	return r.echo.StartServer(server)
+}
+
+func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.echo.ServeHTTP(w, req)
 }
 
 func (*router) Index(ctx echo.Context) error {
 	return ctx.String(http.StatusOK, "service running")
 }
 
-func (*router) Health(ctx echo.Context) error {
+func (r *router) Health(ctx echo.Context) error {
+	ctx.Response().Header().Set("Deprecation", "true")
+	ctx.Response().Header().Set("Link", `</health/ready>; rel="successor-version"`)
+	if !r.health.Check(ctx.Request().Context()).Healthy() {
+		return ctx.NoContent(http.StatusServiceUnavailable)
+	}
 	return ctx.NoContent(http.StatusNoContent)
 }
 
+func (*router) Liveness(ctx echo.Context) error {
+	return ctx.JSON(http.StatusOK, &health.Report{Status: health.StatusUp, Checks: []health.Result{}})
+}
+
+func (r *router) Readiness(ctx echo.Context) error {
+	report := r.health.Check(ctx.Request().Context())
+	if !report.Healthy() {
+		return ctx.JSON(http.StatusServiceUnavailable, report)
+	}
+	return ctx.JSON(http.StatusOK, report)
+}
+
+// route declares an endpoint together with the policy that decides who may call it
+// and the class of its rate limit.
+type route struct {
+	method  string
+	path    string
+	handler echo.HandlerFunc
+	policy  auth.Policy
+	limit   string
+}
+
+// Policies of the pizza routes. Admins may do everything editors may do.
+var (
+	readPizza   = auth.Policy{Public: true}
+	writePizza  = auth.Policy{Roles: []string{auth.RoleMenuEditor, auth.RoleAdmin}, Scopes: []string{auth.ScopePizzaWrite}}
+	deletePizza = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopePizzaDelete}}
+	adminKeys   = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopeAPIKeyAdmin}}
+	readAudit   = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopeAuditRead}}
+	adminHooks  = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopeWebhookAdmin}}
+	readMetrics = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopeMetricsRead}}
+	adminSnaps  = auth.Policy{Roles: []string{auth.RoleAdmin}, Scopes: []string{auth.ScopeSnapshotAdmin}}
+)
+
 func (r *router) setRoutes(echo *echo.Echo) {
-	controller := pizza.NewController()
+	controller := r.controller
 
 	echo.GET("/", r.Index)
 	echo.GET("/health", r.Health)
+	echo.GET("/health/live", r.Liveness)
+	echo.GET("/health/ready", r.Readiness)
 
 	v1 := echo.Group("/v1")
-	pizza := v1.Group("/pizza")
+	if r.rateLimiter != nil {
+		// failed authentication attempts count against the address
+		v1.Use(ratelimit.AddressMiddleware(r.rateLimiter))
+	}
+	if r.clientVerifier != nil {
+		v1.Use(auth.ClientCertificate(r.clientVerifier, r.config.Auth.MTLS))
+	}
+	if r.apiKeys != nil {
+		v1.Use(apikey.Middleware(r.apiKeys, r.config.Auth.APIKeys.Header))
+	}
+	if r.tokenValidator != nil {
+		v1.Use(auth.JWT(r.tokenValidator))
+	}
+
+	pizzas := v1.Group("/pizza")
+	if r.stream != nil {
+		r.addRoutes(pizzas, []route{
+			{http.MethodGet, "/stream", sse.Handler(r.stream, r.config.Stream.HeartbeatInterval), readPizza, ratelimit.ClassRead},
+		})
+	}
+
+	r.addRoutes(pizzas, []route{
+		{http.MethodPost, "", controller.Add, writePizza, ratelimit.ClassWrite},
+		{http.MethodGet, "", r.cached(controller.GetAll, allPizzasKey), readPizza, ratelimit.ClassRead},
+		{http.MethodGet, "/:name", r.cached(controller.GetByName, pizzaKey), readPizza, ratelimit.ClassRead},
+		{http.MethodGet, "/:name/revisions", controller.GetRevisions, readPizza, ratelimit.ClassRead},
+		{http.MethodPost, "/:name/revisions/:revision/revert", controller.Revert, writePizza, ratelimit.ClassWrite},
+		{http.MethodPatch, "/:name", controller.Update, writePizza, ratelimit.ClassWrite},
+		{http.MethodDelete, "/:name", controller.Delete, deletePizza, ratelimit.ClassWrite},
+	})
+
+	// The audit log is only served to authorized callers, without authentication the route does not exist.
+	if r.auditStore != nil && r.authenticationEnabled() {
+		events := audit.NewController(r.auditStore)
+		r.addRoutes(v1.Group("/audit"), []route{
+			{http.MethodGet, "", events.GetAll, readAudit, ratelimit.ClassRead},
+		})
+	}
+
+	if r.stream != nil {
+		r.addRoutes(v1, []route{
+			{http.MethodGet, "/ws", r.webSocket(), readPizza, ratelimit.ClassRead},
+		})
+	}
+
+	// Subscriptions are only managed by authorized callers, without authentication the routes do not exist.
+	if r.webhooks != nil && r.authenticationEnabled() {
+		hooks := webhook.NewController(r.webhooks)
+		r.addRoutes(v1.Group("/webhooks"), []route{
+			{http.MethodPost, "", hooks.Create, adminHooks, ratelimit.ClassWrite},
+			{http.MethodGet, "", hooks.GetAll, adminHooks, ratelimit.ClassRead},
+			{http.MethodGet, "/:id", hooks.GetByID, adminHooks, ratelimit.ClassRead},
+			{http.MethodPatch, "/:id", hooks.Update, adminHooks, ratelimit.ClassWrite},
+			{http.MethodDelete, "/:id", hooks.Delete, adminHooks, ratelimit.ClassWrite},
+			{http.MethodGet, "/:id/deliveries", hooks.GetDeliveries, adminHooks, ratelimit.ClassRead},
+		})
+	}
 
-	pizza.POST("", controller.Add)
-	pizza.GET("", controller.GetAll)
-	pizza.GET("/:name", controller.GetByName)
-	pizza.PATCH("/:name", controller.Update)
-	pizza.DELETE("/:name", controller.Delete)
+	// The metrics are only served to authorized callers, without authentication the route does not exist.
+	if r.authenticationEnabled() {
+		r.addRoutes(v1.Group("/admin/metrics"), []route{
+			{http.MethodGet, "", r.metrics, readMetrics, ratelimit.ClassRead},
+		})
+	}
+
+	// Snapshots contain all pizzas and replace them, without authentication the routes do not exist.
+	if r.snapshots != nil && r.authenticationEnabled() {
+		snapshots := snapshot.NewController(r.snapshots, r.clock, r.config.Snapshot.MaxUploadSize)
+		r.addRoutes(v1.Group("/admin/snapshots"), []route{
+			{http.MethodGet, "", snapshots.Get, adminSnaps, ratelimit.ClassRead},
+			{http.MethodPost, "", snapshots.Create, adminSnaps, ratelimit.ClassWrite},
+			{http.MethodPost, "/restore", snapshots.Restore, adminSnaps, ratelimit.ClassWrite},
+		})
+	}
+
+	if r.apiKeys != nil {
+		keys := apikey.NewController(r.apiKeys, r.clock)
+		r.addRoutes(v1.Group("/admin/apikeys"), []route{
+			{http.MethodPost, "", keys.Create, adminKeys, ratelimit.ClassWrite},
+			{http.MethodGet, "", keys.GetAll, adminKeys, ratelimit.ClassRead},
+			{http.MethodDelete, "/:id", keys.Revoke, adminKeys, ratelimit.ClassWrite},
+			{http.MethodPost, "/:id/rotate", keys.Rotate, adminKeys, ratelimit.ClassWrite},
+		})
+	}
+}
+
+// metrics returns the hits, misses and hit ratios of the caches as JSON.
+func (r *router) metrics(ctx echo.Context) error {
+	return ctx.JSONBlob(http.StatusOK, []byte(cache.Metrics()))
+}
+
+// cached serves the responses of a read route from the response cache, if it is enabled.
+func (r *router) cached(handler echo.HandlerFunc, key cache.KeyFunc) echo.HandlerFunc {
+	if r.cache == nil {
+		return handler
+	}
+
+	// Responses depend on the caller if authentication is enabled, shared caches must not store them.
+	cacheControl := "public"
+	if r.authenticationEnabled() {
+		cacheControl = "private"
+	}
+	if maxAge := r.config.Cache.MaxAge; maxAge > 0 {
+		cacheControl += fmt.Sprintf(", max-age=%d", maxAge)
+	} else {
+		cacheControl += ", no-cache"
+	}
+
+	return cache.Middleware(r.cache, r.config.Cache.TTL, cacheControl, key, r.log)(handler)
+}
+
+// allPizzasKey is the cache key of the list of all pizzas.
+func allPizzasKey(echo.Context) string {
+	return pizza.CacheKeyAll
+}
+
+// pizzaKey is the cache key of a pizza. Past states are not cached.
+func pizzaKey(ctx echo.Context) string {
+	if ctx.QueryParam(pizza.QueryParamAsOf) != "" {
+		return ""
+	}
+	return pizza.CacheKey(ctx.Param(pizza.PathParamName))
+}
+
+// webSocket returns the handler of the WebSocket API. Its commands are authorized and rate limited
+// like the corresponding pizza routes.
+func (r *router) webSocket() echo.HandlerFunc {
+	options := []ws.HandlerOption{
+		ws.WithOriginCheck(r.cors.allows),
+		ws.WithLogger(r.log),
+	}
+	if r.authenticationEnabled() {
+		options = append(options, ws.WithPolicies(map[string]auth.Policy{
+			ws.CommandSubscribe: readPizza,
+			ws.CommandList:      readPizza,
+			ws.CommandGet:       readPizza,
+			ws.CommandCreate:    writePizza,
+			ws.CommandUpdate:    writePizza,
+			ws.CommandDelete:    deletePizza,
+		}))
+	}
+	if r.rateLimiter != nil {
+		options = append(options, ws.WithRateLimiter(r.rateLimiter))
+	}
+
+	handler := ws.Handler(r.pizzas, r.stream, options...)
+	return func(ctx echo.Context) error {
+		r.webSockets.Add(1)
+		defer r.webSockets.Done()
+		return handler(ctx)
+	}
+}
+
+// addRoutes registers the routes in the group. Policies are only enforced if authentication is enabled,
+// rate limits only if a rate limiter is set.
+func (r *router) addRoutes(group *echo.Group, routes []route) {
+	for _, rt := range routes {
+		middlewares := []echo.MiddlewareFunc{}
+		if r.rateLimiter != nil {
+			middlewares = append(middlewares, ratelimit.Middleware(r.rateLimiter, rt.limit))
+		}
+		if r.authenticationEnabled() {
+			middlewares = append(middlewares, auth.Authorize(rt.policy))
+		}
+		if r.idempotency != nil && (rt.method == http.MethodPost || rt.method == http.MethodPatch) {
+			middlewares = append(middlewares, idempotency.Middleware(r.idempotency, r.clock, r.config.Idempotency.TTL, r.config.Idempotency.MaxBodySize))
+		}
+		group.Add(rt.method, rt.path, rt.handler, middlewares...)
+	}
+}
+
+// trustedProxies returns the configured reverse proxies. Invalid entries are rejected by the configuration
+// validation, if they still occur no proxy is trusted.
+func (r *router) trustedProxies() ratelimit.TrustedProxies {
+	proxies, err := ratelimit.ParseTrustedProxies(r.config.Server.TrustedProxies)
+	if err != nil {
+		r.log.Errorf("trusting no proxies: %v", err)
+		return nil
+	}
+	return proxies
 }
 
-// Shutdown is waiting some seconds to stop the server gracefully and to release resources.
-func (r *router) Shutdown() {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+func (r *router) authenticationEnabled() bool {
+	return r.tokenValidator != nil || r.apiKeys != nil || r.clientVerifier != nil
+}
+
+func (r *router) Shutdown(ctx context.Context) error {
+	r.health.SetShuttingDown()
+
+	if drain := r.config.Server.DrainPeriod; drain > 0 {
+		r.log.Infof("draining connections for %s", drain)
+		select {
+		case <-time.After(drain):
+		case <-ctx.Done():
+		}
+	}
+
+	// Streams never finish on their own, they are closed before the server waits for all requests.
+	if r.stream != nil {
+		r.stream.Close()
+	}
 
 	if err := r.echo.Shutdown(ctx); err != nil {
-		Log.Fatal(err)
+		return err
+	}
+
+	// WebSocket connections are hijacked, the server does not wait for them.
+	closed := make(chan struct{})
+	go func() {
+		r.webSockets.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"fmt"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/events"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// benchmarkPizzas is the number of pizzas stored before the benchmarks of existing pizzas run.
+const benchmarkPizzas = 100
+
+// drainInterval is the number of writes after which the benchmarks empty the outbox, it must stay below its capacity.
+const drainInterval = 1000
+
+// routerBenchmark is a router on an in-memory repository whose outbox the benchmark empties.
+type routerBenchmark struct {
+	router Router
+	outbox events.Outbox
+}
+
+func newRouterBenchmark(b *testing.B, pizzas int) *routerBenchmark {
+	// the service logs every created pizza at debug level
+	log := Logger()
+	level, err := ParseLogLevel("warn")
+	if err != nil {
+		b.Fatal(err)
+	}
+	log.SetLevel(level)
+
+	repository := pizza.NewRepository(SystemClock())
+	service := pizza.NewService(pizza.WithRepository(repository), pizza.WithLogger(log))
+	bench := &routerBenchmark{router: NewRouter(WithPizzaService(service), WithLogger(log)), outbox: repository.Outbox()}
+
+	for i := 0; i < pizzas; i++ {
+		if _, err := service.Create(audit.Origin{}, &pizza.PizzaDto{Name: pizzaName(i), Ingredient: benchmarkIngredients()}); err != nil {
+			b.Fatal(err)
+		}
+		if (i+1)%drainInterval == 0 {
+			bench.drain(b)
+		}
+	}
+	bench.drain(b)
+	return bench
+}
+
+// drain marks all pending events published, so benchmarks can write more often than the outbox holds events.
+func (bench *routerBenchmark) drain(b *testing.B) {
+	for {
+		pending, err := bench.outbox.Pending(SystemClock().Now(), drainInterval)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(pending) == 0 {
+			return
+		}
+		for _, entry := range pending {
+			if err := bench.outbox.MarkPublished(entry.Event.ID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// serve sends a request and fails the benchmark unless it is answered with status.
+func (bench *routerBenchmark) serve(b *testing.B, method string, path string, body string, status int) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+
+	bench.router.ServeHTTP(rec, req)
+
+	if rec.Code != status {
+		b.Fatalf("%s %s answered %d, expected %d: %s", method, path, rec.Code, status, rec.Body.String())
+	}
+}
+
+// run runs request b.N times and empties the outbox every drainInterval requests outside of the measurement.
+func (bench *routerBenchmark) run(b *testing.B, request func(i int)) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		request(i)
+		if (i+1)%drainInterval == 0 {
+			b.StopTimer()
+			bench.drain(b)
+			b.StartTimer()
+		}
+	}
+}
+
+func benchmarkIngredients() []pizza.IngredientDto {
+	return []pizza.IngredientDto{{Name: "tomato", Count: 1}, {Name: "mozzarella", Count: 2}, {Name: "basil", Count: 3}}
+}
+
+func pizzaName(i int) string {
+	return fmt.Sprintf("pizza-%d", i)
+}
+
+func pizzaBody(name string) string {
+	return `{"name":"` + name + `","ingredients":[{"name":"tomato","count":1},{"name":"mozzarella","count":2},{"name":"basil","count":3}]}`
+}
+
+func BenchmarkCreatePizza(b *testing.B) {
+	bench := newRouterBenchmark(b, 0)
+
+	bench.run(b, func(i int) {
+		bench.serve(b, http.MethodPost, "/v1/pizza", pizzaBody(pizzaName(i)), http.StatusCreated)
+	})
+}
+
+func BenchmarkGetAllPizzas(b *testing.B) {
+	bench := newRouterBenchmark(b, benchmarkPizzas)
+
+	bench.run(b, func(int) {
+		bench.serve(b, http.MethodGet, "/v1/pizza", "", http.StatusOK)
+	})
+}
+
+func BenchmarkGetPizza(b *testing.B) {
+	bench := newRouterBenchmark(b, benchmarkPizzas)
+
+	bench.run(b, func(i int) {
+		bench.serve(b, http.MethodGet, "/v1/pizza/"+pizzaName(i%benchmarkPizzas), "", http.StatusOK)
+	})
+}
+
+func BenchmarkUpdatePizza(b *testing.B) {
+	bench := newRouterBenchmark(b, benchmarkPizzas)
+
+	bench.run(b, func(i int) {
+		name := pizzaName(i % benchmarkPizzas)
+		bench.serve(b, http.MethodPatch, "/v1/pizza/"+name, pizzaBody(name), http.StatusOK)
+	})
+}
+
+func BenchmarkDeletePizza(b *testing.B) {
+	bench := newRouterBenchmark(b, b.N)
+
+	bench.run(b, func(i int) {
+		bench.serve(b, http.MethodDelete, "/v1/pizza/"+pizzaName(i), "", http.StatusNoContent)
+	})
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/tls"
+	"golang-microservice-template/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newSecurityRouter returns a router with the default configuration changed by change and the listeners the router
+// registered at the configuration manager.
+func newSecurityRouter(change func(cfg *config.Config)) (Router, *[]config.Listener) {
+	cfg := config.Default()
+	change(cfg)
+	listeners := &[]config.Listener{}
+	manager := &config.MockManager{}
+	manager.On("OnChange", mock.Anything).Run(func(args mock.Arguments) {
+		*listeners = append(*listeners, args.Get(0).(config.Listener))
+	})
+
+	return NewRouter(WithConfig(cfg), WithConfigManager(manager)), listeners
+}
+
+func preflight(router Router, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, "/v1/pizza", nil)
+	req.Header.Set(echo.HeaderOrigin, origin)
+	req.Header.Set(echo.HeaderAccessControlRequestMethod, http.MethodPost)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCORSAllowsConfiguredOrigins(t *testing.T) {
+	router, _ := newSecurityRouter(func(cfg *config.Config) {
+		cfg.CORS.AllowOrigins = []string{"https://menu.example.com"}
+	})
+
+	rec := preflight(router, "https://menu.example.com")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "https://menu.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "GET,HEAD,POST,PATCH,DELETE", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+	assert.Contains(t, rec.Header().Get(echo.HeaderAccessControlAllowHeaders), "Idempotency-Key")
+	assert.Equal(t, "600", rec.Header().Get(echo.HeaderAccessControlMaxAge))
+
+	rec = preflight(router, "https://evil.example.com")
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pizza", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://menu.example.com")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "https://menu.example.com", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Contains(t, rec.Header().Get(echo.HeaderAccessControlExposeHeaders), "RateLimit-Remaining")
+}
+
+func TestCORSIsDisabledWithoutOrigins(t *testing.T) {
+	router, _ := newSecurityRouter(func(*config.Config) {})
+
+	rec := preflight(router, "https://menu.example.com")
+
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+}
+
+func TestCORSChangesAreAppliedAtRuntime(t *testing.T) {
+	cfg := config.Default()
+	router, listeners := newSecurityRouter(func(*config.Config) {})
+
+	next := config.Default()
+	next.CORS.AllowOrigins = []string{"https://menu.example.com"}
+	for _, listener := range *listeners {
+		listener(cfg, next)
+	}
+	assert.Equal(t, "https://menu.example.com", preflight(router, "https://menu.example.com").Header().Get(echo.HeaderAccessControlAllowOrigin))
+
+	for _, listener := range *listeners {
+		listener(next, cfg)
+	}
+	assert.Empty(t, preflight(router, "https://menu.example.com").Header().Get(echo.HeaderAccessControlAllowOrigin))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	router, _ := newSecurityRouter(func(cfg *config.Config) {
+		cfg.Security.FrameOptions = "SAMEORIGIN"
+	})
+
+	for name, test := range map[string]struct {
+		tls  bool
+		hsts string
+	}{
+		"http":  {tls: false, hsts: ""},
+		"https": {tls: true, hsts: "max-age=31536000; includeSubdomains"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/pizza/missing", nil)
+			if test.tls {
+				req.TLS = &tls.ConnectionState{HandshakeComplete: true}
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusNotFound, rec.Code, "errors carry the headers as well")
+			assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+			assert.Equal(t, "1; mode=block", rec.Header().Get(echo.HeaderXXSSProtection))
+			assert.Equal(t, "SAMEORIGIN", rec.Header().Get(echo.HeaderXFrameOptions))
+			assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+			assert.Equal(t, test.hsts, rec.Header().Get(echo.HeaderStrictTransportSecurity))
+		})
+	}
+}
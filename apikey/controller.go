@@ -0,0 +1,127 @@
+package apikey
+
+import (
+	. "golang-microservice-template/utils"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// PathParamID is the request path parameter that holds the key id.
+	PathParamID = "id"
+)
+
+// errors
+var (
+	ErrParamIDMissing = "missing api key id in path"
+	ErrExpiryInPast   = "expiresAt must be in the future"
+)
+
+// Controller handles the admin requests to manage API keys.
+type Controller interface {
+	// Create generates a new key and returns its secret once.
+	Create(echo.Context) error
+	// GetAll returns all keys without their secrets.
+	GetAll(echo.Context) error
+	// Revoke disables a key.
+	Revoke(echo.Context) error
+	// Rotate replaces a key with a new one and returns the new secret once.
+	Rotate(echo.Context) error
+}
+
+type controller struct {
+	service Service
+	clock   Clock
+}
+
+// NewController creates a new Controller that manages the keys of the given service.
+func NewController(service Service, clock Clock) Controller {
+	return &controller{
+		service: service,
+		clock:   clock,
+	}
+}
+
+func (c *controller) Create(ctx echo.Context) error {
+	dto := &CreateKeyDto{}
+
+	if err := ctx.Bind(dto); err != nil {
+		return Error(err, ErrorTypeBinding)
+	}
+
+	if err := ctx.Validate(dto); err != nil {
+		return Error(err, ErrorTypeValidation)
+	}
+
+	var ttl time.Duration
+	if dto.ExpiresAt != nil {
+		if ttl = dto.ExpiresAt.Sub(c.clock.Now()); ttl <= 0 {
+			return Error(ErrExpiryInPast, ErrorTypeBadRequest)
+		}
+	}
+
+	key, secret, err := c.service.Create(dto.Owner, dto.Scopes, ttl)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	result := key.ConvertToDto()
+	result.Key = secret
+
+	return ctx.JSON(http.StatusCreated, result)
+}
+
+func (c *controller) GetAll(ctx echo.Context) error {
+	keys, err := c.service.List()
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	dtos := make([]*KeyDto, len(keys))
+	for i, key := range keys {
+		dtos[i] = key.ConvertToDto()
+	}
+
+	return ctx.JSON(http.StatusOK, dtos)
+}
+
+func (c *controller) Revoke(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, err := c.service.Revoke(id)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return ctx.JSON(http.StatusOK, key.ConvertToDto())
+}
+
+func (c *controller) Rotate(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, secret, err := c.service.Rotate(id)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	result := key.ConvertToDto()
+	result.Key = secret
+
+	return ctx.JSON(http.StatusCreated, result)
+}
+
+func checkIDInPath(ctx echo.Context) (string, error) {
+	id := ctx.Param(PathParamID)
+	if id == "" {
+		return "", Error(ErrParamIDMissing, ErrorTypeBadRequest)
+	}
+	return id, nil
+}
@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	"time"
+)
+
+// KeyDto represents the API key information that will be exposed from this service.
+type KeyDto struct {
+	ID         string     `json:"id"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RotatedTo  string     `json:"rotatedTo,omitempty"`
+	// Key is the secret key, it is only returned when a key is created or rotated.
+	Key string `json:"key,omitempty"`
+}
+
+// CreateKeyDto is the request to create a new API key.
+type CreateKeyDto struct {
+	Owner     string     `json:"owner" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"dive,required,max=255"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// ConvertToDto converts a Key model to a Key dto without the secret.
+func (k *Key) ConvertToDto() *KeyDto {
+	return &KeyDto{
+		ID:         k.ID,
+		Owner:      k.Owner,
+		Scopes:     append([]string{}, k.Scopes...),
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		RevokedAt:  k.RevokedAt,
+		LastUsedAt: k.LastUsedAt,
+		RotatedTo:  k.RotatedTo,
+	}
+}
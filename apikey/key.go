@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	keyPrefix  = "pzk_"
+	secretSize = 32
+	idSize     = 8
+)
+
+// Key is a persisted API key. Only the hash of the secret key is stored.
+type Key struct {
+	ID         string     `json:"id"`
+	Hash       string     `json:"hash"`
+	Owner      string     `json:"owner"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	// RotatedTo is the id of the key that replaced this key.
+	RotatedTo string `json:"rotatedTo,omitempty"`
+}
+
+// Active returns true if the key is neither revoked nor expired at the given time.
+func (k *Key) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// copy returns a deep copy of the key.
+func (k *Key) copy() *Key {
+	c := *k
+	c.Scopes = append([]string{}, k.Scopes...)
+	c.ExpiresAt = copyTime(k.ExpiresAt)
+	c.RevokedAt = copyTime(k.RevokedAt)
+	c.LastUsedAt = copyTime(k.LastUsedAt)
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+// Hash returns the hex encoded SHA-256 hash of a secret key.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateSecret returns a new random secret key.
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateID returns a new random key id.
+func generateID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	"golang-microservice-template/auth"
+
+	"github.com/labstack/echo"
+)
+
+// HeaderAPIKey is the default request header that holds the API key.
+const HeaderAPIKey = "X-API-Key"
+
+// ContextKeyAPIKey is the key of the authenticated API key in the echo context.
+const ContextKeyAPIKey = "apikey.key"
+
+// Middleware returns a middleware that authenticates requests with an API key in the given header.
+// Requests without the header continue anonymously, requests with an invalid key are rejected.
+func Middleware(service Service, header string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			secret := ctx.Request().Header.Get(header)
+			if secret == "" {
+				return next(ctx)
+			}
+
+			key, err := service.Authenticate(secret)
+			if err != nil {
+				return err
+			}
+
+			ctx.Set(ContextKeyAPIKey, key)
+			ctx.Set(auth.ContextKeyPrincipal, &auth.Principal{
+				Method:  auth.MethodAPIKey,
+				Subject: key.Owner,
+				Scopes:  key.Scopes,
+			})
+
+			return next(ctx)
+		}
+	}
+}
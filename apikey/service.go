@@ -0,0 +1,191 @@
+package apikey
+
+import (
+	"errors"
+	"golang-microservice-template/auth"
+	"time"
+
+	. "golang-microservice-template/utils"
+)
+
+// lastUsedResolution limits how often the last usage of a key is written to the store.
+const lastUsedResolution = time.Minute
+
+// errors
+var (
+	ErrInvalidKey   = "invalid api key"
+	ErrKeyRevoked   = "api key %s is revoked"
+	ErrUnknownScope = "unknown scope '%s'"
+)
+
+// Service manages the lifecycle of API keys and authenticates requests.
+type Service interface {
+	// Create generates a new key for the owner with the given scopes, which must be known scopes.
+	// A zero ttl creates a key that never expires. The secret key is returned only once, the store keeps its hash.
+	Create(owner string, scopes []string, ttl time.Duration) (*Key, string, error)
+	// List returns all keys.
+	List() ([]*Key, error)
+	// Revoke disables a key immediately.
+	Revoke(id string) (*Key, error)
+	// Rotate creates a new key with the owner, scopes and lifetime of an existing key and revokes the existing key.
+	Rotate(id string) (*Key, string, error)
+	// Authenticate returns the active key for a secret key and records its usage.
+	// All failures are errors of type ErrorTypeUnauthorized.
+	Authenticate(secret string) (*Key, error)
+	// Import adds a key with a known secret, e.g. a bootstrap admin key from the configuration.
+	// A key with the same id is replaced, but stays revoked if it was revoked.
+	Import(id, secret, owner string, scopes []string) error
+}
+
+type service struct {
+	store Store
+	clock Clock
+}
+
+// NewService creates a new Service that persists keys in the given store.
+func NewService(store Store, clock Clock) Service {
+	return &service{
+		store: store,
+		clock: clock,
+	}
+}
+
+func (s *service) Create(owner string, scopes []string, ttl time.Duration) (*Key, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, "", Error(err, ErrorTypeInternalServer)
+	}
+	id, err := generateID()
+	if err != nil {
+		return nil, "", Error(err, ErrorTypeInternalServer)
+	}
+
+	key := &Key{
+		ID:        id,
+		Hash:      Hash(secret),
+		Owner:     owner,
+		Scopes:    append([]string{}, scopes...),
+		CreatedAt: s.clock.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := key.CreatedAt.Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.store.Save(key); err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+func (s *service) List() ([]*Key, error) {
+	return s.store.FindAll()
+}
+
+func (s *service) Revoke(id string) (*Key, error) {
+	key, err := s.store.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if key.RevokedAt == nil {
+		now := s.clock.Now()
+		key.RevokedAt = &now
+		if err := s.store.Update(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+func (s *service) Rotate(id string) (*Key, string, error) {
+	old, err := s.store.FindByID(id)
+	if err != nil {
+		return nil, "", err
+	}
+	if old.RevokedAt != nil {
+		return nil, "", Errorf(ErrorTypeConflict, ErrKeyRevoked, id)
+	}
+
+	var ttl time.Duration
+	if old.ExpiresAt != nil {
+		ttl = old.ExpiresAt.Sub(old.CreatedAt)
+	}
+
+	key, secret, err := s.Create(old.Owner, old.Scopes, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := s.clock.Now()
+	old.RevokedAt = &now
+	old.RotatedTo = key.ID
+	if err := s.store.Update(old); err != nil {
+		return nil, "", err
+	}
+
+	return key, secret, nil
+}
+
+func (s *service) Authenticate(secret string) (*Key, error) {
+	key, err := s.store.FindByHash(Hash(secret))
+	if err != nil {
+		return nil, Error(ErrInvalidKey, ErrorTypeUnauthorized)
+	}
+
+	now := s.clock.Now()
+	if !key.Active(now) {
+		return nil, Error(ErrInvalidKey, ErrorTypeUnauthorized)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		key.LastUsedAt = &now
+		if err := s.store.TouchLastUsed(key.ID, now); err != nil {
+			Log.Warnf("failed to record usage of api key %s: %v", key.ID, err)
+		}
+	}
+
+	return key, nil
+}
+
+func (s *service) Import(id, secret, owner string, scopes []string) error {
+	if secret == "" {
+		return errors.New(ErrInvalidKey)
+	}
+	if err := validateScopes(scopes); err != nil {
+		return err
+	}
+
+	key := &Key{
+		ID:        id,
+		Hash:      Hash(secret),
+		Owner:     owner,
+		Scopes:    append([]string{}, scopes...),
+		CreatedAt: s.clock.Now(),
+	}
+
+	if existing, err := s.store.FindByID(id); err == nil {
+		key.CreatedAt = existing.CreatedAt
+		key.LastUsedAt = existing.LastUsedAt
+		key.RevokedAt = existing.RevokedAt
+		key.RotatedTo = existing.RotatedTo
+		return s.store.Update(key)
+	}
+	return s.store.Save(key)
+}
+
+// validateScopes returns a validation error for the first unknown scope.
+func validateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !auth.KnownScope(scope) {
+			return Errorf(ErrorTypeValidation, ErrUnknownScope, scope)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package apikey
+
+import (
+	"golang-microservice-template/auth"
+	. "golang-microservice-template/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportKeepsRevocation(t *testing.T) {
+	service := NewService(NewMemoryStore(), SystemClock())
+	require.NoError(t, service.Import("admin", "secret", "admin", []string{auth.ScopeAPIKeyAdmin}))
+	rotated, _, err := service.Rotate("admin")
+	require.NoError(t, err)
+
+	// a restart imports the configured key again
+	require.NoError(t, service.Import("admin", "secret", "admin", []string{auth.ScopeAPIKeyAdmin}))
+
+	_, err = service.Authenticate("secret")
+	assert.Error(t, err)
+	keys, err := service.List()
+	require.NoError(t, err)
+	for _, key := range keys {
+		if key.ID == "admin" {
+			assert.NotNil(t, key.RevokedAt)
+			assert.Equal(t, rotated.ID, key.RotatedTo)
+		}
+	}
+}
+
+func TestImportReplacesActiveKey(t *testing.T) {
+	service := NewService(NewMemoryStore(), SystemClock())
+	require.NoError(t, service.Import("admin", "old", "admin", []string{auth.ScopeAPIKeyAdmin}))
+	require.NoError(t, service.Import("admin", "new", "admin", []string{auth.ScopeAPIKeyAdmin}))
+
+	_, err := service.Authenticate("old")
+	assert.Error(t, err)
+	key, err := service.Authenticate("new")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", key.ID)
+}
+
+func TestCreateRejectsUnknownScopes(t *testing.T) {
+	service := NewService(NewMemoryStore(), SystemClock())
+
+	_, _, err := service.Create("ci", []string{auth.ScopePizzaRead, "pizza:admin"}, 0)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pizza:admin")
+	keys, _ := service.List()
+	assert.Empty(t, keys)
+
+	_, _, err = service.Create("ci", auth.Scopes, 0)
+	assert.NoError(t, err)
+}
+
+// interleavingStore runs between once before a key found by its hash is returned, like a concurrent request would.
+type interleavingStore struct {
+	Store
+	between func()
+}
+
+func (s *interleavingStore) FindByHash(hash string) (*Key, error) {
+	key, err := s.Store.FindByHash(hash)
+	if s.between != nil {
+		between := s.between
+		s.between = nil
+		between()
+	}
+	return key, err
+}
+
+func TestAuthenticateKeepsConcurrentRevocation(t *testing.T) {
+	store := &interleavingStore{Store: NewMemoryStore()}
+	service := NewService(store, SystemClock())
+	require.NoError(t, service.Import("ci", "secret", "ci", []string{auth.ScopePizzaRead}))
+	store.between = func() {
+		_, err := service.Revoke("ci")
+		require.NoError(t, err)
+	}
+
+	_, err := service.Authenticate("secret")
+	require.NoError(t, err, "the key was active when it was found")
+
+	key, err := store.FindByID("ci")
+	require.NoError(t, err)
+	assert.NotNil(t, key.RevokedAt, "recording the usage must not undo the revocation")
+	assert.NotNil(t, key.LastUsedAt)
+	_, err = service.Authenticate("secret")
+	assert.Error(t, err)
+}
+
+func TestAuthenticateKeepsConcurrentRotation(t *testing.T) {
+	store := &interleavingStore{Store: NewMemoryStore()}
+	service := NewService(store, SystemClock())
+	require.NoError(t, service.Import("ci", "secret", "ci", []string{auth.ScopePizzaRead}))
+	var rotated *Key
+	store.between = func() {
+		var err error
+		rotated, _, err = service.Rotate("ci")
+		require.NoError(t, err)
+	}
+
+	_, err := service.Authenticate("secret")
+	require.NoError(t, err)
+
+	key, err := store.FindByID("ci")
+	require.NoError(t, err)
+	assert.NotNil(t, key.RevokedAt)
+	assert.Equal(t, rotated.ID, key.RotatedTo)
+}
+
+func TestConcurrentAuthenticateAndRevoke(t *testing.T) {
+	service := NewService(NewMemoryStore(), SystemClock())
+	require.NoError(t, service.Import("ci", "secret", "ci", []string{auth.ScopePizzaRead}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, _ = service.Authenticate("secret")
+		}
+	}()
+	_, err := service.Revoke("ci")
+	require.NoError(t, err)
+	<-done
+
+	_, err = service.Authenticate("secret")
+	assert.Error(t, err, "the key stays revoked")
+}
@@ -0,0 +1,214 @@
+package apikey
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrKeyNotFound  = "api key %s not found"
+	ErrKeyIDTaken   = "api key '%s' already exists"
+	ErrReadKeyStore = "failed to read api key file %s: %v"
+)
+
+// Store persists API keys.
+type Store interface {
+	// FindAll returns all keys ordered by creation time.
+	FindAll() ([]*Key, error)
+	// FindByID finds a key by its id.
+	FindByID(id string) (*Key, error)
+	// FindByHash finds a key by the hash of its secret.
+	FindByHash(hash string) (*Key, error)
+	// Save persists a new key. The id must be unique.
+	Save(key *Key) error
+	// Update replaces an existing key.
+	Update(key *Key) error
+	// TouchLastUsed sets only the last use of an existing key, so it does not undo a concurrent update.
+	TouchLastUsed(id string, at time.Time) error
+}
+
+type memoryStore struct {
+	keys   map[string]*Key
+	hashes map[string]string
+	sync.RWMutex
+}
+
+// NewMemoryStore creates a new store that keeps all keys in memory.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		keys:   make(map[string]*Key),
+		hashes: make(map[string]string),
+	}
+}
+
+func (s *memoryStore) FindAll() ([]*Key, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	list := []*Key{}
+	for _, k := range s.keys {
+		list = append(list, k.copy())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+
+	return list, nil
+}
+
+func (s *memoryStore) FindByID(id string) (*Key, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrKeyNotFound, id)
+	}
+	return key.copy(), nil
+}
+
+func (s *memoryStore) FindByHash(hash string) (*Key, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	id, ok := s.hashes[hash]
+	if !ok {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrKeyNotFound, "")
+	}
+	return s.keys[id].copy(), nil
+}
+
+func (s *memoryStore) Save(key *Key) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.keys[key.ID]; ok {
+		return Errorf(ErrorTypeConflict, ErrKeyIDTaken, key.ID)
+	}
+	s.keys[key.ID] = key.copy()
+	s.hashes[key.Hash] = key.ID
+
+	return nil
+}
+
+func (s *memoryStore) Update(key *Key) error {
+	s.Lock()
+	defer s.Unlock()
+
+	old, ok := s.keys[key.ID]
+	if !ok {
+		return Errorf(ErrorTypeResourceNotFound, ErrKeyNotFound, key.ID)
+	}
+	delete(s.hashes, old.Hash)
+	s.keys[key.ID] = key.copy()
+	s.hashes[key.Hash] = key.ID
+
+	return nil
+}
+
+func (s *memoryStore) TouchLastUsed(id string, at time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	key, ok := s.keys[id]
+	if !ok {
+		return Errorf(ErrorTypeResourceNotFound, ErrKeyNotFound, id)
+	}
+	key.LastUsedAt = &at
+
+	return nil
+}
+
+// fileStore keeps all keys in memory and writes them to a JSON file after each change.
+type fileStore struct {
+	*memoryStore
+	path  string
+	write sync.Mutex
+}
+
+// NewFileStore creates a new store that persists all keys in the JSON file at path.
+// Existing keys are loaded from the file.
+func NewFileStore(path string) (Store, error) {
+	s := &fileStore{
+		memoryStore: NewMemoryStore().(*memoryStore),
+		path:        path,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, fmt.Errorf(ErrReadKeyStore, path, err)
+	}
+
+	keys := []*Key{}
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf(ErrReadKeyStore, path, err)
+	}
+	for _, key := range keys {
+		if err := s.memoryStore.Save(key); err != nil {
+			return nil, fmt.Errorf(ErrReadKeyStore, path, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) Save(key *Key) error {
+	if err := s.memoryStore.Save(key); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+func (s *fileStore) Update(key *Key) error {
+	if err := s.memoryStore.Update(key); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+func (s *fileStore) TouchLastUsed(id string, at time.Time) error {
+	if err := s.memoryStore.TouchLastUsed(id, at); err != nil {
+		return err
+	}
+	return s.persist()
+}
+
+// persist writes all keys to a temporary file and replaces the key file with it.
+func (s *fileStore) persist() error {
+	s.write.Lock()
+	defer s.write.Unlock()
+
+	keys, _ := s.memoryStore.FindAll()
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		Close(tmp)
+		return Error(err, ErrorTypeDatabase)
+	}
+	if err := tmp.Close(); err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return nil
+}
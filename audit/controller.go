@@ -0,0 +1,64 @@
+package audit
+
+import (
+	. "golang-microservice-template/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+)
+
+// Query parameters of the audit log
+const (
+	QueryParamResource = "resource"
+	QueryParamName     = "name"
+	QueryParamActor    = "actor"
+	QueryParamLimit    = "limit"
+
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// errors
+var (
+	ErrInvalidLimit = "limit must be a number between 1 and %d"
+)
+
+// Controller handles the requests to query the audit log.
+type Controller interface {
+	// GetAll returns the events selected by the query parameters resource, name, actor and limit, newest first.
+	GetAll(echo.Context) error
+}
+
+type controller struct {
+	store Store
+}
+
+// NewController creates a new Controller that queries the events of the given store.
+func NewController(store Store) Controller {
+	return &controller{store: store}
+}
+
+func (c *controller) GetAll(ctx echo.Context) error {
+	filter := Filter{
+		Resource: ctx.QueryParam(QueryParamResource),
+		Name:     ctx.QueryParam(QueryParamName),
+		Actor:    ctx.QueryParam(QueryParamActor),
+		Limit:    defaultLimit,
+	}
+
+	if value := ctx.QueryParam(QueryParamLimit); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return Errorf(ErrorTypeBadRequest, ErrInvalidLimit, maxLimit)
+		}
+		filter.Limit = limit
+	}
+
+	events, err := c.store.Find(filter)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return ctx.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"golang-microservice-template/auth"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Actions of audit events
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	// ActionRestore replaces all resources, e.g. with a snapshot.
+	ActionRestore = "restore"
+)
+
+const (
+	// ActorAnonymous is the actor of events caused by unauthenticated requests.
+	ActorAnonymous = "anonymous"
+
+	idSize = 8
+)
+
+// Event records a successful mutation of a resource.
+type Event struct {
+	ID        string    `json:"id"`
+	Action    string    `json:"action"`
+	Resource  string    `json:"resource"`
+	Name      string    `json:"name"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"requestId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Before is the resource before the mutation, empty for created resources.
+	Before json.RawMessage `json:"before,omitempty"`
+	// After is the resource after the mutation, empty for deleted resources.
+	After json.RawMessage `json:"after,omitempty"`
+}
+
+// Origin identifies the caller and request that caused a change.
+type Origin struct {
+	Actor     string
+	RequestID string
+}
+
+// OriginFromContext returns the origin of a request. The actor is the subject of the authenticated
+// Principal or ActorAnonymous.
+func OriginFromContext(ctx echo.Context) Origin {
+	origin := Origin{
+		Actor:     ActorAnonymous,
+		RequestID: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		origin.Actor = principal.Subject
+	}
+	return origin
+}
+
+// NewEvent creates an event for a mutation caused by origin. Before and after are snapshots
+// of the resource, nil if it did not exist.
+func NewEvent(origin Origin, now time.Time, action, resource, name string, before, after interface{}) (*Event, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	event := &Event{
+		ID:        id,
+		Action:    action,
+		Resource:  resource,
+		Name:      name,
+		Actor:     origin.Actor,
+		RequestID: origin.RequestID,
+		Timestamp: now.UTC(),
+	}
+
+	if before != nil {
+		if event.Before, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if after != nil {
+		if event.After, err = json.Marshal(after); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// Filter selects events, empty fields match all events.
+type Filter struct {
+	Resource string
+	Name     string
+	Actor    string
+	// Limit is the maximum number of events to return, 0 returns all.
+	Limit int
+}
+
+// Matches returns true if the event is selected by the filter.
+func (f Filter) Matches(event *Event) bool {
+	return (f.Resource == "" || f.Resource == event.Resource) &&
+		(f.Name == "" || f.Name == event.Name) &&
+		(f.Actor == "" || f.Actor == event.Actor)
+}
+
+// generateID returns a new random event id.
+func generateID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
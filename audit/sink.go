@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrOpenAuditFile = "failed to open audit file %s: %v"
+	ErrReadAuditFile = "failed to read audit file %s: %v"
+)
+
+const maxLineSize = 1024 * 1024
+
+// Sink receives audit events.
+type Sink interface {
+	// Write records an event.
+	Write(event *Event) error
+	// Close releases all resources of the sink.
+	Close() error
+}
+
+// Store is a Sink whose events can be queried.
+type Store interface {
+	Sink
+	// Find returns the events selected by the filter, newest first.
+	Find(filter Filter) ([]*Event, error)
+}
+
+type logSink struct {
+	log LogWriter
+}
+
+// NewLogSink creates a sink that writes every event as JSON to the log.
+func NewLogSink(log LogWriter) Sink {
+	return &logSink{log: log}
+}
+
+func (s *logSink) Write(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.log.Infof("audit %s", data)
+	return nil
+}
+
+func (*logSink) Close() error {
+	return nil
+}
+
+type memoryStore struct {
+	events   []*Event
+	capacity int
+	sync.RWMutex
+}
+
+// NewMemoryStore creates a store that keeps the latest events in memory, older ones are dropped
+// once capacity is reached.
+func NewMemoryStore(capacity int) Store {
+	return &memoryStore{
+		events:   []*Event{},
+		capacity: capacity,
+	}
+}
+
+func (s *memoryStore) Write(event *Event) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.events) >= s.capacity {
+		s.events = append(s.events[:0:0], s.events[len(s.events)-s.capacity+1:]...)
+	}
+	s.events = append(s.events, event)
+
+	return nil
+}
+
+func (s *memoryStore) Find(filter Filter) ([]*Event, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	return find(s.events, filter), nil
+}
+
+func (*memoryStore) Close() error {
+	return nil
+}
+
+type fileStore struct {
+	path string
+	file *os.File
+	sync.Mutex
+}
+
+// NewFileStore creates a store that appends the events as JSON lines to the file at path.
+func NewFileStore(path string) (Store, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf(ErrOpenAuditFile, path, err)
+	}
+
+	return &fileStore{path: path, file: file}, nil
+}
+
+func (s *fileStore) Write(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+// Find reads and decodes the whole file, its cost grows with the number of events in the file.
+// Only the size of the file is read under the lock, so writes are not blocked by the scan.
+// Events written during the scan are not returned.
+func (s *fileStore) Find(filter Filter) ([]*Event, error) {
+	size, err := s.size()
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadAuditFile, s.path, err)
+	}
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadAuditFile, s.path, err)
+	}
+	defer file.Close()
+
+	events := []*Event{}
+	scanner := bufio.NewScanner(io.LimitReader(file, size))
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		event := &Event{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			return nil, fmt.Errorf(ErrReadAuditFile, s.path, err)
+		}
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf(ErrReadAuditFile, s.path, err)
+	}
+
+	return find(events, filter), nil
+}
+
+// size returns the size of the file after the last complete write.
+func (s *fileStore) size() (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *fileStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.file.Close()
+}
+
+// find returns the events selected by the filter in reverse order.
+func find(events []*Event, filter Filter) []*Event {
+	result := []*Event{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+		if filter.Matches(events[i]) {
+			result = append(result, events[i])
+		}
+	}
+	return result
+}
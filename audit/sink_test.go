@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFileStore(t *testing.T) Store {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, store.Close()) })
+	return store
+}
+
+// numbered returns an update event of the pizza whose name is the number.
+func numbered(i int) *Event {
+	return &Event{ID: strconv.Itoa(i), Action: ActionUpdate, Resource: "pizza", Name: strconv.Itoa(i), Actor: "alice", Timestamp: time.Now()}
+}
+
+func TestFileStoreFindsNewestFirst(t *testing.T) {
+	store := newFileStore(t)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, store.Write(numbered(i)))
+	}
+	require.NoError(t, store.Write(&Event{ID: "bob", Action: ActionDelete, Resource: "pizza", Name: "2", Actor: "bob"}))
+
+	events, err := store.Find(Filter{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob", "4", "3"}, ids(events))
+
+	events, err = store.Find(Filter{Resource: "pizza", Name: "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob", "2"}, ids(events))
+
+	events, err = store.Find(Filter{Actor: "alice"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2", "1", "0"}, ids(events))
+}
+
+// TestFileStoreFindsWhileWriting queries the file while events are written. Every query must return complete
+// events without gaps, newest first. Run it with -race.
+func TestFileStoreFindsWhileWriting(t *testing.T) {
+	const events = 200
+	store := newFileStore(t)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < events; i++ {
+			if !assert.NoError(t, store.Write(numbered(i))) {
+				return
+			}
+		}
+	}()
+	for g := 0; g < 2; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			seen := 0
+			for seen < events {
+				found, err := store.Find(Filter{})
+				if !assert.NoError(t, err) {
+					return
+				}
+				if !assert.True(t, len(found) >= seen, "a later query returned fewer events") {
+					return
+				}
+				for i, event := range found {
+					if !assert.Equal(t, strconv.Itoa(len(found)-1-i), event.ID) {
+						return
+					}
+				}
+				seen = len(found)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func ids(events []*Event) []string {
+	result := []string{}
+	for _, event := range events {
+		result = append(result, event.ID)
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Claims are the registered and service specific claims of a JWT.
+// Expiry, issuer and audience are checked by the TokenValidator, not by Valid.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	ID        string   `json:"jti,omitempty"`
+	// Roles granted to the subject, e.g. menu-editor or admin.
+	Roles []string `json:"roles,omitempty"`
+	// Scope is a space separated list of OAuth scopes.
+	Scope string `json:"scope,omitempty"`
+}
+
+// Valid implements jwt.Claims.
+func (*Claims) Valid() error {
+	return nil
+}
+
+// Scopes returns the scopes of the scope claim.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// Audience is the aud claim, which can be a single string or a list of strings.
+type Audience []string
+
+// UnmarshalJSON accepts a string or an array of strings.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Contains returns true if the audience contains the given value.
+func (a Audience) Contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// errors
+var (
+	ErrReadKeyFile = "failed to read key file %s: %v"
+	ErrInvalidJWK  = "invalid key %s in key set: %v"
+)
+
+// jwk is a single RSA key of a JSON Web Key Set, see RFC 7517.
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// loadJWKS reads the RSA signing keys of a local JSON Web Key Set file, keyed by their key id.
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadKeyFile, path, err)
+	}
+
+	set := &jwks{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf(ErrReadKeyFile, path, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidJWK, k.KeyID, err)
+		}
+		keys[k.KeyID] = key
+	}
+
+	return keys, nil
+}
+
+func (k *jwk) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+		return nil, errors.New("exponent too large")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exponent.Int64()),
+	}, nil
+}
+
+// loadPublicKey reads a PEM encoded RSA public key or certificate.
+func loadPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadKeyFile, path, err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil && block.Type == "CERTIFICATE" {
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf(ErrReadKeyFile, path, err)
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf(ErrReadKeyFile, path, "certificate does not contain an RSA key")
+	}
+
+	key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadKeyFile, path, err)
+	}
+	return key, nil
+}
+
+// modTime returns the modification time of the file or the zero time if it does not exist.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// errors
+var (
+	ErrInvalidToken    = "invalid token: %v"
+	ErrTokenExpired    = "token expired"
+	ErrTokenNotYetUsed = "token not valid yet"
+	ErrInvalidIssuer   = "invalid token issuer"
+	ErrInvalidAudience = "invalid token audience"
+	ErrMissingSubject  = "token has no subject"
+	ErrUnknownKey      = "unknown key id '%s'"
+)
+
+// TokenValidator checks the signature and the claims of a token.
+type TokenValidator interface {
+	// Validate returns the claims of a valid token or an error of type ErrorTypeUnauthorized.
+	Validate(token string) (*Claims, error)
+}
+
+type jwtValidator struct {
+	config    config.JWTConfig
+	clock     Clock
+	secret    []byte
+	publicKey *rsa.PublicKey
+	parser    *jwt.Parser
+	keySet    *keySet
+}
+
+// keySet holds the keys of the JWKS file and reloads them when the file changed.
+type keySet struct {
+	path      string
+	interval  time.Duration
+	clock     Clock
+	keys      map[string]*rsa.PublicKey
+	modTime   time.Time
+	checkedAt time.Time
+	sync.Mutex
+}
+
+// NewJWTValidator creates a TokenValidator for the given configuration and loads the configured keys.
+func NewJWTValidator(cfg config.JWTConfig, clock Clock) (TokenValidator, error) {
+	v := &jwtValidator{
+		config: cfg,
+		clock:  clock,
+		parser: &jwt.Parser{
+			ValidMethods:         []string{cfg.Algorithm},
+			SkipClaimsValidation: true,
+		},
+	}
+
+	switch cfg.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		v.secret = []byte(cfg.Secret)
+	case jwt.SigningMethodRS256.Alg():
+		var err error
+		if cfg.PublicKeyFile != "" {
+			if v.publicKey, err = loadPublicKey(cfg.PublicKeyFile); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.JWKSFile != "" {
+			v.keySet = &keySet{path: cfg.JWKSFile, interval: cfg.JWKSReloadInterval, clock: clock}
+			if err := v.keySet.load(); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %s", cfg.Algorithm)
+	}
+
+	return v, nil
+}
+
+func (v *jwtValidator) Validate(token string) (*Claims, error) {
+	claims := &Claims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		return nil, Errorf(ErrorTypeUnauthorized, ErrInvalidToken, err)
+	}
+
+	if err := v.validateClaims(claims); err != nil {
+		return nil, Error(err, ErrorTypeUnauthorized)
+	}
+
+	return claims, nil
+}
+
+// key returns the key to verify the signature of the given token.
+func (v *jwtValidator) key(token *jwt.Token) (interface{}, error) {
+	if v.secret != nil {
+		return v.secret, nil
+	}
+
+	if kid, ok := token.Header["kid"].(string); ok && v.keySet != nil {
+		if key := v.keySet.key(kid); key != nil {
+			return key, nil
+		}
+		if v.publicKey == nil {
+			return nil, fmt.Errorf(ErrUnknownKey, kid)
+		}
+	}
+
+	if v.publicKey != nil {
+		return v.publicKey, nil
+	}
+	if v.keySet != nil {
+		if key := v.keySet.only(); key != nil {
+			return key, nil
+		}
+	}
+
+	return nil, errors.New("no key to verify the token")
+}
+
+// key returns the key with the given id. An unknown id loads the file again if it changed,
+// the file is checked at most once per interval. If the changed file can not be loaded, the previous keys are kept.
+func (s *keySet) key(kid string) *rsa.PublicKey {
+	s.Lock()
+	defer s.Unlock()
+
+	if key, ok := s.keys[kid]; ok {
+		return key
+	}
+
+	now := s.clock.Now()
+	if now.Sub(s.checkedAt) < s.interval {
+		return nil
+	}
+	s.checkedAt = now
+	if !modTime(s.path).After(s.modTime) {
+		return nil
+	}
+	if err := s.load(); err != nil {
+		Log.Errorf("keeping previous key set: %v", err)
+		return nil
+	}
+	Log.Infof("reloaded key set %s", s.path)
+
+	return s.keys[kid]
+}
+
+// only returns the key if the set contains exactly one key.
+func (s *keySet) only() *rsa.PublicKey {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(s.keys) != 1 {
+		return nil
+	}
+	for _, key := range s.keys {
+		return key
+	}
+	return nil
+}
+
+// load reads the keys of the file, the caller must hold the lock unless the set is not shared yet.
+func (s *keySet) load() error {
+	loadedModTime := modTime(s.path)
+
+	keys, err := loadJWKS(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.keys = keys
+	s.modTime = loadedModTime
+	s.checkedAt = s.clock.Now()
+	return nil
+}
+
+func (v *jwtValidator) validateClaims(claims *Claims) error {
+	now := v.clock.Now()
+	leeway := v.config.Leeway
+
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return errors.New(ErrTokenExpired)
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return errors.New(ErrTokenNotYetUsed)
+	}
+	if claims.IssuedAt != 0 && now.Add(leeway).Before(time.Unix(claims.IssuedAt, 0)) {
+		return errors.New(ErrTokenNotYetUsed)
+	}
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return errors.New(ErrInvalidIssuer)
+	}
+	if v.config.Audience != "" && !claims.Audience.Contains(v.config.Audience) {
+		return errors.New(ErrInvalidAudience)
+	}
+	if claims.Subject == "" {
+		return errors.New(ErrMissingSubject)
+	}
+
+	return nil
+}
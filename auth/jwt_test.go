@@ -0,0 +1,278 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "jwt-test-secret"
+
+type fakeClock struct {
+	now time.Time
+	sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// testNow is the time of the fake clock, tokens are valid around it.
+var testNow = time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+// validClaims are the claims of a token accepted by the validators of the tests.
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "alice",
+		"iss": "pizza-issuer",
+		"aud": []string{"pizza-service", "other-service"},
+		"exp": testNow.Add(time.Hour).Unix(),
+		"iat": testNow.Add(-time.Minute).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func writePublicKey(t *testing.T, dir string, key *rsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	path := filepath.Join(dir, "jwt.pem")
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+	return path
+}
+
+// writeJWKS writes the public keys to the key set file and moves its modification time forward,
+// so a rewrite within the resolution of the file system is detected.
+func writeJWKS(t *testing.T, path string, keys map[string]*rsa.PrivateKey) {
+	set := jwks{}
+	for kid, key := range keys {
+		set.Keys = append(set.Keys, jwk{
+			KeyType: "RSA",
+			KeyID:   kid,
+			Use:     "sig",
+			N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	previous := modTime(path)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+	if !modTime(path).After(previous) {
+		next := previous.Add(time.Second)
+		require.NoError(t, os.Chtimes(path, next, next))
+	}
+}
+
+func assertUnauthorized(t *testing.T, err error, message string) {
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, err.(HasHTTPStatus).GetHTTPStatusCode())
+		assert.Contains(t, err.Error(), message)
+	}
+}
+
+func TestValidateChecksClaims(t *testing.T) {
+	validator, err := NewJWTValidator(config.JWTConfig{
+		Algorithm: "HS256",
+		Secret:    testSecret,
+		Issuer:    "pizza-issuer",
+		Audience:  "pizza-service",
+		Leeway:    30 * time.Second,
+	}, &fakeClock{now: testNow})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		change func(claims jwt.MapClaims)
+		err    string
+	}{
+		"valid":                    {change: func(jwt.MapClaims) {}},
+		"single audience":          {change: func(c jwt.MapClaims) { c["aud"] = "pizza-service" }},
+		"expired within leeway":    {change: func(c jwt.MapClaims) { c["exp"] = testNow.Add(-10 * time.Second).Unix() }},
+		"expired":                  {change: func(c jwt.MapClaims) { c["exp"] = testNow.Add(-time.Minute).Unix() }, err: ErrTokenExpired},
+		"no expiry":                {change: func(c jwt.MapClaims) { delete(c, "exp") }, err: ErrTokenExpired},
+		"not before within leeway": {change: func(c jwt.MapClaims) { c["nbf"] = testNow.Add(10 * time.Second).Unix() }},
+		"not before":               {change: func(c jwt.MapClaims) { c["nbf"] = testNow.Add(time.Minute).Unix() }, err: ErrTokenNotYetUsed},
+		"issued in the future":     {change: func(c jwt.MapClaims) { c["iat"] = testNow.Add(time.Minute).Unix() }, err: ErrTokenNotYetUsed},
+		"wrong issuer":             {change: func(c jwt.MapClaims) { c["iss"] = "other-issuer" }, err: ErrInvalidIssuer},
+		"no issuer":                {change: func(c jwt.MapClaims) { delete(c, "iss") }, err: ErrInvalidIssuer},
+		"wrong audience":           {change: func(c jwt.MapClaims) { c["aud"] = []string{"other-service"} }, err: ErrInvalidAudience},
+		"no audience":              {change: func(c jwt.MapClaims) { delete(c, "aud") }, err: ErrInvalidAudience},
+		"no subject":               {change: func(c jwt.MapClaims) { delete(c, "sub") }, err: ErrMissingSubject},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			test.change(claims)
+
+			validated, err := validator.Validate(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims))
+
+			if test.err != "" {
+				assertUnauthorized(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", validated.Subject)
+		})
+	}
+}
+
+func TestValidateChecksSignature(t *testing.T) {
+	dir := t.TempDir()
+	key := newRSAKey(t)
+	publicKeyFile := writePublicKey(t, dir, key)
+	publicKeyPEM, err := ioutil.ReadFile(publicKeyFile)
+	require.NoError(t, err)
+	clock := &fakeClock{now: testNow}
+
+	hs256, err := NewJWTValidator(config.JWTConfig{Algorithm: "HS256", Secret: testSecret}, clock)
+	require.NoError(t, err)
+	rs256, err := NewJWTValidator(config.JWTConfig{Algorithm: "RS256", PublicKeyFile: publicKeyFile}, clock)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		validator TokenValidator
+		token     string
+		valid     bool
+	}{
+		"HS256":                         {validator: hs256, token: sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims()), valid: true},
+		"HS256 with another secret":     {validator: hs256, token: sign(t, jwt.SigningMethodHS256, []byte("other-secret"), "", validClaims())},
+		"HS512 with the secret":         {validator: hs256, token: sign(t, jwt.SigningMethodHS512, []byte(testSecret), "", validClaims())},
+		"RS256 to the HS256 validator":  {validator: hs256, token: sign(t, jwt.SigningMethodRS256, key, "", validClaims())},
+		"RS256":                         {validator: rs256, token: sign(t, jwt.SigningMethodRS256, key, "", validClaims()), valid: true},
+		"RS256 with another key":        {validator: rs256, token: sign(t, jwt.SigningMethodRS256, newRSAKey(t), "", validClaims())},
+		"HS256 signed with public key":  {validator: rs256, token: sign(t, jwt.SigningMethodHS256, publicKeyPEM, "", validClaims())},
+		"HS256 signed with the modulus": {validator: rs256, token: sign(t, jwt.SigningMethodHS256, key.N.Bytes(), "", validClaims())},
+		"unsigned":                      {validator: rs256, token: sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", validClaims())},
+		"malformed":                     {validator: rs256, token: "not.a.token"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			claims, err := test.validator.Validate(test.token)
+
+			if !test.valid {
+				assertUnauthorized(t, err, "invalid token")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims.Subject)
+		})
+	}
+}
+
+func TestValidateSelectsKeyOfKeySet(t *testing.T) {
+	dir := t.TempDir()
+	first, second, fallback := newRSAKey(t), newRSAKey(t), newRSAKey(t)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"first": first, "second": second})
+	clock := &fakeClock{now: testNow}
+
+	keySetOnly, err := NewJWTValidator(config.JWTConfig{Algorithm: "RS256", JWKSFile: jwksFile, JWKSReloadInterval: time.Minute}, clock)
+	require.NoError(t, err)
+	withFallback, err := NewJWTValidator(config.JWTConfig{
+		Algorithm:          "RS256",
+		JWKSFile:           jwksFile,
+		JWKSReloadInterval: time.Minute,
+		PublicKeyFile:      writePublicKey(t, dir, fallback),
+	}, clock)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		validator TokenValidator
+		token     string
+		err       string
+	}{
+		"first key":                      {validator: keySetOnly, token: sign(t, jwt.SigningMethodRS256, first, "first", validClaims())},
+		"second key":                     {validator: keySetOnly, token: sign(t, jwt.SigningMethodRS256, second, "second", validClaims())},
+		"key id of another key":          {validator: keySetOnly, token: sign(t, jwt.SigningMethodRS256, first, "second", validClaims()), err: "verification error"},
+		"unknown key id":                 {validator: keySetOnly, token: sign(t, jwt.SigningMethodRS256, first, "third", validClaims()), err: "unknown key id 'third'"},
+		"no key id with many keys":       {validator: keySetOnly, token: sign(t, jwt.SigningMethodRS256, first, "", validClaims()), err: "no key to verify the token"},
+		"unknown key id uses public key": {validator: withFallback, token: sign(t, jwt.SigningMethodRS256, fallback, "third", validClaims())},
+		"no key id uses public key":      {validator: withFallback, token: sign(t, jwt.SigningMethodRS256, fallback, "", validClaims())},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			claims, err := test.validator.Validate(test.token)
+
+			if test.err != "" {
+				assertUnauthorized(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims.Subject)
+		})
+	}
+}
+
+func TestValidateReloadsChangedKeySet(t *testing.T) {
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	old, rotated := newRSAKey(t), newRSAKey(t)
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"old": old})
+	clock := &fakeClock{now: testNow}
+	validator, err := NewJWTValidator(config.JWTConfig{Algorithm: "RS256", JWKSFile: jwksFile, JWKSReloadInterval: time.Minute}, clock)
+	require.NoError(t, err)
+	rotatedToken := sign(t, jwt.SigningMethodRS256, rotated, "rotated", validClaims())
+
+	writeJWKS(t, jwksFile, map[string]*rsa.PrivateKey{"old": old, "rotated": rotated})
+	_, err = validator.Validate(rotatedToken)
+	assertUnauthorized(t, err, "unknown key id 'rotated'")
+
+	clock.Advance(time.Minute)
+	_, err = validator.Validate(rotatedToken)
+	assert.NoError(t, err, "the changed file is loaded after the reload interval")
+	_, err = validator.Validate(sign(t, jwt.SigningMethodRS256, old, "old", validClaims()))
+	assert.NoError(t, err)
+
+	require.NoError(t, ioutil.WriteFile(jwksFile, []byte("{broken"), 0600))
+	require.NoError(t, os.Chtimes(jwksFile, time.Now().Add(time.Hour), time.Now().Add(time.Hour)))
+	clock.Advance(time.Minute)
+	_, err = validator.Validate(sign(t, jwt.SigningMethodRS256, newRSAKey(t), "unknown", validClaims()))
+	assertUnauthorized(t, err, "unknown key id 'unknown'")
+	_, err = validator.Validate(rotatedToken)
+	assert.NoError(t, err, "a broken file keeps the previous keys")
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	. "golang-microservice-template/utils"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// ContextKeyClaims is the key of the validated token claims in the echo context.
+	ContextKeyClaims = "auth.claims"
+
+	bearerPrefix = "Bearer "
+)
+
+// errors
+var (
+	ErrMissingBearerToken = "missing bearer token"
+)
+
+// JWT returns a middleware that authenticates requests with a bearer token in the Authorization header.
+// Requests without a token continue anonymously, whether they are allowed is decided by the Policy of the route.
+// Requests with an invalid token are rejected. The claims of the token and the Principal derived from
+// them are stored in the context, see ClaimsFromContext and PrincipalFromContext.
+func JWT(validator TokenValidator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			header := ctx.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(ctx)
+			}
+
+			if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return Error(ErrMissingBearerToken, ErrorTypeUnauthorized)
+			}
+
+			claims, err := validator.Validate(strings.TrimSpace(header[len(bearerPrefix):]))
+			if err != nil {
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return err
+			}
+
+			ctx.Set(ContextKeyClaims, claims)
+			ctx.Set(ContextKeyPrincipal, &Principal{
+				Method:  MethodJWT,
+				Subject: claims.Subject,
+				Roles:   claims.Roles,
+				Scopes:  claims.Scopes(),
+			})
+
+			return next(ctx)
+		}
+	}
+}
+
+// ClaimsFromContext returns the claims of the authenticated request or nil.
+func ClaimsFromContext(ctx echo.Context) *Claims {
+	claims, _ := ctx.Get(ContextKeyClaims).(*Claims)
+	return claims
+}
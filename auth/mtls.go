@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"golang-microservice-template/config"
+	"golang-microservice-template/tlsutil"
+	. "golang-microservice-template/utils"
+
+	"github.com/labstack/echo"
+)
+
+// ContextKeyClientIdentity is the key of the verified client certificate identity in the echo context.
+const ContextKeyClientIdentity = "auth.clientIdentity"
+
+// errors
+var (
+	ErrClientCertificateRequired = "client certificate required"
+	ErrClientNotAllowed          = "client %s is not allowed"
+)
+
+// ClientCertificate returns a middleware that authenticates other services by their TLS client certificate.
+// Requests with a certificate that fails verification are rejected with ErrorTypeUnauthorized, requests with
+// a valid certificate of a client that is not listed in the configuration with ErrorTypeForbidden.
+// Requests without a certificate continue anonymously unless a certificate is required.
+func ClientCertificate(verifier tlsutil.ClientVerifier, cfg config.MTLSConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			identity, err := verifier.Verify(ctx.Request().TLS)
+			if err != nil {
+				return Error(err, ErrorTypeUnauthorized)
+			}
+			if identity == nil {
+				if cfg.Required {
+					return Error(ErrClientCertificateRequired, ErrorTypeUnauthorized)
+				}
+				return next(ctx)
+			}
+
+			principal := clientPrincipal(identity, cfg.Clients)
+			if principal == nil {
+				return Errorf(ErrorTypeForbidden, ErrClientNotAllowed, identity.CommonName)
+			}
+
+			ctx.Set(ContextKeyClientIdentity, identity)
+			ctx.Set(ContextKeyPrincipal, principal)
+			return next(ctx)
+		}
+	}
+}
+
+// ClientIdentityFromContext returns the verified client certificate identity of the request or nil.
+func ClientIdentityFromContext(ctx echo.Context) *tlsutil.ClientIdentity {
+	identity, _ := ctx.Get(ContextKeyClientIdentity).(*tlsutil.ClientIdentity)
+	return identity
+}
+
+// clientPrincipal returns the Principal of the first name of the identity that is listed in clients,
+// or nil if none is.
+func clientPrincipal(identity *tlsutil.ClientIdentity, clients map[string]config.ClientPermissions) *Principal {
+	for _, name := range identity.Names() {
+		if permissions, ok := clients[name]; ok {
+			return &Principal{Method: MethodMTLS, Subject: name, Roles: permissions.Roles, Scopes: permissions.Scopes}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/tls"
+	"golang-microservice-template/config"
+	"golang-microservice-template/tlsutil"
+	"golang-microservice-template/tlsutil/tlstest"
+	. "golang-microservice-template/utils"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mtlsFixture is the client certificate middleware with a CA and a verifier that trusts it.
+type mtlsFixture struct {
+	ca       *tlstest.Certificate
+	verifier tlsutil.ClientVerifier
+}
+
+func newMTLSFixture(t *testing.T) *mtlsFixture {
+	ca := tlstest.NewCA(t, "client CA")
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, ca.CertPEM(), 0600))
+	verifier, err := tlsutil.NewClientVerifier(caFile)
+	require.NoError(t, err)
+
+	return &mtlsFixture{ca: ca, verifier: verifier}
+}
+
+// authenticate runs the middleware for a request over a connection with the given state and returns the principal
+// the next handler saw, or the error of the middleware.
+func (f *mtlsFixture) authenticate(cfg config.MTLSConfig, state *tls.ConnectionState) (*Principal, bool, error) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/pizza", nil)
+	req.TLS = state
+	ctx := echo.New().NewContext(req, httptest.NewRecorder())
+
+	var principal *Principal
+	called := false
+	err := ClientCertificate(f.verifier, cfg)(func(ctx echo.Context) error {
+		called = true
+		principal = PrincipalFromContext(ctx)
+		return nil
+	})(ctx)
+
+	return principal, called, err
+}
+
+func assertStatus(t *testing.T, status int, err error) {
+	if assert.Error(t, err) {
+		assert.Equal(t, status, err.(HasHTTPStatus).GetHTTPStatusCode(), err.Error())
+	}
+}
+
+var mtlsClients = map[string]config.ClientPermissions{
+	"ordering":             {Roles: []string{RoleMenuEditor}},
+	"billing.example.com":  {Scopes: []string{ScopePizzaRead}},
+	"kitchen":              {Roles: []string{RoleAdmin}},
+	"kitchen.example.com":  {Scopes: []string{ScopePizzaRead}},
+	"unused.example.com":   {},
+	"delivery.example.com": {Scopes: []string{ScopePizzaRead}},
+}
+
+func TestClientCertificateWithoutCertificate(t *testing.T) {
+	f := newMTLSFixture(t)
+	state := &tls.ConnectionState{HandshakeComplete: true}
+
+	principal, called, err := f.authenticate(config.MTLSConfig{Clients: mtlsClients}, state)
+	require.NoError(t, err)
+	assert.True(t, called, "the request continues anonymously")
+	assert.Nil(t, principal)
+
+	_, called, err = f.authenticate(config.MTLSConfig{Required: true, Clients: mtlsClients}, state)
+	assertStatus(t, http.StatusUnauthorized, err)
+	assert.False(t, called)
+
+	_, called, err = f.authenticate(config.MTLSConfig{Clients: mtlsClients}, nil)
+	assertStatus(t, http.StatusUnauthorized, err)
+	assert.False(t, called, "plain HTTP requests can not be verified")
+}
+
+func TestClientCertificateRejectsInvalidCertificates(t *testing.T) {
+	f := newMTLSFixture(t)
+
+	for name, cert := range map[string]*tlstest.Certificate{
+		"untrusted CA": tlstest.NewCA(t, "other CA").Client(t, "ordering"),
+		"wrong usage":  f.ca.Server(t, "ordering"),
+	} {
+		cert := cert
+		t.Run(name, func(t *testing.T) {
+			for _, required := range []bool{false, true} {
+				_, called, err := f.authenticate(config.MTLSConfig{Required: required, Clients: mtlsClients}, cert.ConnectionState())
+
+				assertStatus(t, http.StatusUnauthorized, err)
+				assert.False(t, called, "an invalid certificate does not continue anonymously")
+			}
+		})
+	}
+}
+
+func TestClientCertificateGrantsPermissionsOfListedNames(t *testing.T) {
+	f := newMTLSFixture(t)
+
+	tests := map[string]struct {
+		cert      *tlstest.Certificate
+		principal *Principal
+	}{
+		"common name": {
+			cert:      f.ca.Client(t, "ordering"),
+			principal: &Principal{Method: MethodMTLS, Subject: "ordering", Roles: []string{RoleMenuEditor}},
+		},
+		"subject alternative name": {
+			cert:      f.ca.Client(t, "billing", "billing.example.com"),
+			principal: &Principal{Method: MethodMTLS, Subject: "billing.example.com", Scopes: []string{ScopePizzaRead}},
+		},
+		"common name before subject alternative name": {
+			cert:      f.ca.Client(t, "kitchen", "kitchen.example.com"),
+			principal: &Principal{Method: MethodMTLS, Subject: "kitchen", Roles: []string{RoleAdmin}},
+		},
+		"first listed subject alternative name": {
+			cert:      f.ca.Client(t, "delivery", "other.example.com", "delivery.example.com"),
+			principal: &Principal{Method: MethodMTLS, Subject: "delivery.example.com", Scopes: []string{ScopePizzaRead}},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			principal, called, err := f.authenticate(config.MTLSConfig{Required: true, Clients: mtlsClients}, test.cert.ConnectionState())
+
+			require.NoError(t, err)
+			assert.True(t, called)
+			assert.Equal(t, test.principal, principal)
+		})
+	}
+}
+
+func TestClientCertificateForbidsUnlistedClients(t *testing.T) {
+	f := newMTLSFixture(t)
+	cert := f.ca.Client(t, "stranger", "stranger.example.com")
+
+	for _, required := range []bool{false, true} {
+		_, called, err := f.authenticate(config.MTLSConfig{Required: required, Clients: mtlsClients}, cert.ConnectionState())
+
+		assertStatus(t, http.StatusForbidden, err)
+		assert.Contains(t, err.Error(), "stranger")
+		assert.False(t, called)
+	}
+}
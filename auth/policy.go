@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"fmt"
+	. "golang-microservice-template/utils"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// Keys for roles
+const (
+	RoleMenuEditor = "menu-editor" // may create and change pizzas
+	RoleAdmin      = "admin"       // may do everything
+)
+
+// Keys for scopes
+const (
+	ScopePizzaRead     = "pizza:read"      // may read pizzas
+	ScopePizzaWrite    = "pizza:write"     // may create and change pizzas
+	ScopePizzaDelete   = "pizza:delete"    // may delete pizzas
+	ScopeAPIKeyAdmin   = "apikeys:admin"   // may manage api keys
+	ScopeAuditRead     = "audit:read"      // may query the audit log
+	ScopeWebhookAdmin  = "webhooks:admin"  // may manage webhook subscriptions
+	ScopeMetricsRead   = "metrics:read"    // may read the service metrics
+	ScopeSnapshotAdmin = "snapshots:admin" // may write and restore snapshots of the pizzas
+)
+
+// Scopes are all known scopes.
+var Scopes = []string{
+	ScopePizzaRead,
+	ScopePizzaWrite,
+	ScopePizzaDelete,
+	ScopeAPIKeyAdmin,
+	ScopeAuditRead,
+	ScopeWebhookAdmin,
+	ScopeMetricsRead,
+	ScopeSnapshotAdmin,
+}
+
+// KnownScope reports whether the scope is one of the known scopes.
+func KnownScope(scope string) bool {
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// errors
+var (
+	ErrAuthenticationRequired = "authentication required"
+	ErrAccessDenied           = "%s requires %s"
+)
+
+// Policy decides which callers may access a route.
+// A caller is granted access if it has any of the roles or any of the scopes.
+type Policy struct {
+	// Public grants access to all callers including anonymous ones.
+	Public bool
+	Roles  []string
+	Scopes []string
+}
+
+// Authorize returns nil if the principal may access the given route, an error of type
+// ErrorTypeUnauthorized for anonymous callers or ErrorTypeForbidden with the reason otherwise.
+func (p Policy) Authorize(principal *Principal, route string) error {
+	if p.Public {
+		return nil
+	}
+	if principal == nil {
+		return Error(ErrAuthenticationRequired, ErrorTypeUnauthorized)
+	}
+	if principal.HasRole(p.Roles...) || principal.HasScope(p.Scopes...) {
+		return nil
+	}
+
+	return Errorf(ErrorTypeForbidden, ErrAccessDenied, route, p.requirement())
+}
+
+// requirement describes the policy, e.g. "role admin or scope pizza:delete".
+func (p Policy) requirement() string {
+	alternatives := []string{}
+	for _, role := range p.Roles {
+		alternatives = append(alternatives, fmt.Sprintf("role %s", role))
+	}
+	for _, scope := range p.Scopes {
+		alternatives = append(alternatives, fmt.Sprintf("scope %s", scope))
+	}
+	if len(alternatives) == 0 {
+		return "a permission nobody has"
+	}
+	return strings.Join(alternatives, " or ")
+}
+
+// Authorize returns a middleware that enforces the policy for the route.
+func Authorize(policy Policy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			route := ctx.Request().Method + " " + ctx.Path()
+			if err := policy.Authorize(PrincipalFromContext(ctx), route); err != nil {
+				if err.(HasHTTPStatus).GetErrorType() == ErrorTypeUnauthorized {
+					ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				}
+				return err
+			}
+			return next(ctx)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"github.com/labstack/echo"
+)
+
+// ContextKeyPrincipal is the key of the authenticated caller in the echo context.
+const ContextKeyPrincipal = "auth.principal"
+
+// Authentication methods
+const (
+	MethodJWT    = "jwt"    // bearer token
+	MethodAPIKey = "apikey" // API key header
+	MethodMTLS   = "mtls"   // client certificate
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// Method is the authentication method of the caller, e.g. MethodJWT.
+	Method string `json:"method"`
+	// Subject identifies the caller, e.g. the sub claim of a token.
+	Subject string `json:"subject"`
+	// Roles granted to the caller.
+	Roles []string `json:"roles,omitempty"`
+	// Scopes granted to the caller.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// HasRole returns true if the principal has one of the given roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	return containsAny(p.Roles, roles)
+}
+
+// HasScope returns true if the principal has one of the given scopes.
+func (p *Principal) HasScope(scopes ...string) bool {
+	return containsAny(p.Scopes, scopes)
+}
+
+// PrincipalFromContext returns the authenticated caller of the request or nil for anonymous requests.
+func PrincipalFromContext(ctx echo.Context) *Principal {
+	principal, _ := ctx.Get(ContextKeyPrincipal).(*Principal)
+	return principal
+}
+
+func containsAny(values, wanted []string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
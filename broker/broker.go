@@ -0,0 +1,54 @@
+package broker
+
+import (
+	"context"
+)
+
+// errors
+var (
+	ErrBrokerClosed = "message broker is closed"
+)
+
+// Message is a message consumed from or published to a topic.
+type Message struct {
+	// ID identifies the message for logs and audit events, it is set by the broker on consumption.
+	ID string
+	// Topic the message was consumed from.
+	Topic   string
+	Key     []byte
+	Data    []byte
+	Headers map[string]string
+}
+
+// Handler processes a consumed message. The message is acknowledged if nil is returned,
+// otherwise it is redelivered if the broker supports it.
+type Handler func(ctx context.Context, message *Message) error
+
+// Broker connects the service to a message broker.
+type Broker interface {
+	// Subscribe consumes the messages of a topic until the subscription is closed.
+	// Replicas of the service share the messages of a topic, each message is handled by one of them.
+	// The context passed to the handler is canceled when the subscription is closed.
+	Subscribe(topic string, handler Handler) (Subscription, error)
+	// Publish sends a message to a topic.
+	Publish(ctx context.Context, topic string, message *Message) error
+	// Ping checks that the broker is reachable.
+	Ping(ctx context.Context) error
+	// Close releases the connection. Subscriptions must be closed before.
+	Close() error
+}
+
+// Subscription is the consumption of a topic.
+type Subscription interface {
+	// Close stops the consumption and waits until the handler returned for all messages in progress.
+	Close() error
+}
+
+// copyHeaders returns a copy of the headers that can be changed.
+func copyHeaders(headers map[string]string) map[string]string {
+	copied := make(map[string]string, len(headers))
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
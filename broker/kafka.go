@@ -0,0 +1,148 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaRetryDelay is the delay before a message is handled again after the handler failed.
+const kafkaRetryDelay = time.Second
+
+type kafkaBroker struct {
+	brokers []string
+	groupID string
+	writer  *kafka.Writer
+	log     LogWriter
+}
+
+// NewKafkaBroker creates a broker for the Kafka cluster of the configuration. Subscriptions join the configured
+// consumer group, so replicas share the partitions of a topic. Messages are published with the hash of their key
+// as partition, messages with the same key are consumed in order.
+func NewKafkaBroker(cfg config.KafkaConfig, log LogWriter) Broker {
+	return &kafkaBroker{
+		brokers: cfg.Brokers,
+		groupID: cfg.GroupID,
+		writer: &kafka.Writer{
+			Addr:     kafka.TCP(cfg.Brokers...),
+			Balancer: &kafka.Hash{},
+		},
+		log: log,
+	}
+}
+
+func (b *kafkaBroker) Subscribe(topic string, handler Handler) (Subscription, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &kafkaSubscription{
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: b.brokers,
+			GroupID: b.groupID,
+			Topic:   topic,
+		}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go s.consume(ctx, handler, b.log)
+	return s, nil
+}
+
+func (b *kafkaBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	msg := kafka.Message{
+		Topic: topic,
+		Key:   message.Key,
+		Value: message.Data,
+	}
+	for key, value := range message.Headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	return b.writer.WriteMessages(ctx, msg)
+}
+
+func (b *kafkaBroker) Ping(ctx context.Context) error {
+	var err error
+	for _, address := range b.brokers {
+		var conn *kafka.Conn
+		if conn, err = kafka.DialContext(ctx, "tcp", address); err == nil {
+			return conn.Close()
+		}
+	}
+	return err
+}
+
+func (b *kafkaBroker) Close() error {
+	return b.writer.Close()
+}
+
+type kafkaSubscription struct {
+	reader *kafka.Reader
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// consume handles the messages of the subscribed topic until the context is canceled. The offset of a message
+// is committed after it was handled. A failed message is handled again, it would be redelivered anyway
+// with the next message that is committed.
+func (s *kafkaSubscription) consume(ctx context.Context, handler Handler, log LogWriter) {
+	defer close(s.done)
+
+	for {
+		msg, err := s.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Warnf("fetching message from kafka topic %s failed: %v", s.reader.Config().Topic, err)
+			if !sleep(ctx, kafkaRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		message := &Message{
+			ID:      fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset),
+			Topic:   msg.Topic,
+			Key:     msg.Key,
+			Data:    msg.Value,
+			Headers: map[string]string{},
+		}
+		for _, header := range msg.Headers {
+			message.Headers[header.Key] = string(header.Value)
+		}
+
+		for handler(ctx, message) != nil {
+			if !sleep(ctx, kafkaRetryDelay) {
+				return
+			}
+		}
+
+		// The context may be canceled while the message was handled, it is committed anyway.
+		if err := s.reader.CommitMessages(context.Background(), msg); err != nil {
+			log.Warnf("committing message %s failed: %v", message.ID, err)
+		}
+	}
+}
+
+func (s *kafkaSubscription) Close() error {
+	s.cancel()
+	<-s.done
+	return s.reader.Close()
+}
+
+// sleep waits for the given duration and returns false if the context was canceled before.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
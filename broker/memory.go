@@ -0,0 +1,128 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// MemoryBroker is an in-process broker for tests. Messages are handled synchronously by Publish
+// and the first subscription of their topic, handler errors are returned to the publisher.
+type MemoryBroker interface {
+	Broker
+	// Published returns all messages published to a topic, oldest first.
+	Published(topic string) []*Message
+}
+
+type memoryBroker struct {
+	subscriptions map[string][]*memorySubscription
+	published     map[string][]*Message
+	sequence      int
+	closed        bool
+	sync.Mutex
+}
+
+// NewMemoryBroker creates a new in-process broker.
+func NewMemoryBroker() MemoryBroker {
+	return &memoryBroker{
+		subscriptions: make(map[string][]*memorySubscription),
+		published:     make(map[string][]*Message),
+	}
+}
+
+func (b *memoryBroker) Subscribe(topic string, handler Handler) (Subscription, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return nil, errors.New(ErrBrokerClosed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &memorySubscription{broker: b, topic: topic, handler: handler, ctx: ctx, cancel: cancel}
+	b.subscriptions[topic] = append(b.subscriptions[topic], s)
+
+	return s, nil
+}
+
+func (b *memoryBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return errors.New(ErrBrokerClosed)
+	}
+	b.sequence++
+	published := &Message{
+		ID:      strconv.Itoa(b.sequence),
+		Topic:   topic,
+		Key:     message.Key,
+		Data:    message.Data,
+		Headers: copyHeaders(message.Headers),
+	}
+	b.published[topic] = append(b.published[topic], published)
+
+	var subscription *memorySubscription
+	if subscriptions := b.subscriptions[topic]; len(subscriptions) > 0 {
+		subscription = subscriptions[0]
+		subscription.running.Add(1)
+	}
+	b.Unlock()
+
+	// The handler may publish, e.g. to a dead letter topic, so it is called without the lock.
+	if subscription == nil {
+		return nil
+	}
+	defer subscription.running.Done()
+	return subscription.handler(subscription.ctx, published)
+}
+
+func (b *memoryBroker) Published(topic string) []*Message {
+	b.Lock()
+	defer b.Unlock()
+
+	return append([]*Message{}, b.published[topic]...)
+}
+
+func (b *memoryBroker) Ping(context.Context) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return errors.New(ErrBrokerClosed)
+	}
+	return nil
+}
+
+func (b *memoryBroker) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	b.closed = true
+	return nil
+}
+
+type memorySubscription struct {
+	broker  *memoryBroker
+	topic   string
+	handler Handler
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func (s *memorySubscription) Close() error {
+	s.broker.Lock()
+	subscriptions := s.broker.subscriptions[s.topic]
+	for i, subscription := range subscriptions {
+		if subscription == s {
+			s.broker.subscriptions[s.topic] = append(subscriptions[:i:i], subscriptions[i+1:]...)
+			break
+		}
+	}
+	s.broker.Unlock()
+
+	s.cancel()
+	s.running.Wait()
+	return nil
+}
@@ -0,0 +1,214 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"golang-microservice-template/config"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// headerMessageID is the NATS header that holds the ID of a message, it is used for deduplication by JetStream.
+	headerMessageID = "Nats-Msg-Id"
+	// natsDrainTimeout bounds the wait for the handlers of received messages when closing.
+	natsDrainTimeout = 10 * time.Second
+	// natsIDPrefixSize is the number of random bytes of the prefix of generated message IDs.
+	natsIDPrefixSize = 8
+)
+
+// errors
+var (
+	ErrDrainTimeout = "draining the NATS %s timed out after %s"
+)
+
+type natsBroker struct {
+	conn       *nats.Conn
+	queueGroup string
+	closed     chan struct{}
+}
+
+// NewNATSBroker connects to the NATS server of the configuration. Subscriptions join the configured queue group,
+// so replicas share the messages. Core NATS delivers at most once, messages are not redelivered after errors.
+func NewNATSBroker(cfg config.NATSConfig) (Broker, error) {
+	closed := make(chan struct{})
+	conn, err := nats.Connect(cfg.URL, nats.Name(cfg.QueueGroup), nats.MaxReconnects(-1),
+		nats.DrainTimeout(natsDrainTimeout),
+		nats.ClosedHandler(func(*nats.Conn) { close(closed) }))
+	if err != nil {
+		return nil, err
+	}
+
+	return &natsBroker{conn: conn, queueGroup: cfg.QueueGroup, closed: closed}, nil
+}
+
+func (b *natsBroker) Subscribe(topic string, handler Handler) (Subscription, error) {
+	prefix, err := generateIDPrefix()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &natsSubscription{conn: b.conn, cancel: cancel, drained: make(chan struct{}), idPrefix: prefix}
+
+	subscription, err := b.conn.QueueSubscribe(topic, b.queueGroup, func(msg *nats.Msg) {
+		headers := map[string]string{}
+		for key := range msg.Header {
+			headers[key] = msg.Header.Get(key)
+		}
+		s.handle(func(sequence int64) {
+			id := headers[headerMessageID]
+			if id == "" {
+				id = fmt.Sprintf("%s-%d", s.idPrefix, sequence)
+			}
+			_ = handler(ctx, &Message{
+				ID:      id,
+				Topic:   msg.Subject,
+				Data:    msg.Data,
+				Headers: headers,
+			})
+		})
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.subscription = subscription
+
+	return s, nil
+}
+
+func (b *natsBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	msg := nats.NewMsg(topic)
+	msg.Data = message.Data
+	for key, value := range message.Headers {
+		msg.Header.Set(key, value)
+	}
+
+	if err := b.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	return b.flush(ctx)
+}
+
+func (b *natsBroker) Ping(ctx context.Context) error {
+	return b.flush(ctx)
+}
+
+// flush waits until the server processed all published messages, with the default timeout if the context has no deadline.
+func (b *natsBroker) flush(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return b.conn.Flush()
+	}
+	return b.conn.FlushWithContext(ctx)
+}
+
+// Close drains the connection: subscriptions that are still open handle their received messages
+// and published messages are flushed, then the connection is closed. It waits at most natsDrainTimeout.
+func (b *natsBroker) Close() error {
+	if err := b.conn.Drain(); err != nil {
+		b.conn.Close()
+		return err
+	}
+
+	timer := time.NewTimer(natsDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-b.closed:
+		return nil
+	case <-timer.C:
+		b.conn.Close()
+		return fmt.Errorf(ErrDrainTimeout, "connection", natsDrainTimeout)
+	}
+}
+
+type natsSubscription struct {
+	subscription *nats.Subscription
+	conn         *nats.Conn
+	cancel       context.CancelFunc
+	// drained is closed when the subscription is closing and all received messages are handled.
+	drained  chan struct{}
+	closing  bool
+	started  int64
+	finished int64
+	// idPrefix identifies the subscription in the IDs of messages without Nats-Msg-Id header.
+	idPrefix string
+	sync.Mutex
+}
+
+// handle runs the handler of a message with its sequence number and signals the end of the drain after the last one.
+func (s *natsSubscription) handle(handler func(sequence int64)) {
+	s.Lock()
+	s.started++
+	sequence := s.started
+	s.Unlock()
+
+	handler(sequence)
+
+	s.Lock()
+	s.finished++
+	s.signalDrained()
+	s.Unlock()
+}
+
+// signalDrained closes drained if the subscription is closing and no message is left. The caller must hold the lock.
+func (s *natsSubscription) signalDrained() {
+	if !s.closing || s.finished < s.started {
+		return
+	}
+	// Pending messages are received but not yet delivered, delivered messages may not have reached handle yet.
+	// The counts fail after the subscription is removed, then all messages are handled.
+	if pending, _, err := s.subscription.Pending(); err == nil && pending > 0 {
+		return
+	}
+	if delivered, err := s.subscription.Delivered(); err == nil && delivered > s.started {
+		return
+	}
+	select {
+	case <-s.drained:
+	default:
+		close(s.drained)
+	}
+}
+
+// Close drains the subscription: the handler gets the messages that were already received, with a context
+// that is canceled after the drain or after natsDrainTimeout.
+func (s *natsSubscription) Close() error {
+	defer s.cancel()
+
+	if err := s.subscription.Drain(); err != nil {
+		return err
+	}
+	// After the flush the server sends no more messages, only the received ones are left.
+	if err := s.conn.FlushTimeout(natsDrainTimeout); err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.closing = true
+	s.signalDrained()
+	s.Unlock()
+
+	timer := time.NewTimer(natsDrainTimeout)
+	defer timer.Stop()
+	select {
+	case <-s.drained:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf(ErrDrainTimeout, "subscription", natsDrainTimeout)
+	}
+}
+
+// generateIDPrefix returns a random prefix for the IDs of the messages of a subscription. Core NATS messages have
+// no ID unless the publisher sets the Nats-Msg-Id header, the generated IDs identify them in logs and audit events.
+func generateIDPrefix() (string, error) {
+	b := make([]byte, natsIDPrefixSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
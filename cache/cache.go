@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores serialized values by key, e.g. in memory or in an external cache shared by all replicas.
+type Cache interface {
+	// Get returns the value of a key, false if the key is unknown or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Generation returns the number of the current generation, which changes with every Delete.
+	Generation(ctx context.Context) (uint64, error)
+	// Set stores the value of a key for ttl if no keys were deleted since the generation was returned by Generation.
+	// A value that was read from the source before a change would otherwise be stored after the change deleted it.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, generation uint64) error
+	// Delete removes keys, unknown keys are ignored. It starts a new generation.
+	Delete(ctx context.Context, keys ...string) error
+}
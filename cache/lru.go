@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	. "golang-microservice-template/utils"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+type lruCache struct {
+	entries    map[string]*list.Element
+	order      *list.List
+	capacity   int
+	generation uint64
+	clock      Clock
+	sync.Mutex
+}
+
+// NewLRUCache creates an in-memory cache of up to capacity entries. The least recently used entry is removed
+// if the capacity is exceeded. Values are shared with the callers and must not be changed.
+func NewLRUCache(capacity int, clock Clock) Cache {
+	return &lruCache{
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+		clock:    clock,
+	}
+}
+
+func (c *lruCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := element.Value.(*entry)
+	if !e.expiresAt.After(c.clock.Now()) {
+		c.remove(element)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(element)
+	return e.value, true, nil
+}
+
+func (c *lruCache) Generation(context.Context) (uint64, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.generation, nil
+}
+
+func (c *lruCache) Set(_ context.Context, key string, value []byte, ttl time.Duration, generation uint64) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if generation != c.generation {
+		return nil
+	}
+
+	e := &entry{key: key, value: value, expiresAt: c.clock.Now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = e
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	for c.order.Len() >= c.capacity {
+		c.remove(c.order.Back())
+	}
+	c.entries[key] = c.order.PushFront(e)
+	return nil
+}
+
+func (c *lruCache) Delete(_ context.Context, keys ...string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.generation++
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// remove deletes an entry. The caller must hold the lock.
+func (c *lruCache) remove(element *list.Element) {
+	delete(c.entries, element.Value.(*entry).key)
+	c.order.Remove(element)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"expvar"
+	"time"
+)
+
+// metrics holds the counters of all instrumented caches by name, published as expvar "cache".
+var metrics = expvar.NewMap("cache")
+
+type instrumentedCache struct {
+	cache  Cache
+	hits   *expvar.Int
+	misses *expvar.Int
+	errors *expvar.Int
+}
+
+// NewInstrumentedCache counts the hits, misses and errors of a cache and publishes them with the hit ratio
+// as expvar "cache.<name>". A cache created with the same name replaces the counters.
+func NewInstrumentedCache(name string, cache Cache) Cache {
+	c := &instrumentedCache{
+		cache:  cache,
+		hits:   new(expvar.Int),
+		misses: new(expvar.Int),
+		errors: new(expvar.Int),
+	}
+
+	counters := new(expvar.Map).Init()
+	counters.Set("hits", c.hits)
+	counters.Set("misses", c.misses)
+	counters.Set("errors", c.errors)
+	counters.Set("hitRatio", expvar.Func(c.hitRatio))
+	metrics.Set(name, counters)
+
+	return c
+}
+
+func (c *instrumentedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok, err := c.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		c.errors.Add(1)
+	case ok:
+		c.hits.Add(1)
+	default:
+		c.misses.Add(1)
+	}
+	return value, ok, err
+}
+
+func (c *instrumentedCache) Generation(ctx context.Context) (uint64, error) {
+	generation, err := c.cache.Generation(ctx)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return generation, err
+}
+
+func (c *instrumentedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration, generation uint64) error {
+	err := c.cache.Set(ctx, key, value, ttl, generation)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return err
+}
+
+func (c *instrumentedCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.cache.Delete(ctx, keys...)
+	if err != nil {
+		c.errors.Add(1)
+	}
+	return err
+}
+
+// Metrics returns the counters of all instrumented caches by name as JSON.
+func Metrics() string {
+	return metrics.String()
+}
+
+// hitRatio returns the share of lookups that were hits, 0 before the first lookup.
+func (c *instrumentedCache) hitRatio() interface{} {
+	hits, misses := c.hits.Value(), c.misses.Value()
+	if hits+misses == 0 {
+		return 0.0
+	}
+	return float64(hits) / float64(hits+misses)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"bytes"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// HeaderCacheControl is the response header that tells clients how long they may reuse a response.
+	HeaderCacheControl = "Cache-Control"
+	// HeaderCacheStatus tells if a response was served from the cache, HIT or MISS.
+	HeaderCacheStatus = "X-Cache"
+
+	cacheControlNoStore = "no-store"
+)
+
+// KeyFunc returns the cache key of a request, an empty key bypasses the cache.
+type KeyFunc func(ctx echo.Context) string
+
+// Middleware serves JSON responses of a GET route from the cache. Successful responses are stored for ttl,
+// the writes of the resource must delete the keys. Responses with status 200 get the cacheControl header,
+// all others no-store. Cache errors are logged and the request is handled without cache.
+func Middleware(cache Cache, ttl time.Duration, cacheControl string, key KeyFunc, log LogWriter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			// Errors must not be reused by clients, the header is replaced when the handler answers with 200.
+			response := ctx.Response()
+			response.Header().Set(HeaderCacheControl, cacheControlNoStore)
+			recorder := &recorder{ResponseWriter: response.Writer, cacheControl: cacheControl}
+			response.Writer = recorder
+			defer func() { response.Writer = recorder.ResponseWriter }()
+
+			k := key(ctx)
+			if k == "" {
+				return next(ctx)
+			}
+
+			request := ctx.Request().Context()
+			value, ok, err := cache.Get(request, k)
+			if err != nil {
+				log.Warnf("reading %s from cache failed: %v", k, err)
+			}
+			if ok {
+				ctx.Response().Header().Set(HeaderCacheStatus, "HIT")
+				return ctx.JSONBlob(http.StatusOK, value)
+			}
+
+			ctx.Response().Header().Set(HeaderCacheStatus, "MISS")
+			// The generation is read before the handler, so a response that is outdated by a concurrent change is not stored.
+			generation, err := cache.Generation(request)
+			if err != nil {
+				log.Warnf("reading the cache generation failed: %v", err)
+				return next(ctx)
+			}
+			recorder.recording = true
+			err = next(ctx)
+
+			if err == nil && response.Status == http.StatusOK {
+				if setErr := cache.Set(request, k, recorder.body.Bytes(), ttl, generation); setErr != nil {
+					log.Warnf("writing %s to cache failed: %v", k, setErr)
+				}
+			}
+			return err
+		}
+	}
+}
+
+// recorder sets the Cache-Control header of successful responses and copies the response body while recording.
+type recorder struct {
+	http.ResponseWriter
+	cacheControl string
+	recording    bool
+	body         bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if code == http.StatusOK {
+		r.Header().Set(HeaderCacheControl, r.cacheControl)
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.recording {
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
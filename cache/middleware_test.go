@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetIsIgnoredAfterDeletion(t *testing.T) {
+	c := NewLRUCache(10, SystemClock())
+	ctx := context.Background()
+	generation, err := c.Generation(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, c.Delete(ctx, "pizza"))
+	require.NoError(t, c.Set(ctx, "pizza", []byte("stale"), time.Minute, generation))
+
+	_, ok, _ := c.Get(ctx, "pizza")
+	assert.False(t, ok)
+
+	generation, _ = c.Generation(ctx)
+	require.NoError(t, c.Set(ctx, "pizza", []byte("fresh"), time.Minute, generation))
+	value, ok, _ := c.Get(ctx, "pizza")
+	assert.True(t, ok)
+	assert.Equal(t, "fresh", string(value))
+}
+
+func TestMiddlewareDoesNotStoreResponseOutdatedByConcurrentChange(t *testing.T) {
+	c := NewLRUCache(10, SystemClock())
+	version := "old"
+	e := echo.New()
+	e.GET("/", func(ctx echo.Context) error {
+		body := `"` + version + `"`
+		// a change commits and invalidates while the old state is being answered
+		version = "new"
+		if err := c.Delete(context.Background(), "key"); err != nil {
+			return err
+		}
+		return ctx.JSONBlob(http.StatusOK, []byte(body))
+	}, Middleware(c, time.Minute, "no-cache", func(echo.Context) string { return "key" }, Log))
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	first := get()
+	assert.Equal(t, "MISS", first.Header().Get(HeaderCacheStatus))
+	assert.Equal(t, `"old"`, first.Body.String())
+
+	second := get()
+	assert.Equal(t, "MISS", second.Header().Get(HeaderCacheStatus))
+	assert.Equal(t, `"new"`, second.Body.String())
+}
+
+func TestMiddlewareSetsCacheControlOnlyOnSuccess(t *testing.T) {
+	c := NewLRUCache(10, SystemClock())
+	e := echo.New()
+	cached := Middleware(c, time.Minute, "public, max-age=60", func(ctx echo.Context) string { return ctx.Param("name") }, Log)
+	e.GET("/pizza/:name", func(ctx echo.Context) error {
+		if ctx.Param("name") != "margherita" {
+			return Error("pizza not found", ErrorTypeResourceNotFound)
+		}
+		return ctx.JSONBlob(http.StatusOK, []byte(`{"name":"margherita"}`))
+	}, cached)
+	e.GET("/uncached", func(ctx echo.Context) error {
+		return Error("slow down", ErrorTypeTooManyRequests)
+	}, Middleware(c, time.Minute, "public, max-age=60", func(echo.Context) string { return "" }, Log))
+	e.HTTPErrorHandler = func(err error, ctx echo.Context) {
+		status, body := ErrorResponse(err, "")
+		_ = ctx.JSON(status, body)
+	}
+
+	tests := []struct {
+		target       string
+		status       int
+		cacheControl string
+	}{
+		{"/pizza/margherita", http.StatusOK, "public, max-age=60"},
+		{"/pizza/margherita", http.StatusOK, "public, max-age=60"},
+		{"/pizza/hawaii", http.StatusNotFound, "no-store"},
+		{"/uncached", http.StatusTooManyRequests, "no-store"},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.target, nil))
+
+		assert.Equal(t, test.status, rec.Code, test.target)
+		assert.Equal(t, test.cacheControl, rec.Header().Get(HeaderCacheControl), test.target)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"golang-microservice-template/api"
+	"golang-microservice-template/cache"
+	"golang-microservice-template/config"
+	"golang-microservice-template/events"
+	"golang-microservice-template/loadgen"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Targets of the load generator
+const (
+	targetHTTP       = "http"
+	targetInProcess  = "in-process"
+	targetRepository = "repository"
+)
+
+// errors
+var (
+	ErrUnknownTarget = "unknown target '%s', expected http, in-process or repository"
+	ErrMissingURL    = "the http target requires -url"
+	ErrInvalidHeader = "invalid header '%s', expected Name: value"
+)
+
+// headers collects the repeatable -header flag.
+type headers []string
+
+func (h *headers) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headers) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run executes a load test and prints its report. It returns the exit code of the process.
+func run() int {
+	var extra headers
+	target := flag.String("target", targetHTTP, "target of the load: http, in-process or repository")
+	baseURL := flag.String("url", "http://localhost:8080", "base URL of a running instance for the http target")
+	duration := flag.Duration("duration", 10*time.Second, "duration of the test")
+	concurrency := flag.Int("concurrency", 8, "number of concurrent workers")
+	rate := flag.Float64("rate", 0, "maximum operations per second, 0 for no limit")
+	mix := flag.String("mix", loadgen.DefaultMix, "relative weight of the operations")
+	pizzas := flag.Int("pizzas", 100, "number of pizzas that are read and updated")
+	token := flag.String("token", "", "bearer token of the http target")
+	apiKey := flag.String("api-key", "", "API key of the http target")
+	cacheEnabled := flag.Bool("cache", true, "enable the response cache of the in-process target")
+	shards := flag.Int("shards", 0, "shards of the sharded repository of the in-process and repository targets, 0 for the map repository")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	flag.Var(&extra, "header", "additional header of the http target, e.g. 'X-Request-ID: load', repeatable")
+	flag.Parse()
+
+	level, _ := ParseLogLevel("error")
+	Log.SetLevel(level)
+
+	weights, err := loadgen.ParseMix(*mix)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	header, err := requestHeader(extra, *token, *apiKey)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	t, stop, err := newTarget(*target, *baseURL, header, *cacheEnabled, *shards)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer stop()
+
+	// an interrupt ends the test early and still prints the report
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	report, err := loadgen.Run(ctx, t, loadgen.Config{
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Mix:         weights,
+		Pizzas:      *pizzas,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.Write(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// requestHeader builds the headers that are added to every request of the http target.
+func requestHeader(extra []string, token, apiKey string) (http.Header, error) {
+	header := http.Header{}
+	for _, h := range extra {
+		pair := strings.SplitN(h, ":", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf(ErrInvalidHeader, h)
+		}
+		header.Add(strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1]))
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if apiKey != "" {
+		header.Set("X-API-Key", apiKey)
+	}
+	return header, nil
+}
+
+// newTarget creates the target of the load and a function that releases its resources.
+func newTarget(name, baseURL string, header http.Header, cacheEnabled bool, shards int) (loadgen.Target, func(), error) {
+	clock := SystemClock()
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: 1000},
+	}
+
+	switch name {
+	case targetHTTP:
+		if baseURL == "" {
+			return nil, nil, fmt.Errorf(ErrMissingURL)
+		}
+		return loadgen.NewHTTPTarget(strings.TrimRight(baseURL, "/"), client, header), func() {}, nil
+
+	case targetRepository:
+		repository := newRepository(clock, shards)
+		return loadgen.NewRepositoryTarget(repository), func() { _ = repository.Close() }, nil
+
+	case targetInProcess:
+		cfg := config.Default()
+		repository := newRepository(clock, shards)
+
+		// the relay drains the outbox like in the service, without subscribers
+		relay := events.NewRelay(repository.Outbox(), events.NewInProcessBus(), clock, Log, cfg.Events)
+		stopRelay := make(chan struct{})
+		go relay.Run(stopRelay)
+
+		serviceOptions := []pizza.ServiceOption{
+			pizza.WithRepository(repository),
+			pizza.WithClock(clock),
+			pizza.WithLogger(Log),
+			pizza.WithConfig(cfg.Pizza),
+		}
+		routerOptions := []api.RouterOption{
+			api.WithConfig(cfg),
+			api.WithLogger(Log),
+			api.WithClock(clock),
+		}
+		if cacheEnabled {
+			responses := cache.NewLRUCache(cfg.Cache.Capacity, clock)
+			serviceOptions = append(serviceOptions, pizza.WithCache(responses))
+			routerOptions = append(routerOptions, api.WithCache(responses))
+		}
+		routerOptions = append(routerOptions, api.WithPizzaService(pizza.NewService(serviceOptions...)))
+
+		server := httptest.NewServer(api.NewRouter(routerOptions...))
+		return loadgen.NewHTTPTarget(server.URL, client, header), func() {
+			server.Close()
+			close(stopRelay)
+			_ = repository.Close()
+		}, nil
+
+	default:
+		return nil, nil, fmt.Errorf(ErrUnknownTarget, name)
+	}
+}
+
+// newRepository creates the sharded repository if shards are given, the map repository otherwise.
+func newRepository(clock Clock, shards int) pizza.Repository {
+	if shards > 0 {
+		return pizza.NewShardedRepository(clock, shards)
+	}
+	return pizza.NewRepository(clock)
+}
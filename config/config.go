@@ -0,0 +1,746 @@
+package config
+
+import (
+	"fmt"
+	. "golang-microservice-template/utils"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPort = 8080
+	localPort   = 8081
+)
+
+// defaultRateLimits are used for all route classes without a configured limit.
+var defaultRateLimits = map[string]RateLimit{
+	"read":    {RequestsPerSecond: 20, Burst: 40},
+	"write":   {RequestsPerSecond: 2, Burst: 10},
+	"address": {RequestsPerSecond: 50, Burst: 100},
+}
+
+// errors
+var (
+	ErrInvalidConfig = "invalid configuration: %s"
+)
+
+// Config holds all settings of the service.
+// Only the log level, the rate limits, the CORS settings and the feature flags can be changed at runtime, see Manager.
+type Config struct {
+	// File is the path of the configuration file the settings were loaded from.
+	File string `yaml:"-"`
+	// Environment is one of the environment keywords, see utils.Environment.
+	Environment string `yaml:"environment"`
+	// WatchInterval is the interval in which the configuration file is checked for changes, 0 disables watching.
+	WatchInterval time.Duration     `yaml:"watchInterval"`
+	Server        ServerConfig      `yaml:"server"`
+	Log           LogConfig         `yaml:"log"`
+	Health        HealthConfig      `yaml:"health"`
+	Pizza         PizzaConfig       `yaml:"pizza"`
+	Auth          AuthConfig        `yaml:"auth"`
+	RateLimit     RateLimitConfig   `yaml:"rateLimit"`
+	CORS          CORSConfig        `yaml:"cors"`
+	Security      SecurityConfig    `yaml:"security"`
+	Audit         AuditConfig       `yaml:"audit"`
+	Events        EventsConfig      `yaml:"events"`
+	Webhooks      WebhookConfig     `yaml:"webhooks"`
+	Stream        StreamConfig      `yaml:"stream"`
+	Consumer      ConsumerConfig    `yaml:"consumer"`
+	Idempotency   IdempotencyConfig `yaml:"idempotency"`
+	Cache         CacheConfig       `yaml:"cache"`
+	Snapshot      SnapshotConfig    `yaml:"snapshot"`
+	// Features toggles optional behavior by name.
+	Features map[string]bool `yaml:"features"`
+}
+
+// ServerConfig holds the settings of the HTTP server.
+type ServerConfig struct {
+	// Port to listen on. Defaults to 8081 in the local environment and 8080 otherwise.
+	Port int `yaml:"port"`
+	// DrainPeriod is the time between the start of the shutdown and closing the listener.
+	// Readiness fails during this period, so load balancers stop sending new requests.
+	DrainPeriod time.Duration `yaml:"drainPeriod"`
+	// ShutdownTimeout is the maximum time to wait for in-flight requests on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	TLS             TLSConfig     `yaml:"tls"`
+	// TrustedProxies are the IP addresses or CIDR ranges of reverse proxies. Only their X-Forwarded-For and
+	// X-Real-IP headers are honoured to identify clients, otherwise the client is the peer of the connection.
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// TLSConfig holds the settings of the HTTPS listener.
+type TLSConfig struct {
+	// CertFile is the path of the PEM encoded certificate chain. The server uses plain HTTP if empty.
+	CertFile string `yaml:"certFile"`
+	// KeyFile is the path of the PEM encoded private key.
+	KeyFile string `yaml:"keyFile"`
+	// ReloadInterval is the minimum time between checks whether the certificate files changed.
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+}
+
+// Enabled returns true if the server uses HTTPS.
+func (c *TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// LogConfig holds the logging settings.
+type LogConfig struct {
+	// Level is the minimum level of log messages, one of debug, info, warn or error.
+	Level string `yaml:"level"`
+}
+
+// HealthConfig holds the settings of the readiness checks.
+type HealthConfig struct {
+	// CheckTimeout cancels a single check after the given time.
+	CheckTimeout time.Duration `yaml:"checkTimeout"`
+	// CacheTTL is the time a readiness report is reused before the checks run again.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// DiskPath is the path of the file system that is checked for free space.
+	DiskPath string `yaml:"diskPath"`
+	// DiskMinFreeBytes is the minimum free space on DiskPath.
+	DiskMinFreeBytes uint64 `yaml:"diskMinFreeBytes"`
+}
+
+// PizzaConfig holds the settings of the pizza controller and repository.
+type PizzaConfig struct {
+	// MaxIngredients limits the number of ingredients of a pizza, 0 means unlimited.
+	MaxIngredients int `yaml:"maxIngredients"`
+	// Repository is the in-memory storage of the pizzas, one of map or sharded.
+	Repository string `yaml:"repository"`
+	// Shards is the number of partitions of the sharded repository.
+	Shards int `yaml:"shards"`
+}
+
+// Repositories of the pizzas
+const (
+	RepositoryMap     = "map"
+	RepositorySharded = "sharded"
+)
+
+// AuthConfig holds the settings of the authentication of requests to the /v1 routes.
+type AuthConfig struct {
+	JWT     JWTConfig    `yaml:"jwt"`
+	APIKeys APIKeyConfig `yaml:"apiKeys"`
+	MTLS    MTLSConfig   `yaml:"mtls"`
+}
+
+// MTLSConfig holds the settings of the client certificate authentication of other services.
+type MTLSConfig struct {
+	// Enabled requests a client certificate during the TLS handshake, it requires server.tls.
+	Enabled bool `yaml:"enabled"`
+	// ClientCAFile is the path of the PEM encoded CA bundle client certificates are verified against.
+	ClientCAFile string `yaml:"clientCAFile"`
+	// Required rejects requests to the /v1 routes without a valid client certificate.
+	// Otherwise a client certificate is one authentication method among others.
+	Required bool `yaml:"required"`
+	// Clients grants roles and scopes to client identities, i.e. the common name or a subject alternative name
+	// of the certificate. Clients that are not listed are forbidden.
+	Clients map[string]ClientPermissions `yaml:"clients"`
+}
+
+// ClientPermissions holds the roles and scopes granted to a client identity.
+type ClientPermissions struct {
+	Roles  []string `yaml:"roles"`
+	Scopes []string `yaml:"scopes"`
+}
+
+// APIKeyConfig holds the settings of the API key authentication.
+type APIKeyConfig struct {
+	// Enabled accepts API keys for all /v1 routes and enables the key administration routes.
+	Enabled bool `yaml:"enabled"`
+	// Header is the request header that holds the API key.
+	Header string `yaml:"header"`
+	// File is the path of the JSON file the hashed keys are stored in. Keys are kept in memory if empty.
+	File string `yaml:"file"`
+	// AdminKey is an API key that may manage all other keys, it is used to create the first keys.
+	AdminKey string `yaml:"adminKey" secret:"true"`
+}
+
+// Enabled returns true if any authentication method is enabled.
+func (c *AuthConfig) Enabled() bool {
+	return c.JWT.Enabled || c.APIKeys.Enabled || c.MTLS.Enabled
+}
+
+// JWTConfig holds the settings of the JWT bearer authentication.
+type JWTConfig struct {
+	// Enabled accepts bearer tokens for all /v1 routes.
+	Enabled bool `yaml:"enabled"`
+	// Algorithm is the expected signing algorithm, HS256 or RS256.
+	Algorithm string `yaml:"algorithm"`
+	// Secret is the shared key for HS256.
+	Secret string `yaml:"secret" secret:"true"`
+	// PublicKeyFile is the path of a PEM encoded RSA public key for RS256.
+	PublicKeyFile string `yaml:"publicKeyFile"`
+	// JWKSFile is the path of a local JSON Web Key Set with RSA keys for RS256, selected by the key id of the token.
+	JWKSFile string `yaml:"jwksFile"`
+	// JWKSReloadInterval is the minimum time between checks whether the key set file changed,
+	// the file is checked when a token names an unknown key id.
+	JWKSReloadInterval time.Duration `yaml:"jwksReloadInterval"`
+	// Issuer must match the iss claim if set.
+	Issuer string `yaml:"issuer"`
+	// Audience must be contained in the aud claim if set.
+	Audience string `yaml:"audience"`
+	// Leeway is the tolerated clock skew when checking exp, nbf and iat.
+	Leeway time.Duration `yaml:"leeway"`
+}
+
+// RateLimitConfig holds the settings of the rate limiting of the /v1 routes.
+type RateLimitConfig struct {
+	// Enabled limits the requests of each client, identified by API key, token subject or IP address.
+	Enabled bool `yaml:"enabled"`
+	// Limits are the token buckets of the route classes read and write and of the class address,
+	// which limits all requests of an IP address before authentication.
+	Limits map[string]RateLimit `yaml:"limits"`
+}
+
+// RateLimit is the token bucket of a route class.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate of requests.
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	// Burst is the number of requests that can be made at once.
+	Burst int `yaml:"burst"`
+}
+
+// CORSConfig holds the cross-origin resource sharing settings for browser clients.
+type CORSConfig struct {
+	// AllowOrigins are the origins that may call the service, e.g. https://menu.example.com. CORS is disabled if empty.
+	AllowOrigins     []string `yaml:"allowOrigins"`
+	AllowMethods     []string `yaml:"allowMethods"`
+	AllowHeaders     []string `yaml:"allowHeaders"`
+	ExposeHeaders    []string `yaml:"exposeHeaders"`
+	AllowCredentials bool     `yaml:"allowCredentials"`
+	// MaxAge is the time in seconds browsers may cache a preflight response.
+	MaxAge int `yaml:"maxAge"`
+}
+
+// SecurityConfig holds the settings of the security headers that are added to every response.
+type SecurityConfig struct {
+	// HSTSMaxAge is the max-age of the Strict-Transport-Security header in seconds, 0 disables the header.
+	// The header is only sent on HTTPS requests.
+	HSTSMaxAge int `yaml:"hstsMaxAge"`
+	// ContentSecurityPolicy is the value of the Content-Security-Policy header.
+	ContentSecurityPolicy string `yaml:"contentSecurityPolicy"`
+	// FrameOptions is the value of the X-Frame-Options header.
+	FrameOptions string `yaml:"frameOptions"`
+}
+
+// Audit sinks
+const (
+	AuditSinkMemory = "memory"
+	AuditSinkFile   = "file"
+	AuditSinkLog    = "log"
+)
+
+// AuditConfig holds the settings of the audit log of all pizza mutations.
+type AuditConfig struct {
+	// Sink is where audit events are written to, one of memory, file or log.
+	// Events written to the log cannot be queried with GET /v1/audit.
+	Sink string `yaml:"sink"`
+	// File is the path of the JSON lines file of the file sink.
+	File string `yaml:"file"`
+	// MemoryCapacity is the number of events the memory sink keeps, older events are dropped.
+	MemoryCapacity int `yaml:"memoryCapacity"`
+}
+
+// EventsConfig holds the settings of the delivery of domain events from the outbox.
+type EventsConfig struct {
+	// PollInterval is the interval in which the outbox is checked for pending events.
+	PollInterval time.Duration `yaml:"pollInterval"`
+	// BatchSize is the maximum number of events published per poll.
+	BatchSize int `yaml:"batchSize"`
+	// RetryBaseDelay is the delay before the first retry of a failed event, it doubles with every attempt.
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay"`
+	// RetryMaxDelay caps the delay between retries.
+	RetryMaxDelay time.Duration `yaml:"retryMaxDelay"`
+	// MaxAttempts is the number of attempts to publish an event before it is moved to the dead letters.
+	MaxAttempts int `yaml:"maxAttempts"`
+}
+
+// WebhookConfig holds the settings of the webhook deliveries to partners.
+type WebhookConfig struct {
+	// Enabled enables the /v1/webhooks routes and the delivery of pizza events to the subscriptions.
+	Enabled bool `yaml:"enabled"`
+	// Timeout is the maximum duration of a delivery request.
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxAttempts is the number of attempts to deliver an event to a subscription.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryBaseDelay is the delay before the first retry of a failed delivery, it doubles with every attempt.
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay"`
+	// RetryMaxDelay caps the delay between retries.
+	RetryMaxDelay time.Duration `yaml:"retryMaxDelay"`
+	// DisableAfterFailures is the number of consecutive failed attempts after which a subscription is disabled.
+	DisableAfterFailures int `yaml:"disableAfterFailures"`
+	// Workers is the number of deliveries made in parallel.
+	Workers int `yaml:"workers"`
+	// DeliveryLogSize is the number of deliveries kept per subscription.
+	DeliveryLogSize int `yaml:"deliveryLogSize"`
+	// AllowPrivateAddresses permits callbacks to loopback, link-local and private addresses, e.g. for local tests.
+	// Otherwise such urls are rejected when a subscription is saved and connections to them are refused.
+	AllowPrivateAddresses bool `yaml:"allowPrivateAddresses"`
+}
+
+// StreamConfig holds the settings of the Server-Sent Events stream of pizza changes.
+type StreamConfig struct {
+	// ReplayBufferSize is the number of events kept to resume streams after reconnects.
+	ReplayBufferSize int `yaml:"replayBufferSize"`
+	// HeartbeatInterval is the interval of comments sent to keep idle streams open.
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval"`
+}
+
+// CacheConfig holds the settings of the response cache of the pizza read routes.
+type CacheConfig struct {
+	// Enabled caches the responses of GET /v1/pizza and GET /v1/pizza/:name in memory.
+	Enabled bool `yaml:"enabled"`
+	// Capacity is the number of cached responses, the least recently used are removed first.
+	Capacity int `yaml:"capacity"`
+	// TTL is the time a response is cached. Responses are removed earlier when a pizza changes.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxAge is the max-age of the Cache-Control header in seconds, 0 makes clients revalidate every response.
+	MaxAge int `yaml:"maxAge"`
+}
+
+// SnapshotConfig holds the settings of the snapshots of the in-memory pizza repository.
+type SnapshotConfig struct {
+	// Enabled restores the snapshot file on startup and writes it on shutdown.
+	Enabled bool `yaml:"enabled"`
+	// File is the path of the JSON snapshot file.
+	File string `yaml:"file"`
+	// Interval is the time between snapshots while the service is running, 0 writes only on shutdown.
+	Interval time.Duration `yaml:"interval"`
+	// MaxUploadSize is the maximum size in bytes of a request that restores a snapshot, larger requests get 413.
+	MaxUploadSize int64 `yaml:"maxUploadSize"`
+}
+
+// IdempotencyConfig holds the settings of the Idempotency-Key support of POST and PATCH routes.
+type IdempotencyConfig struct {
+	// Enabled stores responses to requests with an Idempotency-Key header and replays them for retries.
+	Enabled bool `yaml:"enabled"`
+	// TTL is the time a key and its response are kept.
+	TTL time.Duration `yaml:"ttl"`
+	// MaxEntries is the number of keys kept in memory, the oldest are removed first.
+	MaxEntries int `yaml:"maxEntries"`
+	// MaxBodySize is the maximum size in bytes of the body of a request with a key, larger requests get 413.
+	MaxBodySize int64 `yaml:"maxBodySize"`
+}
+
+// Message brokers
+const (
+	BrokerNATS  = "nats"
+	BrokerKafka = "kafka"
+)
+
+// ConsumerConfig holds the settings of the consumption of pizza commands from a message broker.
+type ConsumerConfig struct {
+	// Enabled enables the consumer of the commands topic.
+	Enabled bool `yaml:"enabled"`
+	// Broker is the message broker to connect to, one of nats or kafka.
+	Broker string `yaml:"broker"`
+	// Topic is the NATS subject or Kafka topic of the pizza commands.
+	Topic string `yaml:"topic"`
+	// DeadLetterTopic receives the commands that cannot be executed.
+	DeadLetterTopic string `yaml:"deadLetterTopic"`
+	// MaxAttempts is the number of attempts to execute a command that failed with a temporary error.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// RetryBaseDelay is the delay before the first retry of a failed command, it doubles with every attempt.
+	RetryBaseDelay time.Duration `yaml:"retryBaseDelay"`
+	// RetryMaxDelay caps the delay between retries.
+	RetryMaxDelay time.Duration `yaml:"retryMaxDelay"`
+	NATS          NATSConfig    `yaml:"nats"`
+	Kafka         KafkaConfig   `yaml:"kafka"`
+}
+
+// NATSConfig holds the connection settings of a NATS server.
+type NATSConfig struct {
+	// URL of the server, e.g. nats://localhost:4222.
+	URL string `yaml:"url"`
+	// QueueGroup is shared by all replicas, each message is handled by one of them.
+	QueueGroup string `yaml:"queueGroup"`
+}
+
+// KafkaConfig holds the connection settings of a Kafka cluster.
+type KafkaConfig struct {
+	// Brokers are the addresses of the bootstrap brokers, e.g. localhost:9092.
+	Brokers []string `yaml:"brokers"`
+	// GroupID is the consumer group shared by all replicas.
+	GroupID string `yaml:"groupID"`
+}
+
+// Default returns a configuration with pre-defined values.
+func Default() *Config {
+	return &Config{
+		Environment: ENV_LOCAL,
+		Server: ServerConfig{
+			ShutdownTimeout: 5 * time.Second,
+			TLS: TLSConfig{
+				ReloadInterval: 10 * time.Second,
+			},
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Health: HealthConfig{
+			CheckTimeout:     2 * time.Second,
+			CacheTTL:         5 * time.Second,
+			DiskPath:         "/",
+			DiskMinFreeBytes: 100 * 1024 * 1024,
+		},
+		Pizza: PizzaConfig{
+			Repository: RepositoryMap,
+			Shards:     32,
+		},
+		Auth: AuthConfig{
+			JWT: JWTConfig{
+				Algorithm:          "HS256",
+				JWKSReloadInterval: time.Minute,
+				Leeway:             30 * time.Second,
+			},
+			APIKeys: APIKeyConfig{
+				Header: "X-API-Key",
+			},
+		},
+		CORS: CORSConfig{
+			AllowMethods:  []string{"GET", "HEAD", "POST", "PATCH", "DELETE"},
+			AllowHeaders:  []string{"Authorization", "Content-Type", "X-API-Key", "X-Request-ID", "Idempotency-Key"},
+			ExposeHeaders: []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Idempotent-Replayed"},
+			MaxAge:        600,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            31536000,
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			FrameOptions:          "DENY",
+		},
+		Audit: AuditConfig{
+			Sink:           AuditSinkMemory,
+			MemoryCapacity: 10000,
+		},
+		Webhooks: WebhookConfig{
+			Timeout:              5 * time.Second,
+			MaxAttempts:          8,
+			RetryBaseDelay:       time.Second,
+			RetryMaxDelay:        5 * time.Minute,
+			DisableAfterFailures: 20,
+			Workers:              4,
+			DeliveryLogSize:      100,
+		},
+		Stream: StreamConfig{
+			ReplayBufferSize:  1000,
+			HeartbeatInterval: 15 * time.Second,
+		},
+		Cache: CacheConfig{
+			Enabled:  true,
+			Capacity: 1000,
+			TTL:      time.Minute,
+		},
+		Snapshot: SnapshotConfig{
+			File:          "pizza-snapshot.json",
+			Interval:      5 * time.Minute,
+			MaxUploadSize: 32 << 20,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:     true,
+			TTL:         24 * time.Hour,
+			MaxEntries:  10000,
+			MaxBodySize: 1 << 20,
+		},
+		Consumer: ConsumerConfig{
+			Broker:          BrokerNATS,
+			Topic:           "pizza.commands",
+			DeadLetterTopic: "pizza.commands.dead-letter",
+			MaxAttempts:     3,
+			RetryBaseDelay:  500 * time.Millisecond,
+			RetryMaxDelay:   10 * time.Second,
+			NATS: NATSConfig{
+				URL:        "nats://localhost:4222",
+				QueueGroup: "pizza-service",
+			},
+			Kafka: KafkaConfig{
+				GroupID: "pizza-service",
+			},
+		},
+		Events: EventsConfig{
+			PollInterval:   500 * time.Millisecond,
+			BatchSize:      100,
+			RetryBaseDelay: time.Second,
+			RetryMaxDelay:  time.Minute,
+			MaxAttempts:    10,
+		},
+		Features: map[string]bool{},
+	}
+}
+
+// Feature flags
+const (
+	// FeatureReadOnly rejects all changes of pizzas with 503, e.g. during maintenance. Reads are served.
+	FeatureReadOnly = "readOnly"
+)
+
+// FeatureEnabled returns true if the feature flag with the given name is set.
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.Features[name]
+}
+
+// withReloadable returns a copy of c that contains all settings of from which can be changed at runtime.
+func (c *Config) withReloadable(from *Config) *Config {
+	next := *c
+	next.Log = from.Log
+	next.RateLimit.Limits = from.RateLimit.Limits
+	next.CORS = from.CORS
+	next.Features = from.Features
+
+	return &next
+}
+
+// IsProduction returns true if the service runs in the production environment.
+func (c *Config) IsProduction() bool {
+	return c.Environment == ENV_PROD
+}
+
+// Address returns the address the HTTP server listens on.
+func (c *Config) Address() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
+
+// LogLevel returns the parsed log level. Call Validate before to ensure the level is valid.
+func (c *Config) LogLevel() LogLevel {
+	level, _ := ParseLogLevel(c.Log.Level)
+	return level
+}
+
+// complete sets all values that are derived from other settings.
+func (c *Config) complete() {
+	c.Environment = NormalizeEnvironment(c.Environment)
+
+	if c.Server.Port == 0 {
+		c.Server.Port = defaultPort
+		if c.Environment == ENV_LOCAL {
+			c.Server.Port = localPort
+		}
+	}
+
+	if c.RateLimit.Limits == nil {
+		c.RateLimit.Limits = map[string]RateLimit{}
+	}
+	for class, limit := range defaultRateLimits {
+		if _, ok := c.RateLimit.Limits[class]; !ok {
+			c.RateLimit.Limits[class] = limit
+		}
+	}
+}
+
+// Validate checks all settings and returns an error listing every invalid value.
+func (c *Config) Validate() error {
+	problems := []string{}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	if c.Server.DrainPeriod < 0 {
+		problems = append(problems, fmt.Sprintf("server.drainPeriod must not be negative, got %s", c.Server.DrainPeriod))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("server.shutdownTimeout must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	if _, err := ParseLogLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level must be one of debug, info, warn, error, got '%s'", c.Log.Level))
+	}
+	if c.WatchInterval < 0 {
+		problems = append(problems, fmt.Sprintf("watchInterval must not be negative, got %s", c.WatchInterval))
+	}
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		problems = append(problems, "server.tls.certFile and server.tls.keyFile must be set together")
+	}
+	if c.Server.TLS.Enabled() && c.Server.TLS.ReloadInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("server.tls.reloadInterval must be positive, got %s", c.Server.TLS.ReloadInterval))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if !validAddressRange(proxy) {
+			problems = append(problems, fmt.Sprintf("server.trustedProxies must be IP addresses or CIDR ranges, got '%s'", proxy))
+		}
+	}
+	if c.CORS.AllowCredentials && containsString(c.CORS.AllowOrigins, "*") {
+		problems = append(problems, "cors.allowOrigins must not contain * if cors.allowCredentials is set")
+	}
+	if c.Security.HSTSMaxAge < 0 {
+		problems = append(problems, fmt.Sprintf("security.hstsMaxAge must not be negative, got %d", c.Security.HSTSMaxAge))
+	}
+	if c.Health.CheckTimeout <= 0 {
+		problems = append(problems, fmt.Sprintf("health.checkTimeout must be positive, got %s", c.Health.CheckTimeout))
+	}
+	if c.Health.CacheTTL < 0 {
+		problems = append(problems, fmt.Sprintf("health.cacheTTL must not be negative, got %s", c.Health.CacheTTL))
+	}
+	if c.Health.DiskPath == "" {
+		problems = append(problems, "health.diskPath must not be empty")
+	}
+	if c.Pizza.MaxIngredients < 0 {
+		problems = append(problems, fmt.Sprintf("pizza.maxIngredients must not be negative, got %d", c.Pizza.MaxIngredients))
+	}
+	switch c.Pizza.Repository {
+	case RepositoryMap:
+	case RepositorySharded:
+		if c.Pizza.Shards < 1 {
+			problems = append(problems, fmt.Sprintf("pizza.shards must be positive, got %d", c.Pizza.Shards))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("pizza.repository must be one of map, sharded, got '%s'", c.Pizza.Repository))
+	}
+
+	if c.Environment == ENV_PROD && !c.Auth.Enabled() {
+		problems = append(problems, "auth.jwt.enabled, auth.apiKeys.enabled or auth.mtls.enabled must be true in production")
+	}
+	problems = append(problems, c.Auth.JWT.validate()...)
+	if c.Auth.APIKeys.Enabled && c.Auth.APIKeys.Header == "" {
+		problems = append(problems, "auth.apiKeys.header must not be empty")
+	}
+	if c.Auth.MTLS.Enabled && !c.Server.TLS.Enabled() {
+		problems = append(problems, "auth.mtls.enabled requires server.tls.certFile and server.tls.keyFile")
+	}
+	if c.Auth.MTLS.Enabled && c.Auth.MTLS.ClientCAFile == "" {
+		problems = append(problems, "auth.mtls.clientCAFile must not be empty")
+	}
+
+	switch c.Audit.Sink {
+	case AuditSinkMemory:
+		if c.Audit.MemoryCapacity < 1 {
+			problems = append(problems, fmt.Sprintf("audit.memoryCapacity must be positive, got %d", c.Audit.MemoryCapacity))
+		}
+	case AuditSinkFile:
+		if c.Audit.File == "" {
+			problems = append(problems, "audit.file must not be empty")
+		}
+	case AuditSinkLog:
+	default:
+		problems = append(problems, fmt.Sprintf("audit.sink must be one of memory, file, log, got '%s'", c.Audit.Sink))
+	}
+
+	if c.Stream.ReplayBufferSize < 1 {
+		problems = append(problems, fmt.Sprintf("stream.replayBufferSize must be positive, got %d", c.Stream.ReplayBufferSize))
+	}
+	if c.Stream.HeartbeatInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("stream.heartbeatInterval must be positive, got %s", c.Stream.HeartbeatInterval))
+	}
+	if c.Events.PollInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("events.pollInterval must be positive, got %s", c.Events.PollInterval))
+	}
+	if c.Events.BatchSize < 1 {
+		problems = append(problems, fmt.Sprintf("events.batchSize must be positive, got %d", c.Events.BatchSize))
+	}
+	if c.Events.RetryBaseDelay <= 0 || c.Events.RetryMaxDelay < c.Events.RetryBaseDelay {
+		problems = append(problems, "events.retryBaseDelay must be positive and not greater than events.retryMaxDelay")
+	}
+	if c.Events.MaxAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("events.maxAttempts must be positive, got %d", c.Events.MaxAttempts))
+	}
+
+	if c.Webhooks.Enabled {
+		if c.Webhooks.Timeout <= 0 {
+			problems = append(problems, fmt.Sprintf("webhooks.timeout must be positive, got %s", c.Webhooks.Timeout))
+		}
+		if c.Webhooks.MaxAttempts < 1 || c.Webhooks.DisableAfterFailures < 1 || c.Webhooks.Workers < 1 || c.Webhooks.DeliveryLogSize < 1 {
+			problems = append(problems, "webhooks.maxAttempts, webhooks.disableAfterFailures, webhooks.workers and webhooks.deliveryLogSize must be positive")
+		}
+		if c.Webhooks.RetryBaseDelay <= 0 || c.Webhooks.RetryMaxDelay < c.Webhooks.RetryBaseDelay {
+			problems = append(problems, "webhooks.retryBaseDelay must be positive and not greater than webhooks.retryMaxDelay")
+		}
+	}
+
+	problems = append(problems, c.Consumer.validate()...)
+
+	if c.Cache.Enabled && (c.Cache.Capacity < 1 || c.Cache.TTL <= 0 || c.Cache.MaxAge < 0) {
+		problems = append(problems, "cache.capacity and cache.ttl must be positive, cache.maxAge must not be negative")
+	}
+	if c.Idempotency.Enabled && (c.Idempotency.TTL <= 0 || c.Idempotency.MaxEntries < 1 || c.Idempotency.MaxBodySize < 1) {
+		problems = append(problems, "idempotency.ttl, idempotency.maxEntries and idempotency.maxBodySize must be positive")
+	}
+	if c.Snapshot.Enabled && (c.Snapshot.File == "" || c.Snapshot.Interval < 0 || c.Snapshot.MaxUploadSize < 1) {
+		problems = append(problems, "snapshot.file must not be empty, snapshot.interval must not be negative, snapshot.maxUploadSize must be positive")
+	}
+
+	for class, limit := range c.RateLimit.Limits {
+		if limit.RequestsPerSecond <= 0 || limit.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("rateLimit.limits.%s needs a positive requestsPerSecond and a burst of at least 1", class))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf(ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (c *ConsumerConfig) validate() []string {
+	if !c.Enabled {
+		return nil
+	}
+
+	problems := []string{}
+	switch c.Broker {
+	case BrokerNATS:
+		if c.NATS.URL == "" || c.NATS.QueueGroup == "" {
+			problems = append(problems, "consumer.nats.url and consumer.nats.queueGroup must not be empty")
+		}
+	case BrokerKafka:
+		if len(c.Kafka.Brokers) == 0 || c.Kafka.GroupID == "" {
+			problems = append(problems, "consumer.kafka.brokers and consumer.kafka.groupID must not be empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("consumer.broker must be one of nats, kafka, got '%s'", c.Broker))
+	}
+	if c.Topic == "" || c.DeadLetterTopic == "" || c.Topic == c.DeadLetterTopic {
+		problems = append(problems, "consumer.topic and consumer.deadLetterTopic must be set and differ")
+	}
+	if c.MaxAttempts < 1 {
+		problems = append(problems, fmt.Sprintf("consumer.maxAttempts must be positive, got %d", c.MaxAttempts))
+	}
+	if c.RetryBaseDelay <= 0 || c.RetryMaxDelay < c.RetryBaseDelay {
+		problems = append(problems, "consumer.retryBaseDelay must be positive and not greater than consumer.retryMaxDelay")
+	}
+	return problems
+}
+
+func (c *JWTConfig) validate() []string {
+	if !c.Enabled {
+		return nil
+	}
+
+	problems := []string{}
+	switch c.Algorithm {
+	case "HS256":
+		if c.Secret == "" {
+			problems = append(problems, "auth.jwt.secret is required for HS256")
+		}
+	case "RS256":
+		if c.PublicKeyFile == "" && c.JWKSFile == "" {
+			problems = append(problems, "auth.jwt.publicKeyFile or auth.jwt.jwksFile is required for RS256")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("auth.jwt.algorithm must be HS256 or RS256, got '%s'", c.Algorithm))
+	}
+	if c.JWKSFile != "" && c.JWKSReloadInterval <= 0 {
+		problems = append(problems, fmt.Sprintf("auth.jwt.jwksReloadInterval must be positive, got %s", c.JWKSReloadInterval))
+	}
+	if c.Leeway < 0 {
+		problems = append(problems, fmt.Sprintf("auth.jwt.leeway must not be negative, got %s", c.Leeway))
+	}
+
+	return problems
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validAddressRange reports whether the value is an IP address or a CIDR range.
+func validAddressRange(value string) bool {
+	if net.ParseIP(value) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(value)
+	return err == nil
+}
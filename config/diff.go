@@ -0,0 +1,92 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Change describes a single setting that differs between two configurations.
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s from '%s' to '%s'", c.Key, c.Old, c.New)
+}
+
+// Diff returns all settings that differ between old and new, keyed by their YAML path, e.g. "log.level".
+func Diff(old, new *Config) []Change {
+	a := map[string]string{}
+	b := map[string]string{}
+	flatten("", reflect.ValueOf(*old), a)
+	flatten("", reflect.ValueOf(*new), b)
+
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	changes := []Change{}
+	for k := range keys {
+		if a[k] != b[k] {
+			changes = append(changes, Change{Key: k, Old: a[k], New: b[k]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+
+	return changes
+}
+
+// flatten writes the string representation of all leaf values of v into out.
+// Values of fields tagged with secret:"true" are masked.
+func flatten(prefix string, v reflect.Value, out map[string]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if tag == "-" || field.PkgPath != "" {
+				continue
+			}
+			if tag == "" {
+				tag = strings.ToLower(field.Name)
+			}
+			if field.Tag.Get("secret") == "true" {
+				out[join(prefix, tag)] = mask(v.Field(i).String())
+				continue
+			}
+			flatten(join(prefix, tag), v.Field(i), out)
+		}
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			flatten(join(prefix, fmt.Sprint(k.Interface())), v.MapIndex(k), out)
+		}
+	default:
+		out[prefix] = fmt.Sprint(v.Interface())
+	}
+}
+
+// mask hides a secret but keeps changes of the secret visible in a diff.
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+func join(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// EnvConfigFile is the environment variable that holds the path of the configuration file.
+const EnvConfigFile = "CONFIG_FILE"
+
+// errors
+var (
+	ErrReadConfigFile = "failed to read configuration file %s: %v"
+	ErrParseEnv       = "failed to parse environment variable %s: %v"
+)
+
+// envBinding maps an environment variable to a setting.
+type envBinding struct {
+	key   string
+	apply func(c *Config, value string) error
+}
+
+var envBindings = []envBinding{
+	{"ENV", func(c *Config, v string) error { c.Environment = v; return nil }},
+	{"PORT", func(c *Config, v string) (err error) { c.Server.Port, err = strconv.Atoi(v); return }},
+	{"DRAIN_PERIOD", func(c *Config, v string) (err error) { c.Server.DrainPeriod, err = time.ParseDuration(v); return }},
+	{"SHUTDOWN_TIMEOUT", func(c *Config, v string) (err error) { c.Server.ShutdownTimeout, err = time.ParseDuration(v); return }},
+	{"CONFIG_WATCH_INTERVAL", func(c *Config, v string) (err error) { c.WatchInterval, err = time.ParseDuration(v); return }},
+	{"LOG_LEVEL", func(c *Config, v string) error { c.Log.Level = v; return nil }},
+	{"HEALTH_CHECK_TIMEOUT", func(c *Config, v string) (err error) { c.Health.CheckTimeout, err = time.ParseDuration(v); return }},
+	{"HEALTH_CACHE_TTL", func(c *Config, v string) (err error) { c.Health.CacheTTL, err = time.ParseDuration(v); return }},
+	{"HEALTH_DISK_PATH", func(c *Config, v string) error { c.Health.DiskPath = v; return nil }},
+	{"PIZZA_MAX_INGREDIENTS", func(c *Config, v string) (err error) { c.Pizza.MaxIngredients, err = strconv.Atoi(v); return }},
+	{"PIZZA_REPOSITORY", func(c *Config, v string) error { c.Pizza.Repository = v; return nil }},
+	{"PIZZA_SHARDS", func(c *Config, v string) (err error) { c.Pizza.Shards, err = strconv.Atoi(v); return }},
+	{"JWT_ENABLED", func(c *Config, v string) (err error) { c.Auth.JWT.Enabled, err = strconv.ParseBool(v); return }},
+	{"JWT_ALGORITHM", func(c *Config, v string) error { c.Auth.JWT.Algorithm = v; return nil }},
+	{"JWT_SECRET", func(c *Config, v string) error { c.Auth.JWT.Secret = v; return nil }},
+	{"JWT_PUBLIC_KEY_FILE", func(c *Config, v string) error { c.Auth.JWT.PublicKeyFile = v; return nil }},
+	{"JWT_JWKS_FILE", func(c *Config, v string) error { c.Auth.JWT.JWKSFile = v; return nil }},
+	{"JWT_ISSUER", func(c *Config, v string) error { c.Auth.JWT.Issuer = v; return nil }},
+	{"JWT_AUDIENCE", func(c *Config, v string) error { c.Auth.JWT.Audience = v; return nil }},
+	{"API_KEYS_ENABLED", func(c *Config, v string) (err error) { c.Auth.APIKeys.Enabled, err = strconv.ParseBool(v); return }},
+	{"API_KEYS_HEADER", func(c *Config, v string) error { c.Auth.APIKeys.Header = v; return nil }},
+	{"API_KEYS_FILE", func(c *Config, v string) error { c.Auth.APIKeys.File = v; return nil }},
+	{"API_KEYS_ADMIN_KEY", func(c *Config, v string) error { c.Auth.APIKeys.AdminKey = v; return nil }},
+	{"MTLS_ENABLED", func(c *Config, v string) (err error) { c.Auth.MTLS.Enabled, err = strconv.ParseBool(v); return }},
+	{"MTLS_CLIENT_CA_FILE", func(c *Config, v string) error { c.Auth.MTLS.ClientCAFile = v; return nil }},
+	{"MTLS_REQUIRED", func(c *Config, v string) (err error) { c.Auth.MTLS.Required, err = strconv.ParseBool(v); return }},
+	{"RATE_LIMIT_ENABLED", func(c *Config, v string) (err error) { c.RateLimit.Enabled, err = strconv.ParseBool(v); return }},
+	{"SERVER_TRUSTED_PROXIES", func(c *Config, v string) error { c.Server.TrustedProxies = splitList(v); return nil }},
+	{"TLS_CERT_FILE", func(c *Config, v string) error { c.Server.TLS.CertFile = v; return nil }},
+	{"TLS_KEY_FILE", func(c *Config, v string) error { c.Server.TLS.KeyFile = v; return nil }},
+	{"WEBHOOKS_ENABLED", func(c *Config, v string) (err error) { c.Webhooks.Enabled, err = strconv.ParseBool(v); return }},
+	{"CACHE_ENABLED", func(c *Config, v string) (err error) { c.Cache.Enabled, err = strconv.ParseBool(v); return }},
+	{"CACHE_TTL", func(c *Config, v string) (err error) { c.Cache.TTL, err = time.ParseDuration(v); return }},
+	{"SNAPSHOT_ENABLED", func(c *Config, v string) (err error) { c.Snapshot.Enabled, err = strconv.ParseBool(v); return }},
+	{"SNAPSHOT_FILE", func(c *Config, v string) error { c.Snapshot.File = v; return nil }},
+	{"SNAPSHOT_INTERVAL", func(c *Config, v string) (err error) { c.Snapshot.Interval, err = time.ParseDuration(v); return }},
+	{"IDEMPOTENCY_ENABLED", func(c *Config, v string) (err error) { c.Idempotency.Enabled, err = strconv.ParseBool(v); return }},
+	{"IDEMPOTENCY_TTL", func(c *Config, v string) (err error) { c.Idempotency.TTL, err = time.ParseDuration(v); return }},
+	{"CONSUMER_ENABLED", func(c *Config, v string) (err error) { c.Consumer.Enabled, err = strconv.ParseBool(v); return }},
+	{"CONSUMER_BROKER", func(c *Config, v string) error { c.Consumer.Broker = v; return nil }},
+	{"NATS_URL", func(c *Config, v string) error { c.Consumer.NATS.URL = v; return nil }},
+	{"KAFKA_BROKERS", func(c *Config, v string) error { c.Consumer.Kafka.Brokers = splitList(v); return nil }},
+	{"AUDIT_SINK", func(c *Config, v string) error { c.Audit.Sink = v; return nil }},
+	{"AUDIT_FILE", func(c *Config, v string) error { c.Audit.File = v; return nil }},
+	{"CORS_ALLOW_ORIGINS", func(c *Config, v string) error { c.CORS.AllowOrigins = splitList(v); return nil }},
+}
+
+// Load builds the configuration from, in increasing order of precedence, the defaults,
+// a YAML or TOML configuration file, environment variables and command line flags.
+// The configuration file is given by flag -config or environment variable CONFIG_FILE.
+func Load(args []string) (*Config, error) {
+	flags := flag.NewFlagSet("pizza-service", flag.ContinueOnError)
+	file := flags.String("config", os.Getenv(EnvConfigFile), "path of the YAML or TOML configuration file")
+	env := flags.String("env", "", "environment, one of production, staging, develop, local")
+	port := flags.Int("port", 0, "port of the HTTP server")
+	drainPeriod := flags.Duration("drain-period", 0, "time readiness fails before the listener is closed on shutdown")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 0, "maximum time to wait for in-flight requests on shutdown")
+	logLevel := flags.String("log-level", "", "minimum log level, one of debug, info, warn, error")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	c, err := LoadFile(*file)
+	if err != nil {
+		return nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			c.Environment = *env
+		case "port":
+			c.Server.Port = *port
+		case "drain-period":
+			c.Server.DrainPeriod = *drainPeriod
+		case "shutdown-timeout":
+			c.Server.ShutdownTimeout = *shutdownTimeout
+		case "log-level":
+			c.Log.Level = *logLevel
+		}
+	})
+
+	c.complete()
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFile builds the configuration from the defaults, the given file and environment variables.
+// Files with the extension .toml are read as TOML, all others as YAML. An empty path skips the file.
+// The result is not validated.
+func LoadFile(path string) (*Config, error) {
+	c := Default()
+	c.File = path
+
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf(ErrReadConfigFile, path, err)
+		}
+		if strings.EqualFold(filepath.Ext(path), ".toml") {
+			if data, err = tomlToYAML(data); err != nil {
+				return nil, fmt.Errorf(ErrReadConfigFile, path, err)
+			}
+		}
+		if err := yaml.UnmarshalStrict(data, c); err != nil {
+			return nil, fmt.Errorf(ErrReadConfigFile, path, err)
+		}
+	}
+
+	for _, binding := range envBindings {
+		if value, exists := os.LookupEnv(binding.key); exists {
+			if err := binding.apply(c, value); err != nil {
+				return nil, fmt.Errorf(ErrParseEnv, binding.key, err)
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// tomlToYAML converts a TOML document to YAML, so both formats share the keys, the duration format
+// and the rejection of unknown keys.
+func tomlToYAML(data []byte) ([]byte, error) {
+	document := map[string]interface{}{}
+	if _, err := toml.Decode(string(data), &document); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(document)
+}
+
+// splitList splits a comma separated list and removes blank entries.
+func splitList(value string) []string {
+	list := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadFileTOML(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+environment = "develop"
+
+[server]
+port = 9000
+drainPeriod = "5s"
+
+[pizza]
+maxIngredients = 8
+repository = "sharded"
+
+[cors]
+allowOrigins = ["https://menu.example.com"]
+
+[rateLimit.limits.read]
+requestsPerSecond = 2.5
+burst = 10
+`)
+
+	c, err := LoadFile(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "develop", c.Environment)
+	assert.Equal(t, 9000, c.Server.Port)
+	assert.Equal(t, 5*time.Second, c.Server.DrainPeriod)
+	assert.Equal(t, 8, c.Pizza.MaxIngredients)
+	assert.Equal(t, RepositorySharded, c.Pizza.Repository)
+	assert.Equal(t, []string{"https://menu.example.com"}, c.CORS.AllowOrigins)
+	assert.Equal(t, 2.5, c.RateLimit.Limits["read"].RequestsPerSecond)
+	assert.Equal(t, 10, c.RateLimit.Limits["read"].Burst)
+	// settings that are not in the file keep their defaults
+	assert.Equal(t, Default().Cache, c.Cache)
+}
+
+func TestLoadFileTOMLRejectsUnknownKeys(t *testing.T) {
+	path := writeFile(t, "config.toml", "[server]\nprot = 9000\n")
+
+	_, err := LoadFile(path)
+
+	assert.Error(t, err)
+}
+
+func TestLoadFileTOMLMatchesYAML(t *testing.T) {
+	yamlPath := writeFile(t, "config.yaml", "server:\n  port: 9000\n  shutdownTimeout: 1m\nlog:\n  level: debug\n")
+	tomlPath := writeFile(t, "config.toml", "[server]\nport = 9000\nshutdownTimeout = \"1m\"\n[log]\nlevel = \"debug\"\n")
+
+	fromYAML, err := LoadFile(yamlPath)
+	require.NoError(t, err)
+	fromTOML, err := LoadFile(tomlPath)
+	require.NoError(t, err)
+
+	fromYAML.File, fromTOML.File = "", ""
+	assert.Equal(t, fromYAML, fromTOML)
+}
+
+func TestLoadFileExample(t *testing.T) {
+	c, err := LoadFile("../config.example.yaml")
+
+	require.NoError(t, err)
+	c.complete()
+	assert.NoError(t, c.Validate())
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	c := Default()
+	c.complete()
+	c.Server.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "2001:db8::/32"}
+	assert.NoError(t, c.Validate())
+
+	c.Server.TrustedProxies = []string{"proxy.example.com"}
+	assert.Error(t, c.Validate())
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	. "golang-microservice-template/utils"
+)
+
+// Listener is called with the previous and the new configuration after a successful reload.
+type Listener func(old, new *Config)
+
+// Manager holds the current configuration and replaces it on reload.
+type Manager interface {
+	// Current returns the active configuration. The returned value must not be modified.
+	Current() *Config
+	// Reload reads the configuration again and applies all reloadable settings.
+	// An invalid configuration is rejected and the previous one is kept.
+	Reload() error
+	// OnChange registers a listener that is notified after each reload that changed a setting.
+	OnChange(Listener)
+	// Watch reloads the configuration whenever the configuration file changes until stop is closed.
+	Watch(interval time.Duration, stop <-chan struct{})
+}
+
+type manager struct {
+	current   *Config
+	args      []string
+	listeners []Listener
+	modTime   time.Time
+	sync.RWMutex
+}
+
+// NewManager creates a new Manager for a configuration that was loaded from the given command line arguments.
+func NewManager(cfg *Config, args []string) Manager {
+	return &manager{
+		current:   cfg,
+		args:      args,
+		listeners: []Listener{},
+		modTime:   fileModTime(cfg.File),
+	}
+}
+
+func (m *manager) Current() *Config {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.current
+}
+
+func (m *manager) OnChange(l Listener) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.listeners = append(m.listeners, l)
+}
+
+func (m *manager) Reload() error {
+	loaded, err := Load(m.args)
+	if err != nil {
+		Log.Errorf("configuration reload rejected, keeping previous configuration: %v", err)
+		return err
+	}
+
+	m.Lock()
+	old := m.current
+	next := old.withReloadable(loaded)
+	m.current = next
+	listeners := m.listeners
+	m.Unlock()
+
+	for _, change := range Diff(next, loaded) {
+		Log.Warnf("configuration reload ignores %s, a restart is required", change)
+	}
+
+	changes := Diff(old, next)
+	if len(changes) == 0 {
+		Log.Info("configuration reloaded without changes")
+		return nil
+	}
+
+	for _, change := range changes {
+		Log.Infof("configuration changed %s", change)
+	}
+
+	for _, l := range listeners {
+		l(old, next)
+	}
+
+	return nil
+}
+
+func (m *manager) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			modTime := fileModTime(m.Current().File)
+			if modTime.Equal(m.modTime) {
+				continue
+			}
+			m.modTime = modTime
+			Log.Infof("configuration file %s changed", m.Current().File)
+			_ = m.Reload()
+		}
+	}
+}
+
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,62 @@
+package consumer
+
+import (
+	"encoding/json"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+)
+
+// Command types
+const (
+	CommandCreate = "create"
+	CommandUpdate = "update"
+	CommandDelete = "delete"
+)
+
+// errors
+var (
+	ErrUnknownCommand = "unknown command type '%s'"
+	ErrMissingName    = "command %s needs a pizza name"
+	ErrMissingPizza   = "command %s needs a pizza"
+)
+
+// Command is a message of the commands topic, e.g.
+//
+//	{"type": "update", "name": "margherita", "pizza": {"ingredients": [{"name": "basil", "count": 2}]}}
+type Command struct {
+	Type string `json:"type"`
+	// Name is the pizza of the update and delete commands.
+	Name string `json:"name,omitempty"`
+	// Pizza is the pizza of the create and update commands.
+	Pizza *pizza.PizzaDto `json:"pizza,omitempty"`
+}
+
+// decodeCommand decodes and checks a command. Errors are not temporary, the command is dead-lettered.
+func decodeCommand(data []byte) (*Command, error) {
+	command := &Command{}
+	if err := json.Unmarshal(data, command); err != nil {
+		return nil, Error(err, ErrorTypeBinding)
+	}
+
+	switch command.Type {
+	case CommandCreate:
+		if command.Pizza == nil {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingPizza, command.Type)
+		}
+	case CommandUpdate:
+		if command.Name == "" {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingName, command.Type)
+		}
+		if command.Pizza == nil {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingPizza, command.Type)
+		}
+	case CommandDelete:
+		if command.Name == "" {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingName, command.Type)
+		}
+	default:
+		return nil, Errorf(ErrorTypeBadRequest, ErrUnknownCommand, command.Type)
+	}
+
+	return command, nil
+}
@@ -0,0 +1,165 @@
+package consumer
+
+import (
+	"context"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/broker"
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ActorConsumer is the audit actor of the changes made by consumed commands.
+const ActorConsumer = "consumer"
+
+// Headers added to dead-lettered messages
+const (
+	HeaderDeadLetterReason    = "X-Dead-Letter-Reason"
+	HeaderDeadLetterStatus    = "X-Dead-Letter-Status"
+	HeaderDeadLetterTopic     = "X-Dead-Letter-Topic"
+	HeaderDeadLetterMessageID = "X-Dead-Letter-Message-ID"
+	HeaderDeadLetterAttempts  = "X-Dead-Letter-Attempts"
+)
+
+// Consumer executes the pizza commands of a topic with the pizza service, like the REST API does.
+// Commands that are invalid, or still fail after all retries, are published to the dead letter topic.
+type Consumer interface {
+	// Start subscribes to the commands topic.
+	Start() error
+	// Stop closes the subscription and waits for the command in progress.
+	Stop(ctx context.Context) error
+}
+
+type consumer struct {
+	broker       broker.Broker
+	service      pizza.Service
+	log          LogWriter
+	config       config.ConsumerConfig
+	subscription broker.Subscription
+}
+
+// NewConsumer creates a new consumer of the commands topic of the configuration.
+func NewConsumer(b broker.Broker, service pizza.Service, log LogWriter, cfg config.ConsumerConfig) Consumer {
+	return &consumer{
+		broker:  b,
+		service: service,
+		log:     log,
+		config:  cfg,
+	}
+}
+
+func (c *consumer) Start() error {
+	subscription, err := c.broker.Subscribe(c.config.Topic, c.handle)
+	if err != nil {
+		return err
+	}
+
+	c.subscription = subscription
+	return nil
+}
+
+func (c *consumer) Stop(ctx context.Context) error {
+	if c.subscription == nil {
+		return nil
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- c.subscription.Close()
+	}()
+
+	select {
+	case err := <-closed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// handle executes a command. Temporary errors are retried with exponential backoff, the retries are
+// canceled with the subscription and the message is redelivered if the broker supports it.
+// An error is returned only if the command cannot be dead-lettered.
+func (c *consumer) handle(ctx context.Context, message *broker.Message) error {
+	command, err := decodeCommand(message.Data)
+	if err != nil {
+		return c.deadLetter(ctx, message, err, 1)
+	}
+
+	origin := audit.Origin{Actor: ActorConsumer, RequestID: message.ID}
+	for attempt := 1; ; attempt++ {
+		err := c.execute(origin, command)
+		if err == nil {
+			return nil
+		}
+
+		if !temporary(err) || attempt >= c.config.MaxAttempts {
+			return c.deadLetter(ctx, message, err, attempt)
+		}
+
+		delay := Backoff(attempt, c.config.RetryBaseDelay, c.config.RetryMaxDelay)
+		c.log.Warnf("command %s of message %s failed, retrying in %s: %v", command.Type, message.ID, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *consumer) execute(origin audit.Origin, command *Command) error {
+	switch command.Type {
+	case CommandCreate:
+		_, err := c.service.Create(origin, command.Pizza)
+		return err
+	case CommandUpdate:
+		_, err := c.service.Update(origin, command.Name, command.Pizza)
+		return err
+	default:
+		return c.service.Delete(origin, command.Name)
+	}
+}
+
+// deadLetter publishes a failed message to the dead letter topic with the reason of the failure.
+func (c *consumer) deadLetter(ctx context.Context, message *broker.Message, cause error, attempts int) error {
+	c.log.Warnf("dead-lettering message %s of topic %s after %d attempts: %v", message.ID, message.Topic, attempts, cause)
+
+	headers := map[string]string{}
+	for key, value := range message.Headers {
+		headers[key] = value
+	}
+	headers[HeaderDeadLetterReason] = cause.Error()
+	headers[HeaderDeadLetterStatus] = strconv.Itoa(status(cause))
+	headers[HeaderDeadLetterTopic] = message.Topic
+	headers[HeaderDeadLetterMessageID] = message.ID
+	headers[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+
+	err := c.broker.Publish(ctx, c.config.DeadLetterTopic, &broker.Message{
+		Key:     message.Key,
+		Data:    message.Data,
+		Headers: headers,
+	})
+	if err != nil {
+		c.log.Errorf("dead-lettering message %s failed: %v", message.ID, err)
+	}
+	return err
+}
+
+// status returns the HTTP status the REST API would answer the error with.
+func status(err error) int {
+	if e, ok := err.(HasHTTPStatus); ok {
+		return e.GetHTTPStatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// temporary returns true if executing the command again may succeed.
+func temporary(err error) bool {
+	code := status(err)
+	return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+}
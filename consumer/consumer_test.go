@@ -0,0 +1,200 @@
+package consumer
+
+import (
+	"context"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/broker"
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const deleteMargherita = `{"type": "delete", "name": "margherita"}`
+
+func newTestConfig() config.ConsumerConfig {
+	cfg := config.Default().Consumer
+	cfg.RetryBaseDelay = 20 * time.Millisecond
+	cfg.RetryMaxDelay = time.Second
+	return cfg
+}
+
+// start starts a consumer of the memory broker and stops it when the test ends.
+func start(t *testing.T, service pizza.Service, cfg config.ConsumerConfig) (broker.MemoryBroker, Consumer) {
+	b := broker.NewMemoryBroker()
+	c := NewConsumer(b, service, Log, cfg)
+	require.NoError(t, c.Start())
+	t.Cleanup(func() { _ = c.Stop(context.Background()) })
+	return b, c
+}
+
+func publish(b broker.Broker, cfg config.ConsumerConfig, data string) error {
+	return b.Publish(context.Background(), cfg.Topic, &broker.Message{Key: []byte("margherita"), Data: []byte(data)})
+}
+
+// fromConsumer matches the audit origin of the consumed message with the given ID.
+func fromConsumer(messageID string) interface{} {
+	return mock.MatchedBy(func(origin audit.Origin) bool {
+		return origin.Actor == ActorConsumer && origin.RequestID == messageID
+	})
+}
+
+func TestCommandsAreExecuted(t *testing.T) {
+	cfg := newTestConfig()
+	service := &pizza.MockService{}
+	service.On("Create", fromConsumer("1"), &pizza.PizzaDto{Name: "margherita"}).Return(&pizza.PizzaDto{Name: "margherita"}, nil).Once()
+	service.On("Update", fromConsumer("2"), "margherita", &pizza.PizzaDto{Name: "margherita"}).Return(&pizza.PizzaDto{Name: "margherita"}, nil).Once()
+	service.On("Delete", fromConsumer("3"), "margherita").Return(nil).Once()
+	b, _ := start(t, service, cfg)
+
+	require.NoError(t, publish(b, cfg, `{"type": "create", "pizza": {"name": "margherita"}}`))
+	require.NoError(t, publish(b, cfg, `{"type": "update", "name": "margherita", "pizza": {"name": "margherita"}}`))
+	require.NoError(t, publish(b, cfg, deleteMargherita))
+
+	service.AssertExpectations(t)
+	assert.Empty(t, b.Published(cfg.DeadLetterTopic))
+}
+
+func TestInvalidCommandsAreDeadLettered(t *testing.T) {
+	tests := map[string]string{
+		"malformed json":       `{"type":`,
+		"unknown type":         `{"type": "bake", "name": "margherita"}`,
+		"create without pizza": `{"type": "create"}`,
+		"update without name":  `{"type": "update", "pizza": {"name": "margherita"}}`,
+		"delete without name":  `{"type": "delete"}`,
+	}
+
+	for name, data := range tests {
+		data := data
+		t.Run(name, func(t *testing.T) {
+			cfg := newTestConfig()
+			service := &pizza.MockService{}
+			b, _ := start(t, service, cfg)
+
+			require.NoError(t, publish(b, cfg, data))
+
+			deadLetters := b.Published(cfg.DeadLetterTopic)
+			require.Len(t, deadLetters, 1)
+			deadLetter := deadLetters[0]
+			assert.Equal(t, data, string(deadLetter.Data))
+			assert.Equal(t, "margherita", string(deadLetter.Key))
+			assert.Equal(t, strconv.Itoa(http.StatusBadRequest), deadLetter.Headers[HeaderDeadLetterStatus])
+			assert.Equal(t, "1", deadLetter.Headers[HeaderDeadLetterAttempts])
+			assert.Equal(t, cfg.Topic, deadLetter.Headers[HeaderDeadLetterTopic])
+			assert.Equal(t, "1", deadLetter.Headers[HeaderDeadLetterMessageID])
+			assert.NotEmpty(t, deadLetter.Headers[HeaderDeadLetterReason])
+			assert.Empty(t, service.Calls, "invalid commands are not executed")
+		})
+	}
+}
+
+func TestTemporaryErrorsAreRetriedWithBackoff(t *testing.T) {
+	cfg := newTestConfig()
+	var attempts []time.Time
+	service := &pizza.MockService{}
+	record := func(mock.Arguments) { attempts = append(attempts, time.Now()) }
+	service.On("Delete", fromConsumer("1"), "margherita").Return(Error("database unavailable", ErrorTypeServiceUnavailable)).Twice().Run(record)
+	service.On("Delete", fromConsumer("1"), "margherita").Return(nil).Once().Run(record)
+	b, _ := start(t, service, cfg)
+
+	require.NoError(t, publish(b, cfg, deleteMargherita))
+
+	service.AssertExpectations(t)
+	require.Len(t, attempts, 3)
+	assert.True(t, attempts[1].Sub(attempts[0]) >= cfg.RetryBaseDelay, "first retry after the base delay")
+	assert.True(t, attempts[2].Sub(attempts[1]) >= 2*cfg.RetryBaseDelay, "the delay doubles with every attempt")
+	assert.Empty(t, b.Published(cfg.DeadLetterTopic))
+}
+
+func TestFailedCommandsAreDeadLettered(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		attempts int
+	}{
+		"temporary error after all attempts": {Error("database unavailable", ErrorTypeServiceUnavailable), 3},
+		"rate limited after all attempts":    {Error("slow down", ErrorTypeTooManyRequests), 3},
+		"permanent error without retry":      {Error("pizza not found", ErrorTypeResourceNotFound), 1},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			cfg := newTestConfig()
+			cfg.RetryBaseDelay = time.Millisecond
+			service := &pizza.MockService{}
+			service.On("Delete", fromConsumer("1"), "margherita").Return(test.err)
+			b, _ := start(t, service, cfg)
+
+			require.NoError(t, publish(b, cfg, deleteMargherita))
+
+			service.AssertNumberOfCalls(t, "Delete", test.attempts)
+			deadLetters := b.Published(cfg.DeadLetterTopic)
+			require.Len(t, deadLetters, 1)
+			headers := deadLetters[0].Headers
+			assert.Equal(t, strconv.Itoa(test.err.(HasHTTPStatus).GetHTTPStatusCode()), headers[HeaderDeadLetterStatus])
+			assert.Equal(t, strconv.Itoa(test.attempts), headers[HeaderDeadLetterAttempts])
+			assert.Equal(t, test.err.Error(), headers[HeaderDeadLetterReason])
+		})
+	}
+}
+
+func TestStopWaitsForCommandInProgress(t *testing.T) {
+	cfg := newTestConfig()
+	started, release := make(chan struct{}), make(chan struct{})
+	service := &pizza.MockService{}
+	service.On("Delete", fromConsumer("1"), "margherita").Return(nil).Run(func(mock.Arguments) {
+		close(started)
+		<-release
+	})
+	b, c := start(t, service, cfg)
+	published := make(chan error, 1)
+	go func() { published <- publish(b, cfg, deleteMargherita) }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.Stop(ctx), "the command is still in progress")
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- c.Stop(context.Background()) }()
+	select {
+	case <-stopped:
+		t.Fatal("stopped before the command finished")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-stopped)
+	assert.NoError(t, <-published)
+	assert.Empty(t, b.Published(cfg.DeadLetterTopic))
+}
+
+func TestStopCancelsRetries(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.RetryBaseDelay = time.Minute
+	var once sync.Once
+	failed := make(chan struct{})
+	service := &pizza.MockService{}
+	service.On("Delete", fromConsumer("1"), "margherita").Return(Error("database unavailable", ErrorTypeServiceUnavailable)).
+		Run(func(mock.Arguments) { once.Do(func() { close(failed) }) })
+	b, c := start(t, service, cfg)
+	published := make(chan error, 1)
+	go func() { published <- publish(b, cfg, deleteMargherita) }()
+	<-failed
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, c.Stop(ctx))
+
+	assert.Equal(t, context.Canceled, <-published, "the message is left for redelivery")
+	service.AssertNumberOfCalls(t, "Delete", 1)
+	assert.Empty(t, b.Published(cfg.DeadLetterTopic))
+}
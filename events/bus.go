@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// errors
+var (
+	ErrHandlersFailed = "%d of %d handlers failed for event %s: %s"
+)
+
+// Handler processes an event. Handlers may receive an event more than once and must be idempotent.
+type Handler func(ctx context.Context, event *Event) error
+
+// Bus delivers events to the subscribed handlers.
+type Bus interface {
+	// Publish delivers the event to all handlers subscribed to its type.
+	// An error means the event must be published again.
+	Publish(ctx context.Context, event *Event) error
+	// Subscribe registers a handler for the given event types, or for all events if none are given.
+	Subscribe(handler Handler, eventTypes ...string)
+}
+
+type subscription struct {
+	handler    Handler
+	eventTypes []string
+}
+
+func (s *subscription) matches(eventType string) bool {
+	if len(s.eventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.eventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type inProcessBus struct {
+	subscriptions []*subscription
+	sync.RWMutex
+}
+
+// NewInProcessBus creates a bus that calls the handlers synchronously in the publishing goroutine.
+func NewInProcessBus() Bus {
+	return &inProcessBus{subscriptions: []*subscription{}}
+}
+
+func (b *inProcessBus) Publish(ctx context.Context, event *Event) error {
+	b.RLock()
+	subscriptions := b.subscriptions
+	b.RUnlock()
+
+	total := 0
+	failures := []string{}
+	for _, s := range subscriptions {
+		if !s.matches(event.Type) {
+			continue
+		}
+		total++
+		if err := s.handler(ctx, event); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf(ErrHandlersFailed, len(failures), total, event.ID, strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (b *inProcessBus) Subscribe(handler Handler, eventTypes ...string) {
+	b.Lock()
+	defer b.Unlock()
+
+	subscriptions := append([]*subscription{}, b.subscriptions...)
+	b.subscriptions = append(subscriptions, &subscription{handler: handler, eventTypes: eventTypes})
+}
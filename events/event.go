@@ -0,0 +1,55 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const idSize = 16
+
+// Event is a domain event that tells other components about a change.
+// Events are delivered at least once, consumers deduplicate them by ID.
+type Event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Subject identifies the changed entity, e.g. the pizza name.
+	Subject string `json:"subject"`
+	// Revision is the revision of the subject the event was created for.
+	Revision   int             `json:"revision"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Data       json.RawMessage `json:"data,omitempty"`
+}
+
+// NewEvent creates an event with a new ID. The data is encoded as JSON, nil is omitted.
+func NewEvent(eventType, subject string, revision int, occurredAt time.Time, data interface{}) (*Event, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	event := &Event{
+		ID:         id,
+		Type:       eventType,
+		Subject:    subject,
+		Revision:   revision,
+		OccurredAt: occurredAt.UTC(),
+	}
+	if data != nil {
+		if event.Data, err = json.Marshal(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return event, nil
+}
+
+// generateID returns a new random event id.
+func generateID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
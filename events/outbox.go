@@ -0,0 +1,182 @@
+package events
+
+import (
+	"fmt"
+	. "golang-microservice-template/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultOutboxCapacity is the maximum number of pending events of the in-memory outboxes of the repositories.
+const DefaultOutboxCapacity = 10000
+
+// errors
+var (
+	ErrEventNotInOutbox = "event %s not in outbox"
+	ErrOutboxFull       = "the outbox is full with %d pending events, retry later"
+)
+
+// Entry is an event in the outbox together with its delivery state.
+type Entry struct {
+	Event         *Event
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+}
+
+// Outbox keeps events until they are published. Storages add the events of a change in the same
+// transaction as the change, so a change is never stored without its event. Delivery is at least once only
+// as long as the outbox lives: the in-memory outbox loses the events that are still pending when the process
+// exits, e.g. after a crash or a flush that ran out of time. An outbox that must survive restarts has to be
+// persisted together with the changes.
+type Outbox interface {
+	// Add stores a new event that is due immediately. It fails with ErrorTypeServiceUnavailable if the outbox is full.
+	Add(event *Event) error
+	// Pending returns up to limit entries that are due at now, oldest first. An entry is only returned
+	// if no older event of the same subject is pending, so the events of a subject are published in order.
+	Pending(now time.Time, limit int) ([]*Entry, error)
+	// MarkPublished removes a published event.
+	MarkPublished(id string) error
+	// MarkFailed records a failed delivery attempt and schedules the next one.
+	MarkFailed(id string, next time.Time, cause error) error
+	// MarkDead moves an event that cannot be published out of the pending events, so the later events
+	// of its subject are published.
+	MarkDead(id string, cause error) error
+	// DeadLetters returns the events that were given up, oldest first.
+	DeadLetters() ([]*Entry, error)
+}
+
+type memoryOutbox struct {
+	entries     map[string]*Entry
+	order       []string
+	deadLetters []*Entry
+	capacity    int
+	sync.Mutex
+}
+
+// NewMemoryOutbox creates an outbox that keeps up to capacity pending events and as many dead letters
+// in memory, the oldest dead letters are dropped. Callers that need transactional writes hold their own lock
+// while adding events.
+func NewMemoryOutbox(capacity int) Outbox {
+	return &memoryOutbox{
+		entries:     make(map[string]*Entry),
+		order:       []string{},
+		deadLetters: []*Entry{},
+		capacity:    capacity,
+	}
+}
+
+func (o *memoryOutbox) Add(event *Event) error {
+	o.Lock()
+	defer o.Unlock()
+
+	if len(o.order) >= o.capacity {
+		return Errorf(ErrorTypeServiceUnavailable, ErrOutboxFull, len(o.order))
+	}
+
+	o.entries[event.ID] = &Entry{Event: event, NextAttemptAt: event.OccurredAt}
+	o.order = append(o.order, event.ID)
+
+	return nil
+}
+
+func (o *memoryOutbox) Pending(now time.Time, limit int) ([]*Entry, error) {
+	o.Lock()
+	defer o.Unlock()
+
+	due := []*Entry{}
+	blocked := map[string]bool{}
+	for _, id := range o.order {
+		entry := o.entries[id]
+		if blocked[entry.Event.Subject] {
+			continue
+		}
+		blocked[entry.Event.Subject] = true
+		if !entry.NextAttemptAt.After(now) {
+			copied := *entry
+			due = append(due, &copied)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].Event.OccurredAt.Before(due[j].Event.OccurredAt) })
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (o *memoryOutbox) MarkPublished(id string) error {
+	o.Lock()
+	defer o.Unlock()
+
+	_, err := o.remove(id)
+	return err
+}
+
+func (o *memoryOutbox) MarkFailed(id string, next time.Time, cause error) error {
+	o.Lock()
+	defer o.Unlock()
+
+	entry, ok := o.entries[id]
+	if !ok {
+		return fmt.Errorf(ErrEventNotInOutbox, id)
+	}
+	entry.Attempts++
+	entry.NextAttemptAt = next
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	return nil
+}
+
+func (o *memoryOutbox) MarkDead(id string, cause error) error {
+	o.Lock()
+	defer o.Unlock()
+
+	entry, err := o.remove(id)
+	if err != nil {
+		return err
+	}
+	entry.Attempts++
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	o.deadLetters = append(o.deadLetters, entry)
+	if len(o.deadLetters) > o.capacity {
+		o.deadLetters = o.deadLetters[len(o.deadLetters)-o.capacity:]
+	}
+
+	return nil
+}
+
+func (o *memoryOutbox) DeadLetters() ([]*Entry, error) {
+	o.Lock()
+	defer o.Unlock()
+
+	list := make([]*Entry, len(o.deadLetters))
+	for i, entry := range o.deadLetters {
+		copied := *entry
+		list[i] = &copied
+	}
+	return list, nil
+}
+
+// remove deletes a pending entry and returns it. The caller must hold the lock.
+func (o *memoryOutbox) remove(id string) (*Entry, error) {
+	entry, ok := o.entries[id]
+	if !ok {
+		return nil, fmt.Errorf(ErrEventNotInOutbox, id)
+	}
+	delete(o.entries, id)
+	for i, v := range o.order {
+		if v == id {
+			o.order = append(o.order[:i], o.order[i+1:]...)
+			break
+		}
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"errors"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingReturnsOnlyOldestEventOfSubject(t *testing.T) {
+	now := time.Now()
+	outbox := NewMemoryOutbox(10)
+	first := newTestEvent(t, "margherita", 1, now)
+	second := newTestEvent(t, "margherita", 2, now)
+	other := newTestEvent(t, "funghi", 1, now)
+	for _, event := range []*Event{first, second, other} {
+		require.NoError(t, outbox.Add(event))
+	}
+
+	// the first event waits for its retry, the second must not overtake it
+	require.NoError(t, outbox.MarkFailed(first.ID, now.Add(time.Minute), errors.New("down")))
+	pending, err := outbox.Pending(now, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, other.ID, pending[0].Event.ID)
+
+	require.NoError(t, outbox.MarkDead(first.ID, errors.New("down")))
+	pending, err = outbox.Pending(now, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, second.ID, pending[0].Event.ID)
+
+	dead, err := outbox.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, first.ID, dead[0].Event.ID)
+	assert.Equal(t, 2, dead[0].Attempts)
+	assert.Equal(t, "down", dead[0].LastError)
+}
+
+func TestOutboxIsBounded(t *testing.T) {
+	now := time.Now()
+	outbox := NewMemoryOutbox(2)
+	require.NoError(t, outbox.Add(newTestEvent(t, "a", 1, now)))
+	require.NoError(t, outbox.Add(newTestEvent(t, "b", 1, now)))
+
+	err := outbox.Add(newTestEvent(t, "c", 1, now))
+
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(HasHTTPStatus).GetHTTPStatusCode())
+	pending, _ := outbox.Pending(now, 10)
+	assert.Len(t, pending, 2)
+}
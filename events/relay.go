@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"time"
+)
+
+// Relay publishes the events of an outbox to a bus. Events are removed from the outbox only after
+// they were published, failed events are retried with exponential backoff. Events that failed
+// the configured maximum number of attempts are moved to the dead letters of the outbox.
+// Later events of a subject wait until the earlier ones are published or given up.
+type Relay interface {
+	// Run publishes pending events in the configured poll interval until stop is closed.
+	Run(stop <-chan struct{})
+	// Flush publishes all events that are due now once, e.g. before shutdown.
+	Flush(ctx context.Context) error
+}
+
+type relay struct {
+	outbox Outbox
+	bus    Bus
+	clock  Clock
+	log    LogWriter
+	config config.EventsConfig
+}
+
+// NewRelay creates a Relay that publishes the events of outbox to bus.
+func NewRelay(outbox Outbox, bus Bus, clock Clock, log LogWriter, cfg config.EventsConfig) Relay {
+	return &relay{
+		outbox: outbox,
+		bus:    bus,
+		clock:  clock,
+		log:    log,
+		config: cfg,
+	}
+}
+
+func (r *relay) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := r.Flush(context.Background()); err != nil {
+				r.log.Errorf("failed to relay events: %v", err)
+			}
+		}
+	}
+}
+
+func (r *relay) Flush(ctx context.Context) error {
+	for {
+		entries, err := r.outbox.Pending(r.clock.Now(), r.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		removed := 0
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if r.publish(ctx, entry) {
+				removed++
+			}
+		}
+
+		// Continue while the outbox drains, removed events may unblock the next events of their subjects.
+		// Failed events wait for their retry.
+		if removed == 0 {
+			return nil
+		}
+	}
+}
+
+// publish publishes a single event and updates its outbox entry. It returns true if the event was removed
+// from the pending events, because it was published or given up.
+func (r *relay) publish(ctx context.Context, entry *Entry) bool {
+	event := entry.Event
+
+	if err := r.bus.Publish(ctx, event); err != nil {
+		if attempts := entry.Attempts + 1; attempts >= r.config.MaxAttempts {
+			r.log.Errorf("giving up event %s %s of %s after %d attempts: %v", event.ID, event.Type, event.Subject, attempts, err)
+			if err := r.outbox.MarkDead(event.ID, err); err != nil {
+				r.log.Error(err.Error())
+				return false
+			}
+			return true
+		}
+
+		next := r.clock.Now().Add(Backoff(entry.Attempts+1, r.config.RetryBaseDelay, r.config.RetryMaxDelay))
+		r.log.Warnf("failed to publish event %s %s of %s (attempt %d), retrying at %s: %v",
+			event.ID, event.Type, event.Subject, entry.Attempts+1, next.Format(time.RFC3339), err)
+		if err := r.outbox.MarkFailed(event.ID, next, err); err != nil {
+			r.log.Error(err.Error())
+		}
+		return false
+	}
+
+	if err := r.outbox.MarkPublished(event.ID); err != nil {
+		r.log.Error(err.Error())
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+	sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestEvent(t *testing.T, subject string, revision int, at time.Time) *Event {
+	event, err := NewEvent("PizzaUpdated", subject, revision, at, nil)
+	require.NoError(t, err)
+	return event
+}
+
+func testEventsConfig() config.EventsConfig {
+	cfg := config.Default().Events
+	cfg.RetryBaseDelay = time.Second
+	cfg.RetryMaxDelay = time.Second
+	cfg.MaxAttempts = 3
+	return cfg
+}
+
+func TestRelayKeepsOrderOfSubjectAndGivesUpAfterMaxAttempts(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	outbox := NewMemoryOutbox(10)
+	bus := NewInProcessBus()
+	published := []int{}
+	bus.Subscribe(func(_ context.Context, event *Event) error {
+		if event.Revision == 1 {
+			return errors.New("handler failed")
+		}
+		published = append(published, event.Revision)
+		return nil
+	})
+	for revision := 1; revision <= 3; revision++ {
+		require.NoError(t, outbox.Add(newTestEvent(t, "margherita", revision, clock.Now())))
+	}
+	relay := NewRelay(outbox, bus, clock, Log, testEventsConfig())
+
+	for attempt := 1; attempt < testEventsConfig().MaxAttempts; attempt++ {
+		require.NoError(t, relay.Flush(context.Background()))
+		assert.Empty(t, published, "later events must wait for the failing event")
+		clock.Advance(time.Second)
+	}
+
+	require.NoError(t, relay.Flush(context.Background()))
+
+	assert.Equal(t, []int{2, 3}, published)
+	dead, err := outbox.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, 1, dead[0].Event.Revision)
+	assert.Equal(t, testEventsConfig().MaxAttempts, dead[0].Attempts)
+	pending, _ := outbox.Pending(clock.Now(), 10)
+	assert.Empty(t, pending)
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+)
+
+// Checker probes a single dependency of the service, e.g. a repository or the local disk.
+type Checker interface {
+	// Name returns the unique name of the check that is shown in the health report.
+	Name() string
+	// Check returns an error if the probed dependency is not usable.
+	Check(ctx context.Context) error
+}
+
+// CheckFunc is a function that probes a dependency.
+type CheckFunc func(ctx context.Context) error
+
+type checker struct {
+	name  string
+	check CheckFunc
+}
+
+// NewChecker wraps a function as a named Checker.
+func NewChecker(name string, check CheckFunc) Checker {
+	return &checker{
+		name:  name,
+		check: check,
+	}
+}
+
+func (c *checker) Name() string {
+	return c.name
+}
+
+func (c *checker) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
@@ -0,0 +1,26 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// errors
+var (
+	ErrDiskSpaceLow = "free disk space on %s is %d bytes, required are at least %d bytes"
+)
+
+// NewDiskSpaceChecker returns a Checker that fails if the free space of the file system at path drops below minFree bytes.
+func NewDiskSpaceChecker(path string, minFree uint64) Checker {
+	return NewChecker("disk", func(context.Context) error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return err
+		}
+
+		if free < minFree {
+			return fmt.Errorf(ErrDiskSpaceLow, path, free, minFree)
+		}
+		return nil
+	})
+}
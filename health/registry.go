@@ -0,0 +1,203 @@
+package health
+
+import (
+	"context"
+	. "golang-microservice-template/utils"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Keys for the status of a check or a whole report
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusShuttingDown = "shutting down"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs float64   `json:"latencyMs"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Report summarizes the results of all registered checks.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy returns true if the service can serve requests.
+func (r *Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+// Registry holds all checks that determine whether the service is ready to serve requests.
+type Registry interface {
+	// Register adds a check to the registry.
+	Register(Checker)
+	// Check runs all registered checks or returns the cached report of a recent run.
+	Check(ctx context.Context) *Report
+	// SetShuttingDown marks the service as shutting down, all following reports are unhealthy.
+	SetShuttingDown()
+	// IsShuttingDown returns true once SetShuttingDown was called.
+	IsShuttingDown() bool
+}
+
+type registry struct {
+	checks   []Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+	clock    Clock
+	cached   *Report
+	cachedAt time.Time
+	// running is the run of the checks in progress, nil if none.
+	running *run
+	// generation counts the registrations, reports of runs with older checks are not cached.
+	generation   int
+	shuttingDown int32
+	sync.Mutex
+}
+
+// run is a single execution of all checks that concurrent probes wait for and share.
+type run struct {
+	report *Report
+	done   chan struct{}
+}
+
+// NewRegistry creates a new registry. Each check is cancelled after timeout,
+// reports are cached for cacheTTL to avoid probe storms.
+func NewRegistry(timeout, cacheTTL time.Duration, clock Clock) Registry {
+	return &registry{
+		checks:   []Checker{},
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+		clock:    clock,
+	}
+}
+
+func (r *registry) Register(c Checker) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.checks = append(r.checks, c)
+	r.cached = nil
+	r.generation++
+}
+
+// SetShuttingDown does not wait for running checks, readiness fails immediately.
+func (r *registry) SetShuttingDown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+func (r *registry) IsShuttingDown() bool {
+	return atomic.LoadInt32(&r.shuttingDown) == 1
+}
+
+// Check runs the checks without holding the lock. Concurrent probes wait for and share a single run.
+// The run is detached from the context of the probe that started it, every check is bounded by the check
+// timeout only, so a probe that gives up does not fail the report that is cached for the others.
+func (r *registry) Check(ctx context.Context) *Report {
+	if r.IsShuttingDown() {
+		return shuttingDownReport()
+	}
+
+	r.Lock()
+	if r.cached != nil && r.clock.Now().Sub(r.cachedAt) < r.cacheTTL {
+		report := r.cached
+		r.Unlock()
+		return report
+	}
+
+	current := r.running
+	if current == nil {
+		current = &run{done: make(chan struct{})}
+		r.running = current
+		checks := append([]Checker{}, r.checks...)
+		generation := r.generation
+		go r.execute(current, checks, generation)
+	}
+	r.Unlock()
+
+	select {
+	case <-current.done:
+	case <-ctx.Done():
+		return &Report{Status: StatusDown, Checks: []Result{}}
+	}
+
+	if r.IsShuttingDown() {
+		return shuttingDownReport()
+	}
+	return current.report
+}
+
+// execute runs the checks of a shared run and caches its report unless checks were registered meanwhile.
+func (r *registry) execute(current *run, checks []Checker, generation int) {
+	current.report = r.runAll(context.Background(), checks)
+
+	r.Lock()
+	if generation == r.generation {
+		r.cached = current.report
+		r.cachedAt = r.clock.Now()
+	}
+	r.running = nil
+	r.Unlock()
+	close(current.done)
+}
+
+func shuttingDownReport() *Report {
+	return &Report{Status: StatusShuttingDown, Checks: []Result{}}
+}
+
+// runAll runs the checks in parallel.
+func (r *registry) runAll(ctx context.Context, checks []Checker) *Report {
+	report := &Report{
+		Status: StatusUp,
+		Checks: make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Checker) {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func (r *registry) run(ctx context.Context, c Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := r.clock.Now()
+	err := c.Check(ctx)
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:      c.Name(),
+		Status:    StatusUp,
+		LatencyMs: float64(r.clock.Now().Sub(start)) / float64(time.Millisecond),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+	sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSetShuttingDownDoesNotWaitForRunningChecks(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Second, &fakeClock{now: time.Now()})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	registry.Register(NewChecker("slow", func(context.Context) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	reports := make(chan *Report)
+	go func() { reports <- registry.Check(context.Background()) }()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		registry.SetShuttingDown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetShuttingDown waited for the running check")
+	}
+
+	assert.True(t, registry.IsShuttingDown())
+	assert.Equal(t, StatusShuttingDown, registry.Check(context.Background()).Status)
+
+	close(release)
+	assert.Equal(t, StatusShuttingDown, (<-reports).Status)
+}
+
+func TestConcurrentChecksShareOneRun(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	registry := NewRegistry(time.Minute, time.Second, clock)
+	var runs int32
+	release := make(chan struct{})
+	registry.Register(NewChecker("counted", func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, StatusUp, registry.Check(context.Background()).Status)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	// the report is cached until the clock passed the cache TTL
+	registry.Check(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	clock.Advance(2 * time.Second)
+	registry.Check(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestCancelledProbeDoesNotFailOtherProbes(t *testing.T) {
+	registry := NewRegistry(time.Minute, time.Minute, &fakeClock{now: time.Now()})
+	started := make(chan struct{})
+	release := make(chan struct{})
+	registry.Register(NewChecker("slow", func(ctx context.Context) error {
+		close(started)
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan *Report)
+	go func() { reports <- registry.Check(ctx) }()
+	<-started
+	cancel()
+	assert.Equal(t, StatusDown, (<-reports).Status, "the cancelled probe gives up")
+
+	close(release)
+	report := registry.Check(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, StatusUp, report.Checks[0].Status)
+}
+
+func TestChecksAreBoundedByTheCheckTimeout(t *testing.T) {
+	registry := NewRegistry(20*time.Millisecond, time.Minute, &fakeClock{now: time.Now()})
+	registry.Register(NewChecker("hanging", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+
+	report := registry.Check(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Contains(t, report.Checks[0].Error, context.DeadlineExceeded.Error())
+}
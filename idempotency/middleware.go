@@ -0,0 +1,164 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"golang-microservice-template/ratelimit"
+	. "golang-microservice-template/utils"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header that holds the idempotency key chosen by the client.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is set on responses that are replayed from a previous request.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+	// MaxKeyLength is the maximum length of an idempotency key.
+	MaxKeyLength = 255
+)
+
+// errors
+var (
+	ErrKeyTooLong        = "Idempotency-Key must not be longer than %d characters"
+	ErrKeyReused         = "Idempotency-Key %s was already used for a different request"
+	ErrRequestInProgress = "a request with Idempotency-Key %s is still in progress"
+	ErrBodyTooLarge      = "requests with Idempotency-Key must not have a body larger than %d bytes"
+)
+
+// Middleware makes requests with an Idempotency-Key header safe to retry. The response to the first request
+// with a key is stored for ttl and replayed for retries with the same method, URI and body.
+// Retries with a different request are answered with 422, retries while the first request is in progress with 409.
+// Server errors are not stored, so the request can be retried. Keys are scoped to the client, see ratelimit.ClientKey.
+// The body is read to compare requests, bodies larger than maxBodySize bytes are rejected with 413.
+func Middleware(store Store, clock Clock, ttl time.Duration, maxBodySize int64) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			key := ctx.Request().Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				return next(ctx)
+			}
+			if len(key) > MaxKeyLength {
+				return Errorf(ErrorTypeBadRequest, ErrKeyTooLong, MaxKeyLength)
+			}
+
+			fingerprint, err := fingerprint(ctx, maxBodySize)
+			if err != nil {
+				return err
+			}
+
+			record := &Record{
+				Key:         ratelimit.ClientKey(ctx) + " " + key,
+				Fingerprint: fingerprint,
+				ExpiresAt:   clock.Now().Add(ttl),
+			}
+			existing, err := store.Reserve(record, clock.Now())
+			if err != nil {
+				return Error(err, ErrorTypeInternalServer)
+			}
+			if existing != nil {
+				return replay(ctx, existing, key, fingerprint)
+			}
+
+			return execute(ctx, next, store, record)
+		}
+	}
+}
+
+// execute handles the first request with a key and stores its response.
+func execute(ctx echo.Context, next echo.HandlerFunc, store Store, record *Record) (err error) {
+	completed := false
+	defer func() {
+		// Also releases the key if the handler panics.
+		if !completed {
+			if releaseErr := store.Release(record.Key); releaseErr != nil && err == nil {
+				err = Error(releaseErr, ErrorTypeInternalServer)
+			}
+		}
+	}()
+
+	response := ctx.Response()
+	headers := response.Header().Clone()
+	recorder := &recorder{ResponseWriter: response.Writer}
+	response.Writer = recorder
+
+	// Errors are handled here to store the error response, like a response written by the handler.
+	if err := next(ctx); err != nil {
+		ctx.Error(err)
+	}
+	response.Writer = recorder.ResponseWriter
+
+	if response.Status >= http.StatusInternalServerError {
+		return nil
+	}
+
+	record.Status = response.Status
+	record.Body = recorder.body.Bytes()
+	record.Header = http.Header{}
+	for name, values := range response.Header() {
+		if !reflect.DeepEqual(headers[name], values) {
+			record.Header[name] = values
+		}
+	}
+	if err := store.Complete(record); err != nil {
+		return Error(err, ErrorTypeInternalServer)
+	}
+	completed = true
+	return nil
+}
+
+// replay answers a retry with the stored response.
+func replay(ctx echo.Context, record *Record, key, fingerprint string) error {
+	if record.Fingerprint != fingerprint {
+		return Errorf(ErrorTypeUnprocessableEntity, ErrKeyReused, key)
+	}
+	if !record.Completed {
+		return Errorf(ErrorTypeConflict, ErrRequestInProgress, key)
+	}
+
+	header := ctx.Response().Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+
+	ctx.Response().WriteHeader(record.Status)
+	_, err := ctx.Response().Write(record.Body)
+	return err
+}
+
+// fingerprint hashes the method, URI and body of a request. The body is restored for the handler.
+func fingerprint(ctx echo.Context, maxBodySize int64) (string, error) {
+	request := ctx.Request()
+	body, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Response(), request.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return "", Errorf(ErrorTypeRequestTooLarge, ErrBodyTooLarge, maxBodySize)
+		}
+		return "", Error(err, ErrorTypeBadRequest)
+	}
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(request.Method + " " + request.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// recorder copies the response body written by the handler.
+type recorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
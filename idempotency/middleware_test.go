@@ -0,0 +1,73 @@
+package idempotency
+
+import (
+	. "golang-microservice-template/utils"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(maxBodySize int64) (*echo.Echo, *int) {
+	calls := 0
+	e := echo.New()
+	e.HTTPErrorHandler = HTTPErrorHandler
+	e.POST("/", func(ctx echo.Context) error {
+		calls++
+		body, err := ioutil.ReadAll(ctx.Request().Body)
+		if err != nil {
+			return err
+		}
+		return ctx.String(http.StatusCreated, string(body))
+	}, Middleware(NewMemoryStore(10), SystemClock(), time.Hour, maxBodySize))
+	return e, &calls
+}
+
+func post(e *echo.Echo, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderIdempotencyKey, key)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestBodyWithinLimitIsReplayed(t *testing.T) {
+	e, calls := newTestServer(8)
+
+	first := post(e, "k1", "12345678")
+	retry := post(e, "k1", "12345678")
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, "12345678", first.Body.String())
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestBodyOverLimitIsRejected(t *testing.T) {
+	e, calls := newTestServer(8)
+
+	rec := post(e, "k1", "123456789")
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, 0, *calls)
+	// the key was not reserved
+	assert.Equal(t, http.StatusCreated, post(e, "k1", "1234").Code)
+}
+
+func TestRequestWithoutKeyIsNotLimited(t *testing.T) {
+	e, calls := newTestServer(8)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("123456789"))
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, *calls)
+}
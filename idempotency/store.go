@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Record is the response to the first request with an idempotency key.
+type Record struct {
+	// Key is the idempotency key, prefixed with the client it belongs to.
+	Key string
+	// Fingerprint is a hash of the method, URI and body of the request.
+	Fingerprint string
+	// Completed is false while the first request is in progress.
+	Completed bool
+	Status    int
+	// Header holds the headers set by the handler, e.g. Content-Type.
+	Header    http.Header
+	Body      []byte
+	ExpiresAt time.Time
+}
+
+// Store persists the records of idempotency keys.
+type Store interface {
+	// Reserve stores an incomplete record if there is no unexpired record with the same key.
+	// Otherwise the existing record is returned and nothing is stored.
+	Reserve(record *Record, now time.Time) (*Record, error)
+	// Complete stores the response of a reserved record.
+	Complete(record *Record) error
+	// Release removes a reserved record, so the request can be retried with the same key.
+	Release(key string) error
+}
+
+type memoryStore struct {
+	records  map[string]*list.Element
+	order    *list.List
+	capacity int
+	sync.Mutex
+}
+
+// NewMemoryStore creates a store that keeps up to capacity records in memory.
+// The oldest records are removed if the capacity is exceeded before they expire.
+func NewMemoryStore(capacity int) Store {
+	return &memoryStore{
+		records:  make(map[string]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+	}
+}
+
+func (s *memoryStore) Reserve(record *Record, now time.Time) (*Record, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	// All records have the same time to live, so the oldest expire first.
+	for front := s.order.Front(); front != nil && !front.Value.(*Record).ExpiresAt.After(now); front = s.order.Front() {
+		s.remove(front)
+	}
+
+	if element, ok := s.records[record.Key]; ok {
+		return element.Value.(*Record).copy(), nil
+	}
+
+	for s.order.Len() >= s.capacity {
+		s.remove(s.order.Front())
+	}
+	s.records[record.Key] = s.order.PushBack(record.copy())
+
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(record *Record) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if element, ok := s.records[record.Key]; ok {
+		completed := record.copy()
+		completed.Completed = true
+		element.Value = completed
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(key string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if element, ok := s.records[key]; ok {
+		s.remove(element)
+	}
+	return nil
+}
+
+// remove deletes a record. The caller must hold the lock.
+func (s *memoryStore) remove(element *list.Element) {
+	delete(s.records, element.Value.(*Record).Key)
+	s.order.Remove(element)
+}
+
+func (r *Record) copy() *Record {
+	c := *r
+	c.Header = r.Header.Clone()
+	c.Body = append([]byte(nil), r.Body...)
+	return &c
+}
@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrStartComponent = "failed to start %s: %v"
+	ErrStopComponents = "failed to stop components: %s"
+)
+
+// Hook starts or stops a component. It should return once the context is done.
+type Hook func(ctx context.Context) error
+
+// ComponentOption configures a registered component.
+type ComponentOption func(c *component)
+
+// WithStopTimeout overrides the stop timeout of the manager for the component.
+func WithStopTimeout(timeout time.Duration) ComponentOption {
+	return func(c *component) {
+		c.stopTimeout = timeout
+	}
+}
+
+// Manager starts and stops the components of the service in a defined order.
+type Manager interface {
+	// Register adds a component with its start and stop hooks, both hooks may be nil.
+	Register(name string, start, stop Hook, options ...ComponentOption)
+	// Start runs all start hooks in registration order. If a hook fails,
+	// the components that were already started are stopped again.
+	Start(ctx context.Context) error
+	// Stop runs the stop hooks of all started components in reverse registration order.
+	// All hooks are run even if one of them fails. Every hook gets its own stop timeout, so a slow
+	// component does not use up the time of the components stopped after it. Cancelling ctx cancels all hooks.
+	Stop(ctx context.Context) error
+}
+
+type component struct {
+	name        string
+	start       Hook
+	stop        Hook
+	stopTimeout time.Duration
+}
+
+type manager struct {
+	components  []component
+	started     int
+	stopTimeout time.Duration
+	sync.Mutex
+}
+
+// NewManager creates a new Manager without components, each stop hook may run for stopTimeout.
+func NewManager(stopTimeout time.Duration) Manager {
+	return &manager{
+		components:  []component{},
+		stopTimeout: stopTimeout,
+	}
+}
+
+func (m *manager) Register(name string, start, stop Hook, options ...ComponentOption) {
+	m.Lock()
+	defer m.Unlock()
+
+	c := component{name: name, start: start, stop: stop, stopTimeout: m.stopTimeout}
+	for _, option := range options {
+		option(&c)
+	}
+	m.components = append(m.components, c)
+}
+
+func (m *manager) Start(ctx context.Context) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, c := range m.components[m.started:] {
+		if c.start != nil {
+			Log.Debugf("starting %s", c.name)
+			if err := c.start(ctx); err != nil {
+				err = fmt.Errorf(ErrStartComponent, c.name, err)
+				if stopErr := m.stop(ctx); stopErr != nil {
+					Log.Error(stopErr.Error())
+				}
+				return err
+			}
+		}
+		m.started++
+	}
+
+	return nil
+}
+
+func (m *manager) Stop(ctx context.Context) error {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.stop(ctx)
+}
+
+func (m *manager) stop(ctx context.Context) error {
+	problems := []string{}
+
+	for ; m.started > 0; m.started-- {
+		c := m.components[m.started-1]
+		if c.stop == nil {
+			continue
+		}
+
+		Log.Debugf("stopping %s", c.name)
+		if err := c.stopWithin(ctx); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", c.name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf(ErrStopComponents, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// stopWithin runs the stop hook with a context that expires after the stop timeout of the component.
+func (c component) stopWithin(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.stopTimeout)
+	defer cancel()
+
+	return c.stop(ctx)
+}
@@ -0,0 +1,112 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitForDone returns a stop hook that blocks until its context is done and reports the error of the context.
+func waitForDone(errs chan<- error) Hook {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		errs <- ctx.Err()
+		return ctx.Err()
+	}
+}
+
+func TestStopGivesEveryHookItsOwnTimeout(t *testing.T) {
+	m := NewManager(50 * time.Millisecond)
+	flushed := make(chan error, 1)
+	m.Register("relay", nil, func(ctx context.Context) error {
+		select {
+		case <-ctx.Done():
+			flushed <- ctx.Err()
+		default:
+			flushed <- nil
+		}
+		return nil
+	})
+	slow := make(chan error, 1)
+	m.Register("http server", nil, waitForDone(slow), WithStopTimeout(100*time.Millisecond))
+	require.NoError(t, m.Start(context.Background()))
+
+	started := time.Now()
+	err := m.Stop(context.Background())
+
+	assert.Error(t, err, "the slow hook reports its timeout")
+	assert.Equal(t, context.DeadlineExceeded, <-slow)
+	assert.True(t, time.Since(started) >= 100*time.Millisecond, "the slow hook runs for its own timeout")
+	assert.NoError(t, <-flushed, "the hook stopped after the slow one gets a fresh timeout")
+}
+
+func TestStopRunsHooksInReverseOrder(t *testing.T) {
+	m := NewManager(time.Second)
+	stopped := []string{}
+	for _, name := range []string{"repository", "relay", "http server"} {
+		name := name
+		m.Register(name, nil, func(context.Context) error {
+			stopped = append(stopped, name)
+			return nil
+		})
+	}
+	require.NoError(t, m.Start(context.Background()))
+
+	require.NoError(t, m.Stop(context.Background()))
+
+	assert.Equal(t, []string{"http server", "relay", "repository"}, stopped)
+}
+
+func TestStopRunsAllHooksIfOneFails(t *testing.T) {
+	m := NewManager(time.Second)
+	stopped := false
+	m.Register("repository", nil, func(context.Context) error {
+		stopped = true
+		return nil
+	})
+	m.Register("relay", nil, func(context.Context) error { return errors.New("broken") })
+	require.NoError(t, m.Start(context.Background()))
+
+	err := m.Stop(context.Background())
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "relay: broken")
+	}
+	assert.True(t, stopped)
+}
+
+func TestCancellingStopCancelsAllHooks(t *testing.T) {
+	m := NewManager(time.Minute)
+	errs := make(chan error, 2)
+	m.Register("repository", nil, waitForDone(errs))
+	m.Register("relay", nil, waitForDone(errs))
+	require.NoError(t, m.Start(context.Background()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.Error(t, m.Stop(ctx))
+
+	assert.Equal(t, context.Canceled, <-errs)
+	assert.Equal(t, context.Canceled, <-errs)
+}
+
+func TestFailedStartStopsStartedComponents(t *testing.T) {
+	m := NewManager(time.Second)
+	stopped := false
+	m.Register("repository", func(context.Context) error { return nil }, func(context.Context) error {
+		stopped = true
+		return nil
+	})
+	m.Register("broker", func(context.Context) error { return errors.New("unreachable") }, nil)
+
+	err := m.Start(context.Background())
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "broker")
+	}
+	assert.True(t, stopped)
+}
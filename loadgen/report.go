@@ -0,0 +1,133 @@
+package loadgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// results collects the latencies and errors of the operations of one worker.
+type results struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	firstErr  map[string]string
+}
+
+func newResults() *results {
+	return &results{
+		latencies: map[string][]time.Duration{},
+		errors:    map[string]int{},
+		firstErr:  map[string]string{},
+	}
+}
+
+func (r *results) add(op string, latency time.Duration, err error) {
+	r.latencies[op] = append(r.latencies[op], latency)
+	if err != nil {
+		r.errors[op]++
+		if _, ok := r.firstErr[op]; !ok {
+			r.firstErr[op] = err.Error()
+		}
+	}
+}
+
+// Report holds the throughput and latency percentiles of a load test.
+type Report struct {
+	Duration    time.Duration `json:"duration"`
+	Concurrency int           `json:"concurrency"`
+	// Operations holds the statistics of every executed operation, followed by the total.
+	Operations []*OperationStats `json:"operations"`
+}
+
+// OperationStats holds the statistics of an operation. Latencies are in milliseconds.
+type OperationStats struct {
+	Operation string `json:"operation"`
+	Count     int    `json:"count"`
+	Errors    int    `json:"errors"`
+	// Throughput is the number of operations per second.
+	Throughput float64 `json:"throughput"`
+	P50        float64 `json:"p50"`
+	P90        float64 `json:"p90"`
+	P99        float64 `json:"p99"`
+	Max        float64 `json:"max"`
+	// FirstError is an example of the errors of the operation.
+	FirstError string `json:"firstError,omitempty"`
+}
+
+func newReport(duration time.Duration, concurrency int, collected []*results) *Report {
+	report := &Report{Duration: duration, Concurrency: concurrency}
+
+	all := []time.Duration{}
+	total := &OperationStats{Operation: "total"}
+	for _, op := range Operations {
+		latencies := []time.Duration{}
+		stats := &OperationStats{Operation: op}
+		for _, r := range collected {
+			latencies = append(latencies, r.latencies[op]...)
+			stats.Errors += r.errors[op]
+			if stats.FirstError == "" {
+				stats.FirstError = r.firstErr[op]
+			}
+		}
+		if len(latencies) == 0 {
+			continue
+		}
+
+		stats.summarize(latencies, duration)
+		report.Operations = append(report.Operations, stats)
+
+		all = append(all, latencies...)
+		total.Errors += stats.Errors
+	}
+	total.summarize(all, duration)
+	report.Operations = append(report.Operations, total)
+
+	return report
+}
+
+// summarize sets the count, throughput and percentiles of the latencies.
+func (s *OperationStats) summarize(latencies []time.Duration, duration time.Duration) {
+	s.Count = len(latencies)
+	if s.Count == 0 {
+		return
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.Throughput = float64(s.Count) / duration.Seconds()
+	s.P50 = milliseconds(percentile(latencies, 50))
+	s.P90 = milliseconds(percentile(latencies, 90))
+	s.P99 = milliseconds(percentile(latencies, 99))
+	s.Max = milliseconds(latencies[len(latencies)-1])
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Write prints the report as a table.
+func (r *Report) Write(w io.Writer) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(table, "duration %s, %d workers\n", r.Duration.Round(time.Millisecond), r.Concurrency)
+	fmt.Fprintln(table, "operation\tcount\terrors\tops/s\tp50 ms\tp90 ms\tp99 ms\tmax ms\t")
+	for _, s := range r.Operations {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.1f\t%.3f\t%.3f\t%.3f\t%.3f\t\n",
+			s.Operation, s.Count, s.Errors, s.Throughput, s.P50, s.P90, s.P99, s.Max)
+	}
+	for _, s := range r.Operations {
+		if s.FirstError != "" && s.Operation != "total" {
+			fmt.Fprintf(table, "%s error: %s\n", s.Operation, s.FirstError)
+		}
+	}
+	return table.Flush()
+}
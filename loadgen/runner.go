@@ -0,0 +1,210 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errors
+var (
+	ErrInvalidMix    = "invalid mix '%s', expected e.g. list=40,get=40,create=10,update=5,delete=5"
+	ErrInvalidConfig = "duration, concurrency and pizzas must be positive"
+)
+
+// DefaultMix is mostly reads, like a menu.
+const DefaultMix = "list=40,get=40,create=10,update=5,delete=5"
+
+// Config holds the settings of a load test.
+type Config struct {
+	// Duration of the test.
+	Duration time.Duration
+	// Concurrency is the number of workers that execute operations one after another.
+	Concurrency int
+	// Rate is the maximum number of operations per second of all workers, 0 for no limit.
+	Rate float64
+	// Mix is the relative weight of each operation.
+	Mix map[string]int
+	// Pizzas is the number of pizzas that are created before the test and then read and updated.
+	Pizzas int
+}
+
+// ParseMix parses the weights of the operations, e.g. list=40,get=40,create=10,update=5,delete=5.
+func ParseMix(value string) (map[string]int, error) {
+	mix := map[string]int{}
+	for _, part := range strings.Split(value, ",") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			return nil, fmt.Errorf(ErrInvalidMix, value)
+		}
+		weight, err := strconv.Atoi(pair[1])
+		if err != nil || weight < 0 || !known(pair[0]) {
+			return nil, fmt.Errorf(ErrInvalidMix, value)
+		}
+		mix[pair[0]] = weight
+	}
+	return mix, nil
+}
+
+func known(op string) bool {
+	for _, o := range Operations {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Run seeds the pizzas and executes the operations of the mix against the target until the duration passed
+// or the context is canceled. Created pizzas are deleted by the delete operations of the same worker,
+// a delete without a created pizza creates one instead.
+func Run(ctx context.Context, target Target, cfg Config) (*Report, error) {
+	if cfg.Duration <= 0 || cfg.Concurrency < 1 || cfg.Pizzas < 1 {
+		return nil, fmt.Errorf(ErrInvalidConfig)
+	}
+	picker, err := newPicker(cfg.Mix)
+	if err != nil {
+		return nil, err
+	}
+
+	seeded := make([]string, cfg.Pizzas)
+	for i := range seeded {
+		seeded[i] = fmt.Sprintf("loadgen-%d", i)
+	}
+	if err := target.Seed(ctx, seeded); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var ticks <-chan time.Time
+	if cfg.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.Rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	// Names of created pizzas are unique across runs against the same instance.
+	run := strconv.FormatInt(time.Now().UnixNano(), 36)
+	collected := make([]*results, cfg.Concurrency)
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range collected {
+		collected[i] = newResults()
+		wg.Add(1)
+		go func(index int, r *results) {
+			defer wg.Done()
+
+			w := &worker{
+				target:  target,
+				picker:  picker,
+				random:  rand.New(rand.NewSource(started.UnixNano() + int64(index))),
+				seeded:  seeded,
+				prefix:  fmt.Sprintf("loadgen-%s-%d-", run, index),
+				results: r,
+			}
+			w.run(ctx, ticks)
+		}(i, collected[i])
+	}
+	wg.Wait()
+
+	return newReport(time.Since(started), cfg.Concurrency, collected), nil
+}
+
+type worker struct {
+	target  Target
+	picker  *picker
+	random  *rand.Rand
+	seeded  []string
+	prefix  string
+	created []string
+	results *results
+}
+
+func (w *worker) run(ctx context.Context, ticks <-chan time.Time) {
+	for sequence := 0; ; sequence++ {
+		if ticks != nil {
+			select {
+			case <-ticks:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		op, name := w.next(sequence)
+		start := time.Now()
+		err := w.target.Execute(ctx, op, name)
+		latency := time.Since(start)
+
+		// Operations interrupted by the end of the test are not counted.
+		if ctx.Err() != nil {
+			return
+		}
+		w.results.add(op, latency, err)
+
+		if err == nil && op == OpCreate {
+			w.created = append(w.created, name)
+		}
+	}
+}
+
+// next picks the next operation and its pizza.
+func (w *worker) next(sequence int) (string, string) {
+	op := w.picker.pick(w.random)
+	if op == OpDelete && len(w.created) == 0 {
+		op = OpCreate
+	}
+
+	switch op {
+	case OpCreate:
+		return op, w.prefix + strconv.Itoa(sequence)
+	case OpDelete:
+		name := w.created[len(w.created)-1]
+		w.created = w.created[:len(w.created)-1]
+		return op, name
+	default:
+		return op, w.seeded[w.random.Intn(len(w.seeded))]
+	}
+}
+
+// picker chooses operations randomly by their weight.
+type picker struct {
+	ops     []string
+	weights []int
+	total   int
+}
+
+func newPicker(mix map[string]int) (*picker, error) {
+	p := &picker{}
+	for _, op := range Operations {
+		if weight := mix[op]; weight > 0 {
+			p.ops = append(p.ops, op)
+			p.weights = append(p.weights, weight)
+			p.total += weight
+		}
+	}
+	if p.total == 0 {
+		return nil, fmt.Errorf(ErrInvalidMix, "")
+	}
+	return p, nil
+}
+
+func (p *picker) pick(random *rand.Rand) string {
+	n := random.Intn(p.total)
+	for i, weight := range p.weights {
+		if n < weight {
+			return p.ops[i]
+		}
+		n -= weight
+	}
+	return p.ops[len(p.ops)-1]
+}
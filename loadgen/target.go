@@ -0,0 +1,172 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"golang-microservice-template/pizza"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// Operations of the load mix
+const (
+	OpList   = "list"
+	OpGet    = "get"
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Operations are all operations in the order of the report.
+var Operations = []string{OpList, OpGet, OpCreate, OpUpdate, OpDelete}
+
+// errors
+var (
+	ErrUnexpectedStatus = "%s %s answered %d"
+	ErrUnknownOperation = "unknown operation '%s'"
+)
+
+// Target executes the operations of a load test, e.g. against the REST API or the repository.
+type Target interface {
+	// Seed creates the pizzas that are read and updated. Existing pizzas are kept.
+	Seed(ctx context.Context, names []string) error
+	// Execute runs an operation on a pizza, the name is ignored by list.
+	Execute(ctx context.Context, op, name string) error
+}
+
+type httpTarget struct {
+	baseURL string
+	client  *http.Client
+	header  http.Header
+}
+
+// NewHTTPTarget creates a target that calls the REST API at the base URL, e.g. http://localhost:8080.
+// The header is added to every request, e.g. for authentication.
+func NewHTTPTarget(baseURL string, client *http.Client, header http.Header) Target {
+	return &httpTarget{baseURL: baseURL, client: client, header: header}
+}
+
+func (t *httpTarget) Seed(ctx context.Context, names []string) error {
+	for _, name := range names {
+		err := t.do(ctx, http.MethodPost, "/v1/pizza", newPizza(name), http.StatusCreated, http.StatusConflict)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *httpTarget) Execute(ctx context.Context, op, name string) error {
+	path := "/v1/pizza/" + url.PathEscape(name)
+
+	switch op {
+	case OpList:
+		return t.do(ctx, http.MethodGet, "/v1/pizza", nil, http.StatusOK)
+	case OpGet:
+		return t.do(ctx, http.MethodGet, path, nil, http.StatusOK)
+	case OpCreate:
+		return t.do(ctx, http.MethodPost, "/v1/pizza", newPizza(name), http.StatusCreated)
+	case OpUpdate:
+		return t.do(ctx, http.MethodPatch, path, newPizza(name), http.StatusOK)
+	case OpDelete:
+		return t.do(ctx, http.MethodDelete, path, nil, http.StatusNoContent)
+	default:
+		return fmt.Errorf(ErrUnknownOperation, op)
+	}
+}
+
+// do sends a request and returns an error if the response status is not one of the expected.
+func (t *httpTarget) do(ctx context.Context, method, path string, body interface{}, expected ...int) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, t.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// The body is read to reuse the connection.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf(ErrUnexpectedStatus, method, path, resp.StatusCode)
+}
+
+type repositoryTarget struct {
+	repository pizza.Repository
+}
+
+// NewRepositoryTarget creates a target that calls the repository directly, to measure its locking without HTTP.
+func NewRepositoryTarget(repository pizza.Repository) Target {
+	return &repositoryTarget{repository: repository}
+}
+
+func (t *repositoryTarget) Seed(_ context.Context, names []string) error {
+	for _, name := range names {
+		if found, _ := t.repository.FindByName(name); found != nil {
+			continue
+		}
+		if _, err := t.repository.Save(newPizza(name).ConvertToModel()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *repositoryTarget) Execute(_ context.Context, op, name string) error {
+	var err error
+	switch op {
+	case OpList:
+		_, err = t.repository.FindAll()
+	case OpGet:
+		_, err = t.repository.FindByName(name)
+	case OpCreate:
+		_, err = t.repository.Save(newPizza(name).ConvertToModel())
+	case OpUpdate:
+		_, err = t.repository.Update(newPizza(name).ConvertToModel())
+	case OpDelete:
+		err = t.repository.Delete(name)
+	default:
+		err = fmt.Errorf(ErrUnknownOperation, op)
+	}
+	return err
+}
+
+// newPizza returns a pizza with a few ingredients, like a typical request.
+func newPizza(name string) *pizza.PizzaDto {
+	return &pizza.PizzaDto{
+		Name: name,
+		Ingredient: []pizza.IngredientDto{
+			{Name: "tomato", Count: 1},
+			{Name: "mozzarella", Count: 2},
+			{Name: "basil", Count: 3},
+		},
+	}
+}
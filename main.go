@@ -1,36 +1,363 @@
 package main
 
 import (
+	"context"
 	"golang-microservice-template/api"
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/broker"
+	"golang-microservice-template/cache"
+	"golang-microservice-template/config"
+	"golang-microservice-template/consumer"
+	"golang-microservice-template/events"
+	"golang-microservice-template/health"
+	"golang-microservice-template/idempotency"
+	"golang-microservice-template/lifecycle"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/ratelimit"
+	"golang-microservice-template/snapshot"
+	"golang-microservice-template/sse"
+	"golang-microservice-template/tlsutil"
 	. "golang-microservice-template/utils"
+	"golang-microservice-template/webhook"
+	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
-)
-
-const (
-	defaultPort = 8080
-	localPort   = 8081
+	"time"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the service and blocks until it is stopped. It returns the exit code of the process.
+func run() int {
 	Log.Infof("[PizzaService] Start")
 
-	router := api.NewRouter()
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		Log.Error(err.Error())
+		return 1
+	}
+	Log.SetLevel(cfg.LogLevel())
+
+	configManager := config.NewManager(cfg, os.Args[1:])
+	configManager.OnChange(func(_, next *config.Config) {
+		Log.SetLevel(next.LogLevel())
+	})
 
-	port := defaultPort
-	if Environment() == ENV_LOCAL {
-		port = localPort
+	serverErrors := make(chan error, 1)
+	app, err := newApplication(cfg, configManager, serverErrors)
+	if err != nil {
+		Log.Error(err.Error())
+		return 1
 	}
 
-	go func() {
-		Log.Fatal(router.Start(":" + strconv.Itoa(port)))
-	}()
+	if err := app.Start(context.Background()); err != nil {
+		Log.Error(err.Error())
+		return 1
+	}
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGABRT)
-	<-done
 
-	router.Shutdown()
+	exitCode := 0
+	for running := true; running; {
+		select {
+		case <-reload:
+			_ = configManager.Reload()
+		case err := <-serverErrors:
+			Log.Error(err.Error())
+			exitCode = 1
+			running = false
+		case sig := <-done:
+			Log.Infof("[PizzaService] Received %s, shutting down", sig)
+			running = false
+		}
+	}
+
+	// a second signal skips the graceful shutdown
+	go func() {
+		sig := <-done
+		Log.Errorf("[PizzaService] Received %s during shutdown, forcing exit", sig)
+		os.Exit(1)
+	}()
+
+	// every stop hook gets its own timeout, see newApplication
+	if err := app.Stop(context.Background()); err != nil {
+		Log.Error(err.Error())
+		return 1
+	}
+
+	Log.Infof("[PizzaService] Stopped")
+	return exitCode
+}
+
+// newApplication is the composition root of the service. It creates all components, wires them together
+// and registers their start and stop hooks. Components are stopped in reverse order, so the HTTP server
+// is registered last to finish in-flight requests before the resources they use are released.
+func newApplication(cfg *config.Config, configManager config.Manager, serverErrors chan<- error) (lifecycle.Manager, error) {
+	app := lifecycle.NewManager(cfg.Server.ShutdownTimeout)
+	clock := SystemClock()
+
+	stopWatching := make(chan struct{})
+	app.Register("config watcher", func(context.Context) error {
+		if cfg.WatchInterval > 0 && cfg.File != "" {
+			go configManager.Watch(cfg.WatchInterval, stopWatching)
+		}
+		return nil
+	}, func(context.Context) error {
+		close(stopWatching)
+		return nil
+	})
+
+	repository := newRepository(cfg.Pizza, clock)
+	app.Register("repository", nil, func(context.Context) error {
+		return repository.Close()
+	})
+
+	registry := health.NewRegistry(cfg.Health.CheckTimeout, cfg.Health.CacheTTL, clock)
+	registry.Register(health.NewDiskSpaceChecker(cfg.Health.DiskPath, cfg.Health.DiskMinFreeBytes))
+	registry.Register(health.NewChecker("repository", func(context.Context) error {
+		return repository.Ping()
+	}))
+
+	auditSink, err := newAuditSink(cfg.Audit)
+	if err != nil {
+		return nil, err
+	}
+	app.Register("audit sink", nil, func(context.Context) error {
+		return auditSink.Close()
+	})
+
+	bus := events.NewInProcessBus()
+	bus.Subscribe(func(_ context.Context, event *events.Event) error {
+		Log.Debugf("published %s of %s revision %d", event.Type, event.Subject, event.Revision)
+		return nil
+	})
+
+	var webhooks webhook.Service
+	if cfg.Webhooks.Enabled {
+		store := webhook.NewMemoryStore(cfg.Webhooks.DeliveryLogSize)
+		webhooks = webhook.NewService(store, clock, pizza.EventTypes, cfg.IsProduction(), cfg.Webhooks.AllowPrivateAddresses)
+		dispatcher := webhook.NewDispatcher(store, clock, Log, cfg.Webhooks)
+		bus.Subscribe(dispatcher.Handle, pizza.EventTypes...)
+		app.Register("webhook dispatcher", func(context.Context) error {
+			dispatcher.Start()
+			return nil
+		}, dispatcher.Stop)
+	}
+
+	stream := sse.NewBroker(cfg.Stream.ReplayBufferSize)
+	bus.Subscribe(stream.Handle, pizza.EventTypes...)
+
+	relay := events.NewRelay(repository.Outbox(), bus, clock, Log, cfg.Events)
+	stopRelay := make(chan struct{})
+	app.Register("event relay", func(context.Context) error {
+		go relay.Run(stopRelay)
+		return nil
+	}, func(ctx context.Context) error {
+		close(stopRelay)
+		return relay.Flush(ctx)
+	})
+
+	serviceOptions := []pizza.ServiceOption{
+		pizza.WithRepository(repository),
+		pizza.WithClock(clock),
+		pizza.WithLogger(Log),
+		pizza.WithConfig(cfg.Pizza),
+		pizza.WithAuditSink(auditSink),
+		pizza.WithReadOnly(func() bool {
+			return configManager.Current().FeatureEnabled(config.FeatureReadOnly)
+		}),
+	}
+	var responses cache.Cache
+	if cfg.Cache.Enabled {
+		responses = cache.NewInstrumentedCache("pizza", cache.NewLRUCache(cfg.Cache.Capacity, clock))
+		serviceOptions = append(serviceOptions, pizza.WithCache(responses))
+	}
+	pizzas := pizza.NewService(serviceOptions...)
+
+	// Snapshots are restored before and written after the consumer and the HTTP server change pizzas.
+	var snapshots snapshot.Manager
+	if cfg.Snapshot.Enabled {
+		snapshots = snapshot.NewManager(pizzas, cfg.Snapshot.File, Log)
+		stopSnapshots := make(chan struct{})
+		app.Register("snapshots", func(context.Context) error {
+			info, err := snapshots.Load()
+			if err != nil {
+				return err
+			}
+			if info != nil {
+				Log.Infof("restored %d pizzas from snapshot %s of %s", info.Pizzas, info.File, info.CreatedAt.Format(time.RFC3339))
+			}
+			if cfg.Snapshot.Interval > 0 {
+				go snapshots.Run(cfg.Snapshot.Interval, stopSnapshots)
+			}
+			return nil
+		}, func(context.Context) error {
+			close(stopSnapshots)
+			info, err := snapshots.Save()
+			if err != nil {
+				return err
+			}
+			Log.Infof("saved snapshot of %d pizzas to %s", info.Pizzas, info.File)
+			return nil
+		})
+	}
+
+	if cfg.Consumer.Enabled {
+		messageBroker, err := newBroker(cfg.Consumer)
+		if err != nil {
+			return nil, err
+		}
+		registry.Register(health.NewChecker("message broker", messageBroker.Ping))
+		app.Register("message broker", nil, func(context.Context) error {
+			return messageBroker.Close()
+		})
+
+		commands := consumer.NewConsumer(messageBroker, pizzas, Log, cfg.Consumer)
+		app.Register("command consumer", func(context.Context) error {
+			return commands.Start()
+		}, commands.Stop)
+	}
+
+	routerOptions := []api.RouterOption{
+		api.WithConfig(cfg),
+		api.WithConfigManager(configManager),
+		api.WithPizzaService(pizzas),
+		api.WithHealthRegistry(registry),
+		api.WithLogger(Log),
+		api.WithClock(clock),
+		api.WithStream(stream),
+	}
+
+	if responses != nil {
+		routerOptions = append(routerOptions, api.WithCache(responses))
+	}
+
+	if snapshots != nil {
+		routerOptions = append(routerOptions, api.WithSnapshots(snapshots))
+	}
+
+	if cfg.Idempotency.Enabled {
+		routerOptions = append(routerOptions, api.WithIdempotencyStore(idempotency.NewMemoryStore(cfg.Idempotency.MaxEntries)))
+	}
+
+	if webhooks != nil {
+		routerOptions = append(routerOptions, api.WithWebhooks(webhooks))
+	}
+
+	if store, ok := auditSink.(audit.Store); ok {
+		routerOptions = append(routerOptions, api.WithAuditStore(store))
+	}
+
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), clock, rateLimits(cfg.RateLimit))
+		configManager.OnChange(func(_, next *config.Config) {
+			limiter.SetLimits(rateLimits(next.RateLimit))
+		})
+		routerOptions = append(routerOptions, api.WithRateLimiter(limiter))
+	}
+
+	if cfg.Auth.APIKeys.Enabled {
+		keys, err := newAPIKeyService(cfg.Auth.APIKeys, clock)
+		if err != nil {
+			return nil, err
+		}
+		routerOptions = append(routerOptions, api.WithAPIKeys(keys))
+	}
+
+	if cfg.Auth.MTLS.Enabled {
+		verifier, err := tlsutil.NewClientVerifier(cfg.Auth.MTLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		routerOptions = append(routerOptions, api.WithClientVerifier(verifier))
+	}
+
+	if cfg.Auth.JWT.Enabled {
+		validator, err := auth.NewJWTValidator(cfg.Auth.JWT, clock)
+		if err != nil {
+			return nil, err
+		}
+		routerOptions = append(routerOptions, api.WithTokenValidator(validator))
+	}
+
+	router := api.NewRouter(routerOptions...)
+	app.Register("http server", func(context.Context) error {
+		go func() {
+			if err := router.Start(cfg.Address()); err != nil && err != http.ErrServerClosed {
+				serverErrors <- err
+			}
+		}()
+		return nil
+	}, router.Shutdown, lifecycle.WithStopTimeout(cfg.Server.DrainPeriod+cfg.Server.ShutdownTimeout))
+
+	return app, nil
+}
+
+// newAuditSink creates the configured audit sink.
+func newAuditSink(cfg config.AuditConfig) (audit.Sink, error) {
+	switch cfg.Sink {
+	case config.AuditSinkFile:
+		return audit.NewFileStore(cfg.File)
+	case config.AuditSinkLog:
+		return audit.NewLogSink(Log), nil
+	default:
+		return audit.NewMemoryStore(cfg.MemoryCapacity), nil
+	}
+}
+
+// newRepository creates the configured pizza repository.
+func newRepository(cfg config.PizzaConfig, clock Clock) pizza.Repository {
+	if cfg.Repository == config.RepositorySharded {
+		return pizza.NewShardedRepository(clock, cfg.Shards)
+	}
+	return pizza.NewRepository(clock)
+}
+
+// newBroker connects to the message broker of the consumer.
+func newBroker(cfg config.ConsumerConfig) (broker.Broker, error) {
+	switch cfg.Broker {
+	case config.BrokerKafka:
+		return broker.NewKafkaBroker(cfg.Kafka, Log), nil
+	default:
+		return broker.NewNATSBroker(cfg.NATS)
+	}
+}
+
+// newAPIKeyService creates the API key service with a file or in-memory store and imports the configured admin key.
+func newAPIKeyService(cfg config.APIKeyConfig, clock Clock) (apikey.Service, error) {
+	store := apikey.NewMemoryStore()
+	if cfg.File != "" {
+		var err error
+		if store, err = apikey.NewFileStore(cfg.File); err != nil {
+			return nil, err
+		}
+	}
+
+	service := apikey.NewService(store, clock)
+	// An admin key that was revoked or rotated in the store stays revoked, even if it is still configured.
+	if cfg.AdminKey != "" {
+		if err := service.Import("admin", cfg.AdminKey, "admin", []string{auth.ScopeAPIKeyAdmin}); err != nil {
+			return nil, err
+		}
+	}
+
+	return service, nil
+}
+
+// rateLimits converts the configured rate limits of all route classes.
+func rateLimits(cfg config.RateLimitConfig) map[string]ratelimit.Limit {
+	limits := map[string]ratelimit.Limit{}
+	for class, limit := range cfg.Limits {
+		limits[class] = ratelimit.Limit{Rate: limit.RequestsPerSecond, Burst: limit.Burst}
+	}
+	return limits
 }
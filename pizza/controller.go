@@ -1,8 +1,11 @@
 package pizza
 
 import (
+	"golang-microservice-template/audit"
 	. "golang-microservice-template/utils"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo"
 )
@@ -10,11 +13,17 @@ import (
 const (
 	// PathParamName is the request path parameter that holds the pizza name.
 	PathParamName = "name"
+	// PathParamRevision is the request path parameter that holds the revision number.
+	PathParamRevision = "revision"
+	// QueryParamAsOf is the query parameter that selects the state of a pizza at a point in time.
+	QueryParamAsOf = "asOf"
 )
 
 // errors
 var (
 	ErrParamNameMissing = "missing pizza name in path"
+	ErrInvalidAsOf      = "asOf must be an RFC 3339 timestamp, e.g. 2020-01-31T12:00:00Z"
+	ErrInvalidRevision  = "revision must be a positive number"
 )
 
 // Controller handles all requests related to pizza data.
@@ -23,8 +32,12 @@ type Controller interface {
 	Add(echo.Context) error
 	// GetAll returns all pizzas.
 	GetAll(echo.Context) error
-	// GetByName looks up and returns a pizza by name.
+	// GetByName looks up and returns a pizza by name, or its state at the time of the asOf query parameter.
 	GetByName(echo.Context) error
+	// GetRevisions returns all revisions of a pizza, oldest first.
+	GetRevisions(echo.Context) error
+	// Revert restores an older revision of a pizza as a new revision.
+	Revert(echo.Context) error
 	// Update changes an existing pizza.
 	Update(echo.Context) error
 	// Delete removes an existing pizza.
@@ -32,14 +45,12 @@ type Controller interface {
 }
 
 type controller struct {
-	repository Repository
+	service Service
 }
 
-// NewController creates a new Controller with pre-defined values.
-func NewController() Controller {
-	return &controller{
-		repository: NewRepository(),
-	}
+// NewController creates a new Controller that handles the requests with the given service.
+func NewController(service Service) Controller {
+	return &controller{service: service}
 }
 
 func (c *controller) Add(ctx echo.Context) error {
@@ -49,69 +60,74 @@ func (c *controller) Add(ctx echo.Context) error {
 		return Error(err, ErrorTypeBinding)
 	}
 
-	if err := ctx.Validate(dto); err != nil {
-		return Error(err, ErrorTypeValidation)
+	dto, err := c.service.Create(audit.OriginFromContext(ctx), dto)
+	if err != nil {
+		return err
 	}
 
-	found, err := c.repository.FindByName(dto.Name)
-	if found != nil {
-		return Errorf(ErrorTypeConflict, ErrPizzaNameTaken, dto.Name)
-	} else if err != nil {
-		Log.Debug(err)
-	}
+	return ctx.JSON(http.StatusCreated, dto)
+}
 
-	entity, err := dto.ConvertToModel()
+func (c *controller) GetAll(ctx echo.Context) error {
+	dtos, err := c.service.GetAll()
 	if err != nil {
-		return Error(err, ErrorTypeInternalServer)
+		return err
 	}
 
-	entity, err = c.repository.Save(entity)
+	return ctx.JSON(http.StatusOK, dtos)
+}
+
+func (c *controller) GetByName(ctx echo.Context) error {
+	name, err := checkNameInPath(ctx)
 	if err != nil {
-		return Error(err, ErrorTypeDatabase)
+		return err
 	}
 
-	dto, err = entity.ConvertToDto()
+	var dto *PizzaDto
+	if value := ctx.QueryParam(QueryParamAsOf); value != "" {
+		at, parseErr := time.Parse(time.RFC3339, value)
+		if parseErr != nil {
+			return Error(ErrInvalidAsOf, ErrorTypeBadRequest)
+		}
+		dto, err = c.service.GetAsOf(name, at)
+	} else {
+		dto, err = c.service.Get(name)
+	}
 	if err != nil {
-		return Error(err, ErrorTypeInternalServer)
+		return err
 	}
 
-	return ctx.JSON(http.StatusCreated, dto)
+	return ctx.JSON(http.StatusOK, dto)
 }
 
-func (c *controller) GetAll(ctx echo.Context) error {
-	pizzas, err := c.repository.FindAll()
+func (c *controller) GetRevisions(ctx echo.Context) error {
+	name, err := checkNameInPath(ctx)
 	if err != nil {
-		return Error(err, ErrorTypeDatabase)
+		return err
 	}
 
-	dtos := make([]*PizzaDto, len(pizzas))
-
-	for i, pizza := range pizzas {
-		dtos[i], err = pizza.ConvertToDto()
-		if err != nil {
-			return Error(err, ErrorTypeInternalServer)
-		}
+	dtos, err := c.service.Revisions(name)
+	if err != nil {
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, dtos)
 }
 
-func (c *controller) GetByName(ctx echo.Context) error {
+func (c *controller) Revert(ctx echo.Context) error {
 	name, err := checkNameInPath(ctx)
 	if err != nil {
 		return err
 	}
 
-	pizza, err := c.repository.FindByName(name)
-	if err != nil {
-		return Error(err, ErrorTypeDatabase)
-	} else if pizza == nil {
-		return Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
+	number, err := strconv.Atoi(ctx.Param(PathParamRevision))
+	if err != nil || number < 1 {
+		return Error(ErrInvalidRevision, ErrorTypeBadRequest)
 	}
 
-	dto, err := pizza.ConvertToDto()
+	dto, err := c.service.Revert(audit.OriginFromContext(ctx), name, number)
 	if err != nil {
-		return Error(err, ErrorTypeInternalServer)
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, dto)
@@ -128,28 +144,9 @@ func (c *controller) Update(ctx echo.Context) error {
 		return Error(err, ErrorTypeBinding)
 	}
 
-	if err := ctx.Validate(dto); err != nil {
-		return Error(err, ErrorTypeValidation)
-	}
-
-	pizza, _ := c.repository.FindByName(name)
-	if pizza == nil {
-		return Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
-	}
-
-	pizza, err = dto.ConvertToModel()
+	dto, err = c.service.Update(audit.OriginFromContext(ctx), name, dto)
 	if err != nil {
-		return Error(err, ErrorTypeInternalServer)
-	}
-
-	pizza, err = c.repository.Update(pizza)
-	if err != nil {
-		return Error(err, ErrorTypeDatabase)
-	}
-
-	dto, err = pizza.ConvertToDto()
-	if err != nil {
-		return Error(err, ErrorTypeInternalServer)
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, dto)
@@ -161,15 +158,8 @@ func (c *controller) Delete(ctx echo.Context) error {
 		return err
 	}
 
-	pizza, err := c.repository.FindByName(name)
-	if err != nil {
-		return Error(err, ErrorTypeDatabase)
-	} else if pizza == nil {
-		return Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
-	}
-
-	if err := c.repository.Delete(pizza.Name); err != nil {
-		return Error(err, ErrorTypeDatabase)
+	if err := c.service.Delete(audit.OriginFromContext(ctx), name); err != nil {
+		return err
 	}
 
 	return ctx.NoContent(http.StatusNoContent)
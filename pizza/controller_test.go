@@ -0,0 +1,127 @@
+package pizza
+
+import (
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newControllerContext returns an echo context of a request with the path parameters, given as name and value pairs.
+func newControllerContext(method, target, body string, params ...string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	rec := httptest.NewRecorder()
+	ctx := echo.New().NewContext(req, rec)
+	names, values := []string{}, []string{}
+	for i := 0; i+1 < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+	ctx.SetParamNames(names...)
+	ctx.SetParamValues(values...)
+	return ctx, rec
+}
+
+func errorType(t *testing.T, err error) string {
+	require.Error(t, err)
+	return err.(HasHTTPStatus).GetErrorType()
+}
+
+func TestControllerAddUsesInjectedService(t *testing.T) {
+	service := &MockService{}
+	created := &PizzaDto{Name: "margherita", Revision: 1}
+	service.On("Create", mock.AnythingOfType("audit.Origin"), &PizzaDto{Name: "margherita", Ingredient: []IngredientDto{{Name: "basil", Count: 1}}}).
+		Return(created, nil)
+	ctx, rec := newControllerContext(http.MethodPost, "/v1/pizza", `{"name":"margherita","ingredients":[{"name":"basil","count":1}]}`)
+
+	require.NoError(t, NewController(service).Add(ctx))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"revision":1`)
+	service.AssertExpectations(t)
+}
+
+func TestControllerAddRejectsMalformedBody(t *testing.T) {
+	service := &MockService{}
+	ctx, _ := newControllerContext(http.MethodPost, "/v1/pizza", `{"name":`)
+
+	assert.Equal(t, ErrorTypeBinding, errorType(t, NewController(service).Add(ctx)))
+	service.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestControllerReturnsServiceErrors(t *testing.T) {
+	service := &MockService{}
+	service.On("Get", "funghi").Return(nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, "funghi"))
+	ctx, _ := newControllerContext(http.MethodGet, "/v1/pizza/funghi", "", PathParamName, "funghi")
+
+	assert.Equal(t, ErrorTypeResourceNotFound, errorType(t, NewController(service).GetByName(ctx)))
+}
+
+func TestControllerGetByNameAsOf(t *testing.T) {
+	at := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	service := &MockService{}
+	service.On("GetAsOf", "margherita", at).Return(&PizzaDto{Name: "margherita", Revision: 2}, nil)
+	controller := NewController(service)
+
+	ctx, rec := newControllerContext(http.MethodGet, "/v1/pizza/margherita?asOf=2020-01-31T12:00:00Z", "", PathParamName, "margherita")
+	require.NoError(t, controller.GetByName(ctx))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	service.AssertExpectations(t)
+
+	ctx, _ = newControllerContext(http.MethodGet, "/v1/pizza/margherita?asOf=yesterday", "", PathParamName, "margherita")
+	assert.Equal(t, ErrorTypeBadRequest, errorType(t, controller.GetByName(ctx)))
+}
+
+func TestControllerRevertRejectsInvalidRevisions(t *testing.T) {
+	service := &MockService{}
+	controller := NewController(service)
+
+	for _, revision := range []string{"0", "-1", "first"} {
+		ctx, _ := newControllerContext(http.MethodPost, "/", "", PathParamName, "margherita", PathParamRevision, revision)
+		assert.Equal(t, ErrorTypeBadRequest, errorType(t, controller.Revert(ctx)), revision)
+	}
+	service.AssertNotCalled(t, "Revert", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestControllerUpdateAndDelete(t *testing.T) {
+	service := &MockService{}
+	service.On("Update", mock.AnythingOfType("audit.Origin"), "margherita", &PizzaDto{Name: "margherita", Ingredient: []IngredientDto{}}).
+		Return(&PizzaDto{Name: "margherita", Revision: 2}, nil)
+	service.On("Delete", mock.AnythingOfType("audit.Origin"), "margherita").Return(nil)
+	controller := NewController(service)
+
+	ctx, rec := newControllerContext(http.MethodPatch, "/", `{"name":"margherita","ingredients":[]}`, PathParamName, "margherita")
+	require.NoError(t, controller.Update(ctx))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	ctx, rec = newControllerContext(http.MethodDelete, "/", "", PathParamName, "margherita")
+	require.NoError(t, controller.Delete(ctx))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	ctx, _ = newControllerContext(http.MethodDelete, "/", "")
+	assert.Equal(t, ErrorTypeBadRequest, errorType(t, controller.Delete(ctx)))
+	service.AssertExpectations(t)
+}
+
+func TestControllerAddAcceptsLegacyIngredientKeys(t *testing.T) {
+	service := &MockService{}
+	service.On("Create", mock.AnythingOfType("audit.Origin"), &PizzaDto{Name: "margherita", Ingredient: []IngredientDto{{Name: "basil", Count: 2}}}).
+		Return(&PizzaDto{Name: "margherita", Revision: 1}, nil)
+	// ingredients were serialized with the field names before they got json tags
+	ctx, rec := newControllerContext(http.MethodPost, "/v1/pizza", `{"name":"margherita","ingredients":[{"Name":"basil","Count":2}]}`)
+
+	require.NoError(t, NewController(service).Add(ctx))
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	service.AssertExpectations(t)
+}
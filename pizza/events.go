@@ -0,0 +1,25 @@
+package pizza
+
+import (
+	"golang-microservice-template/events"
+)
+
+// Types of the pizza domain events
+const (
+	EventPizzaCreated = "PizzaCreated"
+	EventPizzaUpdated = "PizzaUpdated"
+	EventPizzaDeleted = "PizzaDeleted"
+)
+
+// EventTypes are the types of all pizza domain events.
+var EventTypes = []string{EventPizzaCreated, EventPizzaUpdated, EventPizzaDeleted}
+
+// newEvent creates the domain event of a revision. Its data is the pizza of the revision, none for deletions.
+func newEvent(eventType, name string, revision *Revision) (*events.Event, error) {
+	var data interface{}
+	if revision.Pizza != nil {
+		data = revision.Pizza.ConvertToDto()
+	}
+
+	return events.NewEvent(eventType, name, revision.Number, revision.CreatedAt, data)
+}
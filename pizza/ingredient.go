@@ -2,16 +2,14 @@ package pizza
 
 import (
 	"time"
-
-	. "golang-microservice-template/utils"
-
-	"gopkg.in/jeevatkm/go-model.v1"
 )
 
 // Ingredient represents the persisted pizza model.
 type Ingredient struct {
-	Name  string
-	Count int
+	Name      string
+	Count     int
+	CreatedAt time.Time
+	UpdatedAt *time.Time
 }
 
 // IngredientDto represents the pizza information that will be exposed from this service.
@@ -22,22 +20,29 @@ type IngredientDto struct {
 	UpdatedAt *time.Time `json:"updatedAt"`
 }
 
-// ConvertToDto converts an Ingredient model to a Ingredient dto.
-func (p *Ingredient) ConvertToDto() (*IngredientDto, error) {
-	dto := &IngredientDto{}
-	if errs := model.Copy(dto, p); len(errs) > 0 {
-		return nil, Error(errs[0], ErrorTypeInternalServer)
+// stampIngredients sets the timestamps of the ingredients of a saved pizza, the timestamps sent by clients are ignored.
+// Ingredients of the previous state keep their creation time and get an update time when their count changed,
+// other ingredients are created now.
+func stampIngredients(ingredients, previous []Ingredient, now time.Time) {
+	byName := make(map[string]*Ingredient, len(previous))
+	for i := range previous {
+		byName[previous[i].Name] = &previous[i]
 	}
 
-	return dto, nil
-}
-
-// ConvertToModel converts a Ingredient dto to a Ingredient model.
-func (dto *IngredientDto) ConvertToModel() (*Ingredient, error) {
-	m := &Ingredient{}
-	if errs := model.Copy(m, dto); len(errs) > 0 {
-		return nil, Error(errs[0], ErrorTypeInternalServer)
+	for i := range ingredients {
+		ingredient := &ingredients[i]
+		before, ok := byName[ingredient.Name]
+		if !ok {
+			ingredient.CreatedAt = now
+			ingredient.UpdatedAt = nil
+			continue
+		}
+
+		ingredient.CreatedAt = before.CreatedAt
+		ingredient.UpdatedAt = copyTime(before.UpdatedAt)
+		if ingredient.Count != before.Count {
+			updated := now
+			ingredient.UpdatedAt = &updated
+		}
 	}
-
-	return m, nil
 }
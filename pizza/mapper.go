@@ -0,0 +1,145 @@
+package pizza
+
+import (
+	"time"
+)
+
+// The mappers copy every field explicitly. Each struct is converted to a list of its fields below, the conversions
+// only compile while the lists match the structs. A field added to a model or dto fails the build until it is
+// added to the list and its mapping is reviewed.
+var (
+	_ = pizzaFields(Pizza{})
+	_ = pizzaDtoFields(PizzaDto{})
+	_ = ingredientFields(Ingredient{})
+	_ = ingredientDtoFields(IngredientDto{})
+	_ = revisionFields(Revision{})
+	_ = revisionDtoFields(RevisionDto{})
+)
+
+type pizzaFields struct {
+	ID         int // internal, not exposed
+	Name       string
+	Ingredient []Ingredient
+	Revision   int
+	UpdatedAt  time.Time
+}
+
+type pizzaDtoFields struct {
+	Name       string
+	Ingredient []IngredientDto
+	Revision   int
+	UpdatedAt  time.Time
+}
+
+type ingredientFields struct {
+	Name      string
+	Count     int
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type ingredientDtoFields struct {
+	Name      string
+	Count     int
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type revisionFields struct {
+	Number    int
+	CreatedAt time.Time
+	Deleted   bool
+	Pizza     *Pizza
+}
+
+type revisionDtoFields struct {
+	Revision  int
+	CreatedAt time.Time
+	Deleted   bool
+	Pizza     *PizzaDto
+}
+
+// ConvertToDto converts a Pizza model to a Pizza dto. The dto shares no data with the model.
+func (p *Pizza) ConvertToDto() *PizzaDto {
+	return &PizzaDto{
+		Name:       p.Name,
+		Ingredient: ingredientsToDto(p.Ingredient),
+		Revision:   p.Revision,
+		UpdatedAt:  p.UpdatedAt,
+	}
+}
+
+// ConvertToModel converts a Pizza dto to a Pizza model. The model shares no data with the dto.
+func (dto *PizzaDto) ConvertToModel() *Pizza {
+	return &Pizza{
+		Name:       dto.Name,
+		Ingredient: ingredientsToModel(dto.Ingredient),
+		Revision:   dto.Revision,
+		UpdatedAt:  dto.UpdatedAt,
+	}
+}
+
+// ConvertToDto converts an Ingredient model to an Ingredient dto. The dto shares no data with the model.
+func (i *Ingredient) ConvertToDto() *IngredientDto {
+	return &IngredientDto{
+		Name:      i.Name,
+		Count:     i.Count,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: copyTime(i.UpdatedAt),
+	}
+}
+
+// ConvertToModel converts an Ingredient dto to an Ingredient model. The model shares no data with the dto.
+func (dto *IngredientDto) ConvertToModel() *Ingredient {
+	return &Ingredient{
+		Name:      dto.Name,
+		Count:     dto.Count,
+		CreatedAt: dto.CreatedAt,
+		UpdatedAt: copyTime(dto.UpdatedAt),
+	}
+}
+
+// ingredientsToDto converts Ingredient models to Ingredient dtos, nil stays nil.
+func ingredientsToDto(ingredients []Ingredient) []IngredientDto {
+	if ingredients == nil {
+		return nil
+	}
+	dtos := make([]IngredientDto, len(ingredients))
+	for i := range ingredients {
+		dtos[i] = *ingredients[i].ConvertToDto()
+	}
+	return dtos
+}
+
+// ingredientsToModel converts Ingredient dtos to Ingredient models, nil stays nil.
+func ingredientsToModel(dtos []IngredientDto) []Ingredient {
+	if dtos == nil {
+		return nil
+	}
+	ingredients := make([]Ingredient, len(dtos))
+	for i := range dtos {
+		ingredients[i] = *dtos[i].ConvertToModel()
+	}
+	return ingredients
+}
+
+// copyIngredients returns a copy of the ingredients that shares no data with them, nil stays nil.
+func copyIngredients(ingredients []Ingredient) []Ingredient {
+	if ingredients == nil {
+		return nil
+	}
+	copied := append(make([]Ingredient, 0, len(ingredients)), ingredients...)
+	for i := range copied {
+		copied[i].UpdatedAt = copyTime(copied[i].UpdatedAt)
+	}
+	return copied
+}
+
+// copyTime returns a copy of a time, nil stays nil.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
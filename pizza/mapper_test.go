@@ -0,0 +1,221 @@
+package pizza
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/jeevatkm/go-model.v1"
+)
+
+// fill sets every field of the struct v points to, recursively, to a value that is not zero.
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fill(v.Field(i))
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int:
+		v.SetInt(7)
+	case reflect.Bool:
+		v.SetBool(true)
+	default:
+		panic("fill does not support " + v.Kind().String())
+	}
+}
+
+// zeroFields returns the paths of all fields of v that are zero, recursively.
+func zeroFields(v reflect.Value, path string) []string {
+	if v.IsZero() {
+		return []string{path}
+	}
+	zero := []string{}
+	switch v.Kind() {
+	case reflect.Ptr:
+		zero = append(zero, zeroFields(v.Elem(), path)...)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			return zero
+		}
+		for i := 0; i < v.NumField(); i++ {
+			zero = append(zero, zeroFields(v.Field(i), path+"."+v.Type().Field(i).Name)...)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			zero = append(zero, zeroFields(v.Index(i), path+"[]")...)
+		}
+	}
+	return zero
+}
+
+func TestMappersMapEveryField(t *testing.T) {
+	p, i, r := &Pizza{}, &Ingredient{}, &Revision{}
+	pDto, iDto, rDto := &PizzaDto{}, &IngredientDto{}, &RevisionDto{}
+	for _, v := range []interface{}{p, i, r, pDto, iDto, rDto} {
+		fill(reflect.ValueOf(v).Elem())
+	}
+
+	tests := []struct {
+		name      string
+		converted interface{}
+		unmapped  []string
+	}{
+		{"Pizza.ConvertToDto", p.ConvertToDto(), nil},
+		{"PizzaDto.ConvertToModel", pDto.ConvertToModel(), []string{"Pizza.ID"}},
+		{"Ingredient.ConvertToDto", i.ConvertToDto(), nil},
+		{"IngredientDto.ConvertToModel", iDto.ConvertToModel(), nil},
+		{"Revision.ConvertToDto", r.ConvertToDto(), nil},
+		{"RevisionDto.ConvertToModel", rDto.ConvertToModel(), []string{"Revision.Pizza.ID"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v := reflect.ValueOf(test.converted).Elem()
+			assert.ElementsMatch(t, test.unmapped, zeroFields(v, v.Type().Name()))
+		})
+	}
+}
+
+func TestMappersShareNoData(t *testing.T) {
+	updatedAt := time.Now()
+	p := &Pizza{Name: "margherita", Ingredient: []Ingredient{{Name: "basil", Count: 1, UpdatedAt: &updatedAt}}}
+
+	dto := p.ConvertToDto()
+	dto.Ingredient[0].Name = "oregano"
+	*dto.Ingredient[0].UpdatedAt = time.Time{}
+	back := dto.ConvertToModel()
+	back.Ingredient[0].Count = 2
+	*back.Ingredient[0].UpdatedAt = time.Unix(0, 0)
+
+	assert.Equal(t, "basil", p.Ingredient[0].Name)
+	assert.Equal(t, 1, p.Ingredient[0].Count)
+	assert.Equal(t, updatedAt, *p.Ingredient[0].UpdatedAt)
+	assert.Equal(t, time.Time{}, *dto.Ingredient[0].UpdatedAt)
+}
+
+func TestMappersKeepNilIngredients(t *testing.T) {
+	assert.Nil(t, (&Pizza{}).ConvertToDto().Ingredient)
+	assert.Nil(t, (&PizzaDto{}).ConvertToModel().Ingredient)
+	assert.NotNil(t, (&Pizza{Ingredient: []Ingredient{}}).ConvertToDto().Ingredient)
+}
+
+// sinks keep the compiler from optimizing away the conversions of the benchmarks
+var (
+	pizzaSink         *Pizza
+	pizzaDtoSink      *PizzaDto
+	ingredientDtoSink *IngredientDto
+)
+
+func newBenchmarkPizza() *Pizza {
+	updatedAt := time.Now()
+	p := &Pizza{Name: "margherita", Revision: 3, UpdatedAt: updatedAt}
+	for _, name := range []string{"tomato", "mozzarella", "basil", "olive oil", "oregano"} {
+		p.Ingredient = append(p.Ingredient, Ingredient{Name: name, Count: 1, CreatedAt: updatedAt, UpdatedAt: &updatedAt})
+	}
+	return p
+}
+
+// The go-model benchmarks measure the reflection-based copy the mappers replaced. go-model cannot copy slices of
+// different types, so it is given conversions that copy the ingredients one by one.
+func init() {
+	model.AddConversion((*[]Ingredient)(nil), (*[]IngredientDto)(nil), func(in reflect.Value) (reflect.Value, error) {
+		return goModelCopySlice(in, reflect.TypeOf([]IngredientDto{}))
+	})
+	model.AddConversion((*[]IngredientDto)(nil), (*[]Ingredient)(nil), func(in reflect.Value) (reflect.Value, error) {
+		return goModelCopySlice(in, reflect.TypeOf([]Ingredient{}))
+	})
+}
+
+func goModelCopySlice(in reflect.Value, to reflect.Type) (reflect.Value, error) {
+	out := reflect.MakeSlice(to, in.Len(), in.Len())
+	for i := 0; i < in.Len(); i++ {
+		if errs := model.Copy(out.Index(i).Addr().Interface(), in.Index(i).Addr().Interface()); len(errs) > 0 {
+			return out, errs[0]
+		}
+	}
+	return out, nil
+}
+
+func BenchmarkPizzaConvertToDto(b *testing.B) {
+	p := newBenchmarkPizza()
+
+	b.Run("mapper", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			pizzaDtoSink = p.ConvertToDto()
+		}
+	})
+	b.Run("go-model", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			dto := &PizzaDto{}
+			if errs := model.Copy(dto, p); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	})
+}
+
+func BenchmarkPizzaDtoConvertToModel(b *testing.B) {
+	dto := newBenchmarkPizza().ConvertToDto()
+
+	b.Run("mapper", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			pizzaSink = dto.ConvertToModel()
+		}
+	})
+	b.Run("go-model", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			p := &Pizza{}
+			if errs := model.Copy(p, dto); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	})
+}
+
+func BenchmarkIngredientConvertToDto(b *testing.B) {
+	i := &newBenchmarkPizza().Ingredient[0]
+
+	b.Run("mapper", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			ingredientDtoSink = i.ConvertToDto()
+		}
+	})
+	b.Run("go-model", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			dto := &IngredientDto{}
+			if errs := model.Copy(dto, i); len(errs) > 0 {
+				b.Fatal(errs)
+			}
+		}
+	})
+}
+
+func TestGoModelBenchmarkCopiesLikeMapper(t *testing.T) {
+	p := newBenchmarkPizza()
+	dto := &PizzaDto{}
+	require.Empty(t, model.Copy(dto, p))
+	assert.Equal(t, p.ConvertToDto(), dto)
+
+	back := &Pizza{}
+	require.Empty(t, model.Copy(back, dto))
+	assert.Equal(t, dto.ConvertToModel(), back)
+}
@@ -66,6 +66,34 @@ func (_m *MockController) GetByName(_a0 echo.Context) error {
 	return r0
 }
 
+// GetRevisions provides a mock function with given fields: _a0
+func (_m *MockController) GetRevisions(_a0 echo.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Revert provides a mock function with given fields: _a0
+func (_m *MockController) Revert(_a0 echo.Context) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(echo.Context) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: _a0
 func (_m *MockController) Update(_a0 echo.Context) error {
 	ret := _m.Called(_a0)
@@ -2,6 +2,8 @@
 
 package pizza
 
+import events "golang-microservice-template/events"
+import time "time"
 import mock "github.com/stretchr/testify/mock"
 
 // MockRepository is an autogenerated mock type for the Repository type
@@ -9,6 +11,20 @@ type MockRepository struct {
 	mock.Mock
 }
 
+// Close provides a mock function with given fields:
+func (_m *MockRepository) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: name
 func (_m *MockRepository) Delete(name string) error {
 	ret := _m.Called(name)
@@ -46,6 +62,29 @@ func (_m *MockRepository) FindAll() ([]*Pizza, error) {
 	return r0, r1
 }
 
+// FindAsOf provides a mock function with given fields: name, at
+func (_m *MockRepository) FindAsOf(name string, at time.Time) (*Pizza, error) {
+	ret := _m.Called(name, at)
+
+	var r0 *Pizza
+	if rf, ok := ret.Get(0).(func(string, time.Time) *Pizza); ok {
+		r0 = rf(name, at)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Pizza)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, time.Time) error); ok {
+		r1 = rf(name, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByName provides a mock function with given fields: name
 func (_m *MockRepository) FindByName(name string) (*Pizza, error) {
 	ret := _m.Called(name)
@@ -69,6 +108,57 @@ func (_m *MockRepository) FindByName(name string) (*Pizza, error) {
 	return r0, r1
 }
 
+// FindRevisions provides a mock function with given fields: name
+func (_m *MockRepository) FindRevisions(name string) ([]*Revision, error) {
+	ret := _m.Called(name)
+
+	var r0 []*Revision
+	if rf, ok := ret.Get(0).(func(string) []*Revision); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*Revision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Outbox provides a mock function with given fields:
+func (_m *MockRepository) Outbox() events.Outbox {
+	ret := _m.Called()
+
+	var r0 events.Outbox
+	if rf, ok := ret.Get(0).(func() events.Outbox); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(events.Outbox)
+	}
+
+	return r0
+}
+
+// Ping provides a mock function with given fields:
+func (_m *MockRepository) Ping() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Save provides a mock function with given fields: pizza
 func (_m *MockRepository) Save(pizza *Pizza) (*Pizza, error) {
 	ret := _m.Called(pizza)
@@ -1,9 +1,7 @@
 package pizza
 
 import (
-	. "golang-microservice-template/utils"
-
-	"gopkg.in/jeevatkm/go-model.v1"
+	"time"
 )
 
 // Pizza represents the persisted pizza model.
@@ -11,30 +9,22 @@ type Pizza struct {
 	ID         int
 	Name       string
 	Ingredient []Ingredient
+	// Revision is the number of the latest revision, set by the repository.
+	Revision  int
+	UpdatedAt time.Time
 }
 
 // PizzaDto represents the pizza information that will be exposed from this service.
 type PizzaDto struct {
-	Name       string       `json:"name" validate:"required,max=255"`
-	Ingredient []Ingredient `json:"ingredients"`
+	Name       string          `json:"name" validate:"required,max=255"`
+	Ingredient []IngredientDto `json:"ingredients" validate:"dive"`
+	Revision   int             `json:"revision"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
 }
 
-// ConvertToDto converts a Pizza model to a Pizza dto.
-func (p *Pizza) ConvertToDto() (*PizzaDto, error) {
-	dto := &PizzaDto{}
-	if errs := model.Copy(dto, p); len(errs) > 0 {
-		return nil, Error(errs[0], ErrorTypeInternalServer)
-	}
-
-	return dto, nil
-}
-
-// ConvertToModel converts a Pizza dto to a Pizza model.
-func (dto *PizzaDto) ConvertToModel() (*Pizza, error) {
-	m := &Pizza{}
-	if errs := model.Copy(m, dto); len(errs) > 0 {
-		return nil, Error(errs[0], ErrorTypeInternalServer)
-	}
-
-	return m, nil
+// copy returns a copy of the pizza that shares no data with it.
+func (p *Pizza) copy() *Pizza {
+	c := *p
+	c.Ingredient = copyIngredients(p.Ingredient)
+	return &c
 }
@@ -1,8 +1,11 @@
 package pizza
 
 import (
+	"golang-microservice-template/events"
 	. "golang-microservice-template/utils"
+	"sort"
 	"sync"
+	"time"
 )
 
 // errors
@@ -12,6 +15,7 @@ var (
 )
 
 // Repository used to persist pizza data.
+// Pizzas and revisions are passed in and returned as copies, callers may change them without affecting the storage.
 type Repository interface {
 	// FindAll returns a list of all persisted pizzas.
 	FindAll() ([]*Pizza, error)
@@ -21,19 +25,37 @@ type Repository interface {
 	Update(pizza *Pizza) (*Pizza, error)
 	// Save will persist a pizza. Name must be unique.
 	Save(pizza *Pizza) (*Pizza, error)
-	// Delete permanently removes a pizza.
+	// Delete permanently removes a pizza. Its revisions are kept.
 	Delete(name string) error
+	// FindRevisions returns all revisions of a pizza name, oldest first.
+	FindRevisions(name string) ([]*Revision, error)
+	// FindAsOf finds the state of a pizza at the given time, nil if it did not exist then.
+	FindAsOf(name string, at time.Time) (*Pizza, error)
+	// Outbox returns the domain events of all changes that are not published yet.
+	// Events are added in the same transaction as the change that caused them.
+	Outbox() events.Outbox
+	// Ping checks that the storage is reachable.
+	Ping() error
+	// Close releases all resources of the repository.
+	Close() error
 }
 
 type repository struct {
-	pizzas map[string]*Pizza
+	pizzas    map[string]*Pizza
+	revisions map[string][]*Revision
+	outbox    events.Outbox
+	clock     Clock
 	sync.RWMutex
 }
 
 // NewRepository creates a new pizza repository with pre-defined values.
-func NewRepository() Repository {
+// Revisions are timestamped with the given clock.
+func NewRepository(clock Clock) Repository {
 	return &repository{
-		pizzas: make(map[string]*Pizza),
+		pizzas:    make(map[string]*Pizza),
+		revisions: make(map[string][]*Revision),
+		outbox:    events.NewMemoryOutbox(events.DefaultOutboxCapacity),
+		clock:     clock,
 	}
 }
 
@@ -41,9 +63,9 @@ func (r *repository) FindAll() ([]*Pizza, error) {
 	r.RLock()
 	defer r.RUnlock()
 
-	list := []*Pizza{}
+	list := make([]*Pizza, 0, len(r.pizzas))
 	for _, v := range r.pizzas {
-		list = append(list, v)
+		list = append(list, v.copy())
 	}
 
 	return list, nil
@@ -59,7 +81,7 @@ func (r *repository) FindByName(name string) (*Pizza, error) {
 		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
 	}
 
-	return match, nil
+	return match.copy(), nil
 }
 
 func (r *repository) Update(pizza *Pizza) (*Pizza, error) {
@@ -71,9 +93,13 @@ func (r *repository) Update(pizza *Pizza) (*Pizza, error) {
 		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, pizza.Name)
 	}
 
-	match.Ingredient = pizza.Ingredient
+	updated := match.copy()
+	updated.Ingredient = copyIngredients(pizza.Ingredient)
+	if err := r.commit(pizza.Name, updated, EventPizzaUpdated); err != nil {
+		return nil, err
+	}
 
-	return match, nil
+	return updated.copy(), nil
 }
 
 func (r *repository) Save(pizza *Pizza) (*Pizza, error) {
@@ -85,16 +111,91 @@ func (r *repository) Save(pizza *Pizza) (*Pizza, error) {
 		return nil, Errorf(ErrorTypeConflict, ErrPizzaNameTaken, pizza.Name)
 	}
 
-	r.pizzas[pizza.Name] = pizza
+	saved := pizza.copy()
+	if err := r.commit(pizza.Name, saved, EventPizzaCreated); err != nil {
+		return nil, err
+	}
 
-	return pizza, nil
+	return saved.copy(), nil
 }
 
 func (r *repository) Delete(name string) error {
 	r.Lock()
 	defer r.Unlock()
 
-	delete(r.pizzas, name)
+	if _, ok := r.pizzas[name]; !ok {
+		return nil
+	}
+
+	return r.commit(name, nil, EventPizzaDeleted)
+}
+
+func (r *repository) FindRevisions(name string) ([]*Revision, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	revisions := make([]*Revision, 0, len(r.revisions[name]))
+	for _, revision := range r.revisions[name] {
+		revisions = append(revisions, revision.copy())
+	}
+
+	return revisions, nil
+}
+
+func (r *repository) FindAsOf(name string, at time.Time) (*Pizza, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	revisions := r.revisions[name]
+	i := sort.Search(len(revisions), func(i int) bool { return revisions[i].CreatedAt.After(at) })
+	if i == 0 || revisions[i-1].Deleted {
+		return nil, nil
+	}
+
+	return revisions[i-1].Pizza.copy(), nil
+}
+
+func (r *repository) Outbox() events.Outbox {
+	return r.outbox
+}
+
+// commit stores the next revision of a pizza, nil for a deletion, and adds the domain event of the change
+// to the outbox. Nothing is changed if the event cannot be stored. The caller must hold the write lock
+// and must not keep a reference to the pizza, it is stored as is.
+func (r *repository) commit(name string, pizza *Pizza, eventType string) error {
+	revision := &Revision{
+		Number:    len(r.revisions[name]) + 1,
+		CreatedAt: r.clock.Now(),
+		Deleted:   pizza == nil,
+	}
+	if pizza != nil {
+		pizza.Revision = revision.Number
+		pizza.UpdatedAt = revision.CreatedAt
+		revision.Pizza = pizza.copy()
+	}
+
+	event, err := newEvent(eventType, name, revision)
+	if err != nil {
+		return err
+	}
+	if err := r.outbox.Add(event); err != nil {
+		return err
+	}
+
+	r.revisions[name] = append(r.revisions[name], revision)
+	if pizza != nil {
+		r.pizzas[name] = pizza
+	} else {
+		delete(r.pizzas, name)
+	}
+
+	return nil
+}
+
+func (r *repository) Ping() error {
+	return nil
+}
 
+func (r *repository) Close() error {
 	return nil
 }
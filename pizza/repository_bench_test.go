@@ -0,0 +1,265 @@
+package pizza
+
+import (
+	"fmt"
+	"golang-microservice-template/events"
+	. "golang-microservice-template/utils"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// benchmarkPizzas is the number of pizzas stored before the read benchmarks run.
+const benchmarkPizzas = 100
+
+// drainInterval is the number of writes after which the benchmarks empty the outbox, it must stay below its capacity.
+const drainInterval = 1000
+
+// repositoryConstructor creates an in-memory repository.
+type repositoryConstructor struct {
+	name string
+	new  func(clock Clock) Repository
+}
+
+// repositoryConstructors are the in-memory repositories the benchmarks compare.
+var repositoryConstructors = []repositoryConstructor{
+	{"map", NewRepository},
+	{"sharded", func(clock Clock) Repository { return NewShardedRepository(clock, DefaultShards) }},
+}
+
+// drainOutbox marks all pending events published, so benchmarks can write more often than the outbox holds events.
+// It may be called from any goroutine, failures are reported with b.Error.
+func drainOutbox(b *testing.B, outbox events.Outbox) {
+	for {
+		pending, err := outbox.Pending(SystemClock().Now(), drainInterval)
+		if err != nil {
+			b.Error(err)
+			return
+		}
+		if len(pending) == 0 {
+			return
+		}
+		for _, entry := range pending {
+			if err := outbox.MarkPublished(entry.Event.ID); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	}
+}
+
+// newNamedPizza returns the benchmark pizza with the given name.
+func newNamedPizza(name string) *Pizza {
+	p := newBenchmarkPizza()
+	p.Name = name
+	return p
+}
+
+func pizzaName(i int) string {
+	return fmt.Sprintf("pizza-%d", i)
+}
+
+// benchmarkRepositories runs the benchmark for every repository.
+func benchmarkRepositories(b *testing.B, benchmark func(b *testing.B, constructor repositoryConstructor)) {
+	for _, constructor := range repositoryConstructors {
+		constructor := constructor
+		b.Run(constructor.name, func(b *testing.B) {
+			benchmark(b, constructor)
+		})
+	}
+}
+
+// newFilledRepository creates a repository with n pizzas and no pending events.
+func newFilledRepository(b *testing.B, constructor repositoryConstructor, n int) Repository {
+	r := constructor.new(SystemClock())
+	for i := 0; i < n; i++ {
+		if _, err := r.Save(newNamedPizza(pizzaName(i))); err != nil {
+			b.Fatal(err)
+		}
+		if (i+1)%drainInterval == 0 {
+			drainOutbox(b, r.Outbox())
+		}
+	}
+	drainOutbox(b, r.Outbox())
+	return r
+}
+
+func BenchmarkRepositorySave(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, 0)
+		pizzas := make([]*Pizza, b.N)
+		for i := range pizzas {
+			pizzas[i] = newNamedPizza(pizzaName(i))
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.Save(pizzas[i]); err != nil {
+				b.Fatal(err)
+			}
+			if (i+1)%drainInterval == 0 {
+				b.StopTimer()
+				drainOutbox(b, r.Outbox())
+				b.StartTimer()
+			}
+		}
+	})
+}
+
+func BenchmarkRepositoryFindAll(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, benchmarkPizzas)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.FindAll(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRepositoryFindByName(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, benchmarkPizzas)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.FindByName(pizzaName(i % benchmarkPizzas)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRepositoryUpdate(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, benchmarkPizzas)
+		pizzas := make([]*Pizza, benchmarkPizzas)
+		for i := range pizzas {
+			pizzas[i] = newNamedPizza(pizzaName(i))
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := r.Update(pizzas[i%benchmarkPizzas]); err != nil {
+				b.Fatal(err)
+			}
+			if (i+1)%drainInterval == 0 {
+				b.StopTimer()
+				drainOutbox(b, r.Outbox())
+				b.StartTimer()
+			}
+		}
+	})
+}
+
+func BenchmarkRepositoryDelete(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, b.N)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := r.Delete(pizzaName(i)); err != nil {
+				b.Fatal(err)
+			}
+			if (i+1)%drainInterval == 0 {
+				b.StopTimer()
+				drainOutbox(b, r.Outbox())
+				b.StartTimer()
+			}
+		}
+	})
+}
+
+// drainContinuously empties the outbox until the returned function is called, for benchmarks whose goroutines
+// cannot stop the timer. The drain competes with the benchmark only for the lock of the outbox.
+func drainContinuously(b *testing.B, outbox events.Outbox) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				drainOutbox(b, outbox)
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// BenchmarkRepositoryParallelUpdate updates different pizzas from all goroutines, the sharded repository
+// lets updates of different shards run at the same time. Compare it with -cpu 1,4,8.
+func BenchmarkRepositoryParallelUpdate(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, benchmarkPizzas)
+		pizzas := make([]*Pizza, benchmarkPizzas)
+		for i := range pizzas {
+			pizzas[i] = newNamedPizza(pizzaName(i))
+		}
+		stop := drainContinuously(b, r.Outbox())
+		defer stop()
+		next := int64(0)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				i := atomic.AddInt64(&next, 1)
+				if _, err := r.Update(pizzas[i%benchmarkPizzas]); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkRepositoryParallelMixed reads, lists and updates from all goroutines, one in ten operations is an update
+// and one in a hundred lists all pizzas.
+func BenchmarkRepositoryParallelMixed(b *testing.B) {
+	benchmarkRepositories(b, func(b *testing.B, constructor repositoryConstructor) {
+		r := newFilledRepository(b, constructor, benchmarkPizzas)
+		pizzas := make([]*Pizza, benchmarkPizzas)
+		for i := range pizzas {
+			pizzas[i] = newNamedPizza(pizzaName(i))
+		}
+		stop := drainContinuously(b, r.Outbox())
+		defer stop()
+		next := int64(0)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				i := atomic.AddInt64(&next, 1)
+				var err error
+				switch {
+				case i%100 == 0:
+					_, err = r.FindAll()
+				case i%10 == 0:
+					_, err = r.Update(pizzas[i%benchmarkPizzas])
+				default:
+					_, err = r.FindByName(pizzaName(int(i % benchmarkPizzas)))
+				}
+				if err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package pizza
+
+import (
+	. "golang-microservice-template/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mutate changes every field of a pizza that the repository must not share with its callers.
+func mutate(p *Pizza) {
+	if p == nil {
+		return
+	}
+	p.Name = "mutated"
+	p.Revision = -1
+	for i := range p.Ingredient {
+		p.Ingredient[i].Name = "mutated"
+		p.Ingredient[i].Count = -1
+		if p.Ingredient[i].UpdatedAt != nil {
+			*p.Ingredient[i].UpdatedAt = time.Time{}
+		}
+	}
+	p.Ingredient = append(p.Ingredient, Ingredient{Name: "appended"})
+}
+
+// assertUnchanged fails if a pizza returned by the repository carries a change of mutate.
+func assertUnchanged(t *testing.T, p *Pizza) {
+	assert.Equal(t, "margherita", p.Name)
+	assert.True(t, p.Revision > 0, "revision %d", p.Revision)
+	assert.Contains(t, []int{4, 5}, len(p.Ingredient))
+	for _, ingredient := range p.Ingredient {
+		assert.NotEqual(t, "mutated", ingredient.Name)
+		assert.NotEqual(t, "appended", ingredient.Name)
+		assert.Equal(t, 1, ingredient.Count)
+		if assert.NotNil(t, ingredient.UpdatedAt) {
+			assert.False(t, ingredient.UpdatedAt.IsZero())
+		}
+	}
+}
+
+// TestRepositoriesReturnCopies mutates the pizzas passed to and returned by the repository while other goroutines
+// read and update the same pizza. Run it with -race.
+func TestRepositoriesReturnCopies(t *testing.T) {
+	const goroutines, iterations = 4, 50
+
+	for _, constructor := range testedRepositories {
+		constructor := constructor
+		t.Run(constructor.name, func(t *testing.T) {
+			r := constructor.new(SystemClock())
+			saved := newNamedPizza("margherita")
+			_, err := r.Save(saved)
+			require.NoError(t, err)
+			mutate(saved)
+
+			wg := sync.WaitGroup{}
+			run := func(f func(i int)) {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						f(i)
+					}
+				}()
+			}
+			for g := 0; g < goroutines; g++ {
+				run(func(int) {
+					p, err := r.FindByName("margherita")
+					if !assert.NoError(t, err) {
+						return
+					}
+					assertUnchanged(t, p)
+					mutate(p)
+				})
+				run(func(int) {
+					pizzas, err := r.FindAll()
+					if !assert.NoError(t, err) || !assert.Len(t, pizzas, 1) {
+						return
+					}
+					assertUnchanged(t, pizzas[0])
+					mutate(pizzas[0])
+				})
+				run(func(i int) {
+					// every other update removes the last ingredient
+					update := newNamedPizza("margherita")
+					if i%2 == 0 {
+						update.Ingredient = update.Ingredient[:4]
+					}
+					updated, err := r.Update(update)
+					if !assert.NoError(t, err) {
+						return
+					}
+					mutate(update)
+					assertUnchanged(t, updated)
+					mutate(updated)
+				})
+				run(func(int) {
+					revisions, err := r.FindRevisions("margherita")
+					assert.NoError(t, err)
+					for _, revision := range revisions {
+						assertUnchanged(t, revision.Pizza)
+						mutate(revision.Pizza)
+					}
+				})
+				run(func(int) {
+					p, err := r.FindAsOf("margherita", time.Now())
+					if !assert.NoError(t, err) {
+						return
+					}
+					assertUnchanged(t, p)
+					mutate(p)
+				})
+			}
+			wg.Wait()
+
+			p, err := r.FindByName("margherita")
+			require.NoError(t, err)
+			assertUnchanged(t, p)
+			assert.Equal(t, goroutines*iterations+1, p.Revision)
+			revisions, err := r.FindRevisions("margherita")
+			require.NoError(t, err)
+			assert.Len(t, revisions, goroutines*iterations+1)
+			for _, revision := range revisions {
+				assertUnchanged(t, revision.Pizza)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package pizza
+
+import (
+	"time"
+)
+
+// Revision is an immutable snapshot of a pizza, created by every change.
+type Revision struct {
+	// Number counts the revisions of a pizza name, starting with 1.
+	Number    int
+	CreatedAt time.Time
+	// Deleted marks the revision that removed the pizza, it has no snapshot.
+	Deleted bool
+	Pizza   *Pizza
+}
+
+// copy returns a copy of the revision that shares no data with it.
+func (r *Revision) copy() *Revision {
+	c := *r
+	if r.Pizza != nil {
+		c.Pizza = r.Pizza.copy()
+	}
+	return &c
+}
+
+// RevisionDto represents the revision information that will be exposed from this service.
+type RevisionDto struct {
+	Revision  int       `json:"revision"`
+	CreatedAt time.Time `json:"createdAt"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	Pizza     *PizzaDto `json:"pizza,omitempty"`
+}
+
+// ConvertToDto converts a Revision model to a Revision dto.
+func (r *Revision) ConvertToDto() *RevisionDto {
+	dto := &RevisionDto{
+		Revision:  r.Number,
+		CreatedAt: r.CreatedAt,
+		Deleted:   r.Deleted,
+	}
+
+	if r.Pizza != nil {
+		dto.Pizza = r.Pizza.ConvertToDto()
+	}
+
+	return dto
+}
+
+// ConvertToModel converts a Revision dto to a Revision model, e.g. of a snapshot. The model shares no data with the dto.
+func (dto *RevisionDto) ConvertToModel() *Revision {
+	revision := &Revision{
+		Number:    dto.Revision,
+		CreatedAt: dto.CreatedAt,
+		Deleted:   dto.Deleted,
+	}
+
+	if dto.Pizza != nil {
+		revision.Pizza = dto.Pizza.ConvertToModel()
+	}
+
+	return revision
+}
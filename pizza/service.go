@@ -0,0 +1,427 @@
+package pizza
+
+import (
+	"context"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/cache"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"time"
+
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	// AuditResource is the resource of the audit events of pizza mutations.
+	AuditResource = "pizza"
+	// CacheKeyAll is the cache key of the list of all pizzas.
+	CacheKeyAll = "pizza:all"
+	// AuditNameAll is the name of audit events that affect all pizzas.
+	AuditNameAll = "*"
+)
+
+// CacheKey returns the cache key of a pizza.
+func CacheKey(name string) string {
+	return "pizza:name:" + name
+}
+
+// errors
+var (
+	ErrTooManyIngredients = "pizza must not have more than %d ingredients"
+	ErrPizzaNotFoundAsOf  = "pizza %s did not exist at %s"
+	ErrRevisionNotFound   = "revision %d of pizza %s not found"
+	ErrRevertDeletion     = "revision %d of pizza %s is a deletion and cannot be restored"
+	ErrReadOnly           = "pizzas cannot be changed at the moment, the service is read-only"
+)
+
+// Service implements the pizza use cases independent of the transport, so REST and other
+// APIs share the same validation and errors. All errors are HasHTTPStatus errors.
+type Service interface {
+	// Create validates and persists a new pizza.
+	Create(origin audit.Origin, dto *PizzaDto) (*PizzaDto, error)
+	// GetAll returns all pizzas.
+	GetAll() ([]*PizzaDto, error)
+	// Get returns a pizza by name.
+	Get(name string) (*PizzaDto, error)
+	// GetAsOf returns the state of a pizza at the given time.
+	GetAsOf(name string, at time.Time) (*PizzaDto, error)
+	// Update validates and replaces the ingredients of an existing pizza. The name of the dto is set to name.
+	Update(origin audit.Origin, name string, dto *PizzaDto) (*PizzaDto, error)
+	// Delete removes an existing pizza.
+	Delete(origin audit.Origin, name string) error
+	// Revisions returns all revisions of a pizza, oldest first.
+	Revisions(name string) ([]*RevisionDto, error)
+	// Revert restores an older revision of a pizza as a new revision, deleted pizzas are restored.
+	// The revision is validated like an update, e.g. against the current maximum number of ingredients.
+	Revert(origin audit.Origin, name string, revision int) (*PizzaDto, error)
+	// Snapshot returns all revisions of all pizzas, if the repository is a Snapshotter.
+	Snapshot() (*Snapshot, error)
+	// Restore replaces all pizzas and revisions with the snapshot, if the repository is a Snapshotter.
+	// Every pizza of the snapshot is validated like a created one, nothing is replaced if one is invalid.
+	Restore(origin audit.Origin, snapshot *Snapshot) error
+}
+
+type service struct {
+	repository Repository
+	clock      Clock
+	log        LogWriter
+	config     config.PizzaConfig
+	audit      audit.Sink
+	cache      cache.Cache
+	readOnly   func() bool
+	validate   *validator.Validate
+}
+
+// ServiceOption sets a dependency of the service.
+type ServiceOption func(*service)
+
+// WithRepository sets the repository that persists the pizzas.
+func WithRepository(repository Repository) ServiceOption {
+	return func(s *service) {
+		s.repository = repository
+	}
+}
+
+// WithClock sets the clock that provides the current time.
+func WithClock(clock Clock) ServiceOption {
+	return func(s *service) {
+		s.clock = clock
+	}
+}
+
+// WithLogger sets the logger, defaults to the global Log instance.
+func WithLogger(log LogWriter) ServiceOption {
+	return func(s *service) {
+		s.log = log
+	}
+}
+
+// WithConfig sets the service configuration.
+func WithConfig(cfg config.PizzaConfig) ServiceOption {
+	return func(s *service) {
+		s.config = cfg
+	}
+}
+
+// WithAuditSink sets the sink that records every successful mutation. Mutations are not audited if unset.
+func WithAuditSink(sink audit.Sink) ServiceOption {
+	return func(s *service) {
+		s.audit = sink
+	}
+}
+
+// WithCache sets the cache of the read routes, the keys of changed pizzas are deleted after every mutation.
+func WithCache(c cache.Cache) ServiceOption {
+	return func(s *service) {
+		s.cache = c
+	}
+}
+
+// WithReadOnly sets a function that is asked before every change of a pizza. While it returns true,
+// changes fail with 503, e.g. for the reloadable feature flag config.FeatureReadOnly.
+func WithReadOnly(readOnly func() bool) ServiceOption {
+	return func(s *service) {
+		s.readOnly = readOnly
+	}
+}
+
+// NewService creates a new Service. Dependencies that are not given as option
+// are set to pre-defined values, e.g. a new in-memory repository.
+func NewService(options ...ServiceOption) Service {
+	s := &service{}
+	for _, option := range options {
+		option(s)
+	}
+
+	if s.clock == nil {
+		s.clock = SystemClock()
+	}
+	if s.repository == nil {
+		s.repository = NewRepository(s.clock)
+	}
+	if s.log == nil {
+		s.log = Log
+	}
+	if s.readOnly == nil {
+		s.readOnly = func() bool { return false }
+	}
+	s.validate = validator.New()
+
+	return s
+}
+
+func (s *service) Create(origin audit.Origin, dto *PizzaDto) (*PizzaDto, error) {
+	if s.readOnly() {
+		return nil, Error(ErrReadOnly, ErrorTypeServiceUnavailable)
+	}
+	if err := s.check(dto); err != nil {
+		return nil, err
+	}
+
+	found, err := s.repository.FindByName(dto.Name)
+	if found != nil {
+		return nil, Errorf(ErrorTypeConflict, ErrPizzaNameTaken, dto.Name)
+	} else if err != nil {
+		s.log.Debug(err)
+	}
+
+	pizza := dto.ConvertToModel()
+	stampIngredients(pizza.Ingredient, nil, s.clock.Now())
+
+	entity, err := s.repository.Save(pizza)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	}
+	s.invalidate(entity.Name)
+
+	result := entity.ConvertToDto()
+	s.record(origin, audit.ActionCreate, result.Name, nil, result)
+
+	return result, nil
+}
+
+func (s *service) GetAll() ([]*PizzaDto, error) {
+	pizzas, err := s.repository.FindAll()
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	}
+
+	dtos := make([]*PizzaDto, len(pizzas))
+
+	for i, pizza := range pizzas {
+		dtos[i] = pizza.ConvertToDto()
+	}
+
+	return dtos, nil
+}
+
+func (s *service) Get(name string) (*PizzaDto, error) {
+	pizza, err := s.repository.FindByName(name)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	} else if pizza == nil {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
+	}
+
+	return pizza.ConvertToDto(), nil
+}
+
+func (s *service) GetAsOf(name string, at time.Time) (*PizzaDto, error) {
+	pizza, err := s.repository.FindAsOf(name, at)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	} else if pizza == nil {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFoundAsOf, name, at.Format(time.RFC3339))
+	}
+
+	return pizza.ConvertToDto(), nil
+}
+
+func (s *service) Update(origin audit.Origin, name string, dto *PizzaDto) (*PizzaDto, error) {
+	if s.readOnly() {
+		return nil, Error(ErrReadOnly, ErrorTypeServiceUnavailable)
+	}
+	dto.Name = name
+	if err := s.check(dto); err != nil {
+		return nil, err
+	}
+
+	pizza, _ := s.repository.FindByName(name)
+	if pizza == nil {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
+	}
+
+	before := pizza.ConvertToDto()
+	changed := dto.ConvertToModel()
+	stampIngredients(changed.Ingredient, pizza.Ingredient, s.clock.Now())
+
+	pizza, err := s.repository.Update(changed)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	}
+	s.invalidate(name)
+
+	result := pizza.ConvertToDto()
+	s.record(origin, audit.ActionUpdate, name, before, result)
+
+	return result, nil
+}
+
+func (s *service) Delete(origin audit.Origin, name string) error {
+	if s.readOnly() {
+		return Error(ErrReadOnly, ErrorTypeServiceUnavailable)
+	}
+
+	pizza, err := s.repository.FindByName(name)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	} else if pizza == nil {
+		return Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
+	}
+
+	before := pizza.ConvertToDto()
+
+	if err := s.repository.Delete(pizza.Name); err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+	s.invalidate(name)
+
+	s.record(origin, audit.ActionDelete, name, before, nil)
+
+	return nil
+}
+
+func (s *service) Revisions(name string) ([]*RevisionDto, error) {
+	revisions, err := s.repository.FindRevisions(name)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	} else if len(revisions) == 0 {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrPizzaNotFound, name)
+	}
+
+	dtos := make([]*RevisionDto, len(revisions))
+
+	for i, revision := range revisions {
+		dtos[i] = revision.ConvertToDto()
+	}
+
+	return dtos, nil
+}
+
+func (s *service) Revert(origin audit.Origin, name string, number int) (*PizzaDto, error) {
+	if s.readOnly() {
+		return nil, Error(ErrReadOnly, ErrorTypeServiceUnavailable)
+	}
+
+	revisions, err := s.repository.FindRevisions(name)
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	} else if number < 1 || number > len(revisions) {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrRevisionNotFound, number, name)
+	}
+
+	revision := revisions[number-1]
+	if revision.Deleted {
+		return nil, Errorf(ErrorTypeBadRequest, ErrRevertDeletion, number, name)
+	}
+	if err := s.check(revision.Pizza.ConvertToDto()); err != nil {
+		return nil, err
+	}
+
+	// A deleted pizza is restored, an existing one gets the ingredients of the revision.
+	var before *PizzaDto
+	action := audit.ActionCreate
+	if current, _ := s.repository.FindByName(name); current != nil {
+		before = current.ConvertToDto()
+		action = audit.ActionUpdate
+	}
+
+	var pizza *Pizza
+	if action == audit.ActionCreate {
+		pizza, err = s.repository.Save(revision.Pizza)
+	} else {
+		pizza, err = s.repository.Update(revision.Pizza)
+	}
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	}
+	s.invalidate(name)
+
+	result := pizza.ConvertToDto()
+	s.record(origin, action, name, before, result)
+
+	return result, nil
+}
+
+func (s *service) Snapshot() (*Snapshot, error) {
+	snapshotter, ok := s.repository.(Snapshotter)
+	if !ok {
+		return nil, Error(ErrSnapshotNotSupported, ErrorTypeInternalServer)
+	}
+
+	snapshot, err := snapshotter.Snapshot()
+	if err != nil {
+		return nil, Error(err, ErrorTypeDatabase)
+	}
+
+	return snapshot, nil
+}
+
+func (s *service) Restore(origin audit.Origin, snapshot *Snapshot) error {
+	if s.readOnly() {
+		return Error(ErrReadOnly, ErrorTypeServiceUnavailable)
+	}
+	snapshotter, ok := s.repository.(Snapshotter)
+	if !ok {
+		return Error(ErrSnapshotNotSupported, ErrorTypeInternalServer)
+	}
+
+	// The cached responses of the replaced and the restored pizzas are deleted.
+	names := []string{}
+	if pizzas, err := s.repository.FindAll(); err == nil {
+		for _, pizza := range pizzas {
+			names = append(names, pizza.Name)
+		}
+	}
+	for name := range snapshot.Revisions {
+		names = append(names, name)
+	}
+
+	if err := snapshotter.Restore(snapshot, s.check); err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+	for _, name := range names {
+		s.invalidate(name)
+	}
+
+	s.record(origin, audit.ActionRestore, AuditNameAll, nil, nil)
+
+	return nil
+}
+
+// check validates a pizza and its ingredient count.
+func (s *service) check(dto *PizzaDto) error {
+	if err := s.validate.Struct(dto); err != nil {
+		return Error(err, ErrorTypeValidation)
+	}
+	if s.config.MaxIngredients > 0 && len(dto.Ingredient) > s.config.MaxIngredients {
+		return Errorf(ErrorTypeBadRequest, ErrTooManyIngredients, s.config.MaxIngredients)
+	}
+	return nil
+}
+
+// invalidate deletes the cached responses that contain a changed pizza. Failures are logged, the cached
+// responses expire with their time to live.
+func (s *service) invalidate(name string) {
+	if s.cache == nil {
+		return
+	}
+
+	if err := s.cache.Delete(context.Background(), CacheKeyAll, CacheKey(name)); err != nil {
+		s.log.Warnf("invalidating cached pizza %s failed: %v", name, err)
+	}
+}
+
+// record writes an audit event of a successful mutation. The mutation is already done,
+// so failures are logged and do not fail the request.
+func (s *service) record(origin audit.Origin, action, name string, before, after *PizzaDto) {
+	if s.audit == nil {
+		return
+	}
+
+	var beforeValue, afterValue interface{}
+	if before != nil {
+		beforeValue = before
+	}
+	if after != nil {
+		afterValue = after
+	}
+
+	event, err := audit.NewEvent(origin, s.clock.Now(), action, AuditResource, name, beforeValue, afterValue)
+	if err == nil {
+		err = s.audit.Write(event)
+	}
+	if err != nil {
+		s.log.Errorf("failed to audit %s of pizza %s: %v", action, name, err)
+	}
+}
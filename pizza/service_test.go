@@ -0,0 +1,281 @@
+package pizza
+
+import (
+	"golang-microservice-template/audit"
+	"golang-microservice-template/config"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClock struct {
+	now time.Time
+	sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// historyFixture is a service with the revisions of margherita: created at start with basil, updated an hour later
+// with basil and tomato, deleted another hour later.
+type historyFixture struct {
+	service    Service
+	repository Repository
+	clock      *fakeClock
+	start      time.Time
+}
+
+func newHistoryFixture(t *testing.T, cfg config.PizzaConfig) *historyFixture {
+	start := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	repository := NewRepository(clock)
+	service := NewService(WithRepository(repository), WithClock(clock), WithConfig(cfg))
+
+	_, err := service.Create(audit.Origin{}, margherita("basil"))
+	require.NoError(t, err)
+	clock.Advance(time.Hour)
+	_, err = service.Update(audit.Origin{}, "margherita", margherita("basil", "tomato"))
+	require.NoError(t, err)
+	clock.Advance(time.Hour)
+	require.NoError(t, service.Delete(audit.Origin{}, "margherita"))
+	clock.Advance(time.Hour)
+
+	return &historyFixture{service: service, repository: repository, clock: clock, start: start}
+}
+
+func margherita(ingredients ...string) *PizzaDto {
+	dto := &PizzaDto{Name: "margherita", Ingredient: []IngredientDto{}}
+	for _, name := range ingredients {
+		dto.Ingredient = append(dto.Ingredient, IngredientDto{Name: name, Count: 1})
+	}
+	return dto
+}
+
+func ingredientNames(dto *PizzaDto) []string {
+	names := []string{}
+	for _, ingredient := range dto.Ingredient {
+		names = append(names, ingredient.Name)
+	}
+	return names
+}
+
+func assertErrorStatus(t *testing.T, status int, err error) {
+	if assert.Error(t, err) {
+		assert.Equal(t, status, err.(HasHTTPStatus).GetHTTPStatusCode(), err.Error())
+	}
+}
+
+func TestRevisionsReturnsHistoryOldestFirst(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+
+	revisions, err := f.service.Revisions("margherita")
+
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Revision)
+		assert.Equal(t, f.start.Add(time.Duration(i)*time.Hour), revision.CreatedAt)
+	}
+	assert.Equal(t, []string{"basil"}, ingredientNames(revisions[0].Pizza))
+	assert.Equal(t, []string{"basil", "tomato"}, ingredientNames(revisions[1].Pizza))
+	assert.True(t, revisions[2].Deleted)
+	assert.Nil(t, revisions[2].Pizza)
+
+	_, err = f.service.Revisions("funghi")
+	assertErrorStatus(t, http.StatusNotFound, err)
+}
+
+func TestGetAsOfReturnsRevisionAtTime(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+
+	tests := map[string]struct {
+		at          time.Time
+		ingredients []string
+	}{
+		"at creation":     {at: f.start, ingredients: []string{"basil"}},
+		"before update":   {at: f.start.Add(time.Hour - time.Nanosecond), ingredients: []string{"basil"}},
+		"at update":       {at: f.start.Add(time.Hour), ingredients: []string{"basil", "tomato"}},
+		"before deletion": {at: f.start.Add(2*time.Hour - time.Nanosecond), ingredients: []string{"basil", "tomato"}},
+		"before creation": {at: f.start.Add(-time.Nanosecond)},
+		"at deletion":     {at: f.start.Add(2 * time.Hour)},
+		"after deletion":  {at: f.start.Add(3 * time.Hour)},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			dto, err := f.service.GetAsOf("margherita", test.at)
+
+			if test.ingredients == nil {
+				assertErrorStatus(t, http.StatusNotFound, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.ingredients, ingredientNames(dto))
+		})
+	}
+}
+
+func TestRevertRestoresDeletedPizza(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+
+	reverted, err := f.service.Revert(audit.Origin{}, "margherita", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"basil"}, ingredientNames(reverted))
+	assert.Equal(t, 4, reverted.Revision)
+	current, err := f.service.Get("margherita")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"basil"}, ingredientNames(current))
+	revisions, err := f.service.Revisions("margherita")
+	require.NoError(t, err)
+	assert.Len(t, revisions, 4, "the older revisions are kept")
+}
+
+func TestRevertUpdatesExistingPizza(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+	_, err := f.service.Revert(audit.Origin{}, "margherita", 2)
+	require.NoError(t, err)
+
+	reverted, err := f.service.Revert(audit.Origin{}, "margherita", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"basil"}, ingredientNames(reverted))
+	assert.Equal(t, 5, reverted.Revision)
+}
+
+func TestRevertRejectsUnknownRevisions(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+
+	for name, test := range map[string]struct {
+		pizza    string
+		revision int
+		status   int
+	}{
+		"zero":          {pizza: "margherita", revision: 0, status: http.StatusNotFound},
+		"negative":      {pizza: "margherita", revision: -1, status: http.StatusNotFound},
+		"out of range":  {pizza: "margherita", revision: 4, status: http.StatusNotFound},
+		"unknown pizza": {pizza: "funghi", revision: 1, status: http.StatusNotFound},
+		"deletion":      {pizza: "margherita", revision: 3, status: http.StatusBadRequest},
+	} {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := f.service.Revert(audit.Origin{}, test.pizza, test.revision)
+
+			assertErrorStatus(t, test.status, err)
+			revisions, err := f.service.Revisions("margherita")
+			require.NoError(t, err)
+			assert.Len(t, revisions, 3, "no revision is added")
+		})
+	}
+}
+
+func TestRevertValidatesRevision(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+	limited := NewService(WithRepository(f.repository), WithClock(f.clock), WithConfig(config.PizzaConfig{MaxIngredients: 1}))
+
+	_, err := limited.Revert(audit.Origin{}, "margherita", 2)
+	assertErrorStatus(t, http.StatusBadRequest, err)
+
+	// a revision stored without validation, e.g. by an older version of the service
+	_, err = f.repository.Save(&Pizza{Name: "funghi", Ingredient: []Ingredient{{Name: "", Count: 1}}})
+	require.NoError(t, err)
+	require.NoError(t, f.repository.Delete("funghi"))
+	_, err = f.service.Revert(audit.Origin{}, "funghi", 1)
+	assertErrorStatus(t, http.StatusBadRequest, err)
+
+	_, err = f.service.Get("margherita")
+	assertErrorStatus(t, http.StatusNotFound, err)
+	_, err = f.service.Get("funghi")
+	assertErrorStatus(t, http.StatusNotFound, err)
+}
+
+func TestRevertFailsWhileReadOnly(t *testing.T) {
+	f := newHistoryFixture(t, config.PizzaConfig{})
+	readOnly := NewService(WithRepository(f.repository), WithReadOnly(func() bool { return true }))
+
+	_, err := readOnly.Revert(audit.Origin{}, "margherita", 1)
+
+	assertErrorStatus(t, http.StatusServiceUnavailable, err)
+}
+
+func TestServiceSetsIngredientTimestamps(t *testing.T) {
+	start := time.Date(2020, 1, 31, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	service := NewService(WithRepository(NewRepository(clock)), WithClock(clock))
+	forged := time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dto := margherita("basil", "tomato")
+	for i := range dto.Ingredient {
+		dto.Ingredient[i].CreatedAt = forged
+		dto.Ingredient[i].UpdatedAt = &forged
+	}
+	created, err := service.Create(audit.Origin{}, dto)
+	require.NoError(t, err)
+	for _, ingredient := range created.Ingredient {
+		assert.Equal(t, start, ingredient.CreatedAt, ingredient.Name)
+		assert.Nil(t, ingredient.UpdatedAt, ingredient.Name)
+	}
+
+	clock.Advance(time.Hour)
+	dto = margherita("basil", "tomato", "oregano")
+	dto.Ingredient[0].Count = 2
+	dto.Ingredient[1].CreatedAt = forged
+	dto.Ingredient[2].UpdatedAt = &forged
+	updated, err := service.Update(audit.Origin{}, "margherita", dto)
+	require.NoError(t, err)
+	require.Len(t, updated.Ingredient, 3)
+	basil, tomato, oregano := updated.Ingredient[0], updated.Ingredient[1], updated.Ingredient[2]
+	assert.Equal(t, start, basil.CreatedAt)
+	if assert.NotNil(t, basil.UpdatedAt, "the count of basil changed") {
+		assert.Equal(t, start.Add(time.Hour), *basil.UpdatedAt)
+	}
+	assert.Equal(t, start, tomato.CreatedAt)
+	assert.Nil(t, tomato.UpdatedAt, "tomato is unchanged")
+	assert.Equal(t, start.Add(time.Hour), oregano.CreatedAt)
+	assert.Nil(t, oregano.UpdatedAt, "oregano is new")
+
+	clock.Advance(time.Hour)
+	updated, err = service.Update(audit.Origin{}, "margherita", margherita("basil"))
+	require.NoError(t, err)
+	basil = updated.Ingredient[0]
+	assert.Equal(t, start, basil.CreatedAt)
+	if assert.NotNil(t, basil.UpdatedAt, "the count of basil changed back") {
+		assert.Equal(t, start.Add(2*time.Hour), *basil.UpdatedAt)
+	}
+}
+
+func TestRestoreIsRejectedWhileReadOnly(t *testing.T) {
+	readOnly := false
+	service := NewService(WithReadOnly(func() bool { return readOnly }))
+	_, err := service.Create(audit.Origin{}, margherita("basil"))
+	require.NoError(t, err)
+	snapshot, err := service.Snapshot()
+	require.NoError(t, err)
+	require.NoError(t, service.Delete(audit.Origin{}, "margherita"))
+
+	readOnly = true
+	assertErrorStatus(t, http.StatusServiceUnavailable, service.Restore(audit.Origin{}, snapshot))
+	_, err = service.Get("margherita")
+	assertErrorStatus(t, http.StatusNotFound, err)
+
+	readOnly = false
+	require.NoError(t, service.Restore(audit.Origin{}, snapshot))
+	restored, err := service.Get("margherita")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"basil"}, ingredientNames(restored))
+}
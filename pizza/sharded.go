@@ -0,0 +1,99 @@
+package pizza
+
+import (
+	"golang-microservice-template/events"
+	. "golang-microservice-template/utils"
+	"hash/fnv"
+	"time"
+)
+
+// DefaultShards is the number of shards of the sharded repository if none are configured.
+const DefaultShards = 32
+
+type shardedRepository struct {
+	shards []*repository
+	outbox events.Outbox
+}
+
+// NewShardedRepository creates an in-memory pizza repository that partitions the pizzas by a hash of their name.
+// Every shard has its own lock, so changes of different pizzas do not wait for each other.
+// All shards share one outbox, the events of a pizza keep the order of its changes.
+func NewShardedRepository(clock Clock, shards int) Repository {
+	if shards < 1 {
+		shards = DefaultShards
+	}
+
+	outbox := events.NewMemoryOutbox(events.DefaultOutboxCapacity)
+	r := &shardedRepository{shards: make([]*repository, shards), outbox: outbox}
+	for i := range r.shards {
+		r.shards[i] = &repository{
+			pizzas:    make(map[string]*Pizza),
+			revisions: make(map[string][]*Revision),
+			outbox:    outbox,
+			clock:     clock,
+		}
+	}
+	return r
+}
+
+// shard returns the shard of a pizza name.
+func (r *shardedRepository) shard(name string) *repository {
+	return r.shards[r.index(name)]
+}
+
+// index returns the position of the shard of a pizza name.
+func (r *shardedRepository) index(name string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int(h.Sum32() % uint32(len(r.shards)))
+}
+
+// FindAll locks one shard after another, so the list is not a snapshot of all shards at one point in time.
+func (r *shardedRepository) FindAll() ([]*Pizza, error) {
+	list := []*Pizza{}
+	for _, shard := range r.shards {
+		pizzas, err := shard.FindAll()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pizzas...)
+	}
+
+	return list, nil
+}
+
+func (r *shardedRepository) FindByName(name string) (*Pizza, error) {
+	return r.shard(name).FindByName(name)
+}
+
+func (r *shardedRepository) Update(pizza *Pizza) (*Pizza, error) {
+	return r.shard(pizza.Name).Update(pizza)
+}
+
+func (r *shardedRepository) Save(pizza *Pizza) (*Pizza, error) {
+	return r.shard(pizza.Name).Save(pizza)
+}
+
+func (r *shardedRepository) Delete(name string) error {
+	return r.shard(name).Delete(name)
+}
+
+func (r *shardedRepository) FindRevisions(name string) ([]*Revision, error) {
+	return r.shard(name).FindRevisions(name)
+}
+
+func (r *shardedRepository) FindAsOf(name string, at time.Time) (*Pizza, error) {
+	return r.shard(name).FindAsOf(name, at)
+}
+
+func (r *shardedRepository) Outbox() events.Outbox {
+	return r.outbox
+}
+
+func (r *shardedRepository) Ping() error {
+	return nil
+}
+
+func (r *shardedRepository) Close() error {
+	return nil
+}
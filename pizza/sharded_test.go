@@ -0,0 +1,205 @@
+package pizza
+
+import (
+	. "golang-microservice-template/utils"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testedRepositories are the in-memory repositories that must behave alike, with few shards that hold many pizzas.
+var testedRepositories = append(repositoryConstructors, repositoryConstructor{
+	"sharded by 3", func(clock Clock) Repository { return NewShardedRepository(clock, 3) },
+})
+
+func TestShardedRepositoryPartitionsByName(t *testing.T) {
+	r := NewShardedRepository(SystemClock(), 4).(*shardedRepository)
+
+	used := map[int]bool{}
+	for i := 0; i < 100; i++ {
+		name := pizzaName(i)
+		_, err := r.Save(newNamedPizza(name))
+		require.NoError(t, err)
+
+		assert.Equal(t, r.index(name), r.index(name))
+		_, err = r.shards[r.index(name)].FindByName(name)
+		assert.NoError(t, err, "%s is not stored in its shard", name)
+		used[r.index(name)] = true
+	}
+
+	assert.Len(t, used, 4)
+}
+
+func TestRepositoriesBehaveAlike(t *testing.T) {
+	for _, constructor := range testedRepositories {
+		constructor := constructor
+		t.Run(constructor.name, func(t *testing.T) {
+			r := constructor.new(SystemClock())
+
+			saved, err := r.Save(newNamedPizza("margherita"))
+			require.NoError(t, err)
+			assert.Equal(t, 1, saved.Revision)
+			_, err = r.Save(newNamedPizza("margherita"))
+			assert.Equal(t, ErrorTypeConflict, err.(HasHTTPStatus).GetErrorType())
+
+			update := newNamedPizza("margherita")
+			update.Ingredient = update.Ingredient[:1]
+			updated, err := r.Update(update)
+			require.NoError(t, err)
+			assert.Equal(t, 2, updated.Revision)
+			assert.Len(t, updated.Ingredient, 1)
+			_, err = r.Update(newNamedPizza("funghi"))
+			assert.Equal(t, ErrorTypeResourceNotFound, err.(HasHTTPStatus).GetErrorType())
+
+			require.NoError(t, r.Delete("margherita"))
+			require.NoError(t, r.Delete("margherita"))
+			_, err = r.FindByName("margherita")
+			assert.Equal(t, ErrorTypeResourceNotFound, err.(HasHTTPStatus).GetErrorType())
+
+			revisions, err := r.FindRevisions("margherita")
+			require.NoError(t, err)
+			require.Len(t, revisions, 3)
+			assert.True(t, revisions[2].Deleted)
+			asOf, err := r.FindAsOf("margherita", revisions[1].CreatedAt)
+			require.NoError(t, err)
+			assert.Equal(t, 2, asOf.Revision)
+
+			pending, err := r.Outbox().Pending(SystemClock().Now(), 10)
+			require.NoError(t, err)
+			require.Len(t, pending, 1, "the events of a pizza are pending one after another")
+			assert.Equal(t, EventPizzaCreated, pending[0].Event.Type)
+		})
+	}
+}
+
+// TestRepositoriesConcurrentChanges changes pizzas of all shards from several goroutines while others read them.
+// Run it with -race.
+func TestRepositoriesConcurrentChanges(t *testing.T) {
+	const writers, pizzasPerWriter, updates = 8, 10, 5
+
+	for _, constructor := range testedRepositories {
+		constructor := constructor
+		t.Run(constructor.name, func(t *testing.T) {
+			r := constructor.new(SystemClock())
+			stop := make(chan struct{})
+			readers := sync.WaitGroup{}
+
+			// FindAll returns every pizza at most once and with the state of one of its revisions
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					pizzas, err := r.FindAll()
+					assert.NoError(t, err)
+					seen := map[string]bool{}
+					for _, p := range pizzas {
+						assert.False(t, seen[p.Name], "%s is listed twice", p.Name)
+						seen[p.Name] = true
+						assert.True(t, p.Revision >= 1 && p.Revision <= updates+1, "%s has revision %d", p.Name, p.Revision)
+						assert.Len(t, p.Ingredient, len(newBenchmarkPizza().Ingredient)-(p.Revision-1)%2)
+					}
+				}
+			}()
+			readers.Add(1)
+			go func() {
+				defer readers.Done()
+				for i := 0; ; i++ {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					if p, err := r.FindByName(pizzaName(i % (writers * pizzasPerWriter))); err == nil {
+						assert.Equal(t, pizzaName(i%(writers*pizzasPerWriter)), p.Name)
+					}
+				}
+			}()
+
+			writing := sync.WaitGroup{}
+			for w := 0; w < writers; w++ {
+				writing.Add(1)
+				go func(w int) {
+					defer writing.Done()
+					for i := w * pizzasPerWriter; i < (w+1)*pizzasPerWriter; i++ {
+						name := pizzaName(i)
+						_, err := r.Save(newNamedPizza(name))
+						assert.NoError(t, err)
+						for u := 0; u < updates; u++ {
+							// every other revision lacks the last ingredient
+							update := newNamedPizza(name)
+							if u%2 == 0 {
+								update.Ingredient = update.Ingredient[:len(update.Ingredient)-1]
+							}
+							_, err := r.Update(update)
+							assert.NoError(t, err)
+						}
+						if i%2 == 0 {
+							assert.NoError(t, r.Delete(name))
+						}
+					}
+				}(w)
+			}
+			writing.Wait()
+			close(stop)
+			readers.Wait()
+
+			pizzas, err := r.FindAll()
+			require.NoError(t, err)
+			assert.Len(t, pizzas, writers*pizzasPerWriter/2)
+			for i := 0; i < writers*pizzasPerWriter; i++ {
+				revisions, err := r.FindRevisions(pizzaName(i))
+				require.NoError(t, err)
+				expected := updates + 1 + (1 - i%2)
+				require.Len(t, revisions, expected, pizzaName(i))
+				for n, revision := range revisions {
+					assert.Equal(t, n+1, revision.Number)
+				}
+			}
+		})
+	}
+}
+
+// TestShardedRepositoryFindAllWhileSaving checks that FindAll sees the pizzas of every shard while others are saved.
+// Run it with -race.
+func TestShardedRepositoryFindAllWhileSaving(t *testing.T) {
+	const saved = 200
+	r := NewShardedRepository(SystemClock(), 4)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < saved; i++ {
+			_, err := r.Save(newNamedPizza(pizzaName(i)))
+			assert.NoError(t, err)
+		}
+	}()
+
+	// pizzas are never removed, so a list never has fewer pizzas than an earlier one
+	listed := map[string]bool{}
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		pizzas, err := r.FindAll()
+		require.NoError(t, err)
+		names := map[string]bool{}
+		for _, p := range pizzas {
+			names[p.Name] = true
+		}
+		for name := range listed {
+			assert.True(t, names[name], "%s disappeared from the list", name)
+		}
+		listed = names
+	}
+
+	assert.Len(t, listed, saved)
+}
@@ -0,0 +1,178 @@
+package pizza
+
+import (
+	. "golang-microservice-template/utils"
+	"sort"
+	"time"
+)
+
+// SnapshotVersion is the format version of snapshots, snapshots of other versions are rejected.
+const SnapshotVersion = 1
+
+// errors
+var (
+	ErrSnapshotVersion      = "unsupported snapshot version %d, expected %d"
+	ErrSnapshotRevisions    = "revisions of pizza '%s' must be numbered from 1 without gaps"
+	ErrSnapshotOrder        = "revisions of pizza '%s' must be ordered by their creation time"
+	ErrSnapshotPizza        = "revision %d of pizza '%s' must have the pizza unless it is a deletion"
+	ErrSnapshotInvalidPizza = "revision %d of pizza '%s' is invalid: %v"
+	ErrSnapshotNotSupported = "the repository does not support snapshots"
+)
+
+// Snapshot is the complete state of a repository: all revisions of all pizza names, oldest first and
+// deletions included. The current pizzas are the latest revisions that are no deletions.
+// Pending domain events are not part of a snapshot.
+type Snapshot struct {
+	Version   int                       `json:"version"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	Revisions map[string][]*RevisionDto `json:"revisions"`
+}
+
+// Pizzas returns the number of current pizzas of the snapshot.
+func (s *Snapshot) Pizzas() int {
+	count := 0
+	for _, revisions := range s.Revisions {
+		if n := len(revisions); n > 0 && !revisions[n-1].Deleted {
+			count++
+		}
+	}
+	return count
+}
+
+// Snapshotter is implemented by repositories that can export and replace their complete state.
+type Snapshotter interface {
+	// Snapshot returns a copy of all revisions.
+	Snapshot() (*Snapshot, error)
+	// Restore replaces all pizzas and revisions with the snapshot. It creates no domain events.
+	// Nothing is replaced unless check accepts the pizza of every revision.
+	Restore(snapshot *Snapshot, check func(*PizzaDto) error) error
+}
+
+func (r *repository) Snapshot() (*Snapshot, error) {
+	r.RLock()
+	defer r.RUnlock()
+
+	snapshot := &Snapshot{Version: SnapshotVersion, CreatedAt: r.clock.Now(), Revisions: map[string][]*RevisionDto{}}
+	r.export(snapshot)
+
+	return snapshot, nil
+}
+
+func (r *repository) Restore(snapshot *Snapshot, check func(*PizzaDto) error) error {
+	pizzas, revisions, err := importSnapshot(snapshot, check)
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.pizzas = pizzas
+	r.revisions = revisions
+
+	return nil
+}
+
+// export adds the revisions of the repository to the snapshot. The caller must hold the read lock.
+func (r *repository) export(snapshot *Snapshot) {
+	for name, revisions := range r.revisions {
+		dtos := make([]*RevisionDto, len(revisions))
+		for i, revision := range revisions {
+			dtos[i] = revision.ConvertToDto()
+		}
+		snapshot.Revisions[name] = dtos
+	}
+}
+
+// Snapshot locks all shards, so the snapshot is consistent across them.
+func (r *shardedRepository) Snapshot() (*Snapshot, error) {
+	for _, shard := range r.shards {
+		shard.RLock()
+		defer shard.RUnlock()
+	}
+
+	snapshot := &Snapshot{Version: SnapshotVersion, CreatedAt: r.shards[0].clock.Now(), Revisions: map[string][]*RevisionDto{}}
+	for _, shard := range r.shards {
+		shard.export(snapshot)
+	}
+
+	return snapshot, nil
+}
+
+func (r *shardedRepository) Restore(snapshot *Snapshot, check func(*PizzaDto) error) error {
+	pizzas, revisions, err := importSnapshot(snapshot, check)
+	if err != nil {
+		return err
+	}
+
+	partitions := make([]*repository, len(r.shards))
+	for i := range partitions {
+		partitions[i] = &repository{pizzas: map[string]*Pizza{}, revisions: map[string][]*Revision{}}
+	}
+	for name, list := range revisions {
+		partition := partitions[r.index(name)]
+		partition.revisions[name] = list
+		if pizza, ok := pizzas[name]; ok {
+			partition.pizzas[name] = pizza
+		}
+	}
+
+	for _, shard := range r.shards {
+		shard.Lock()
+		defer shard.Unlock()
+	}
+	for i, shard := range r.shards {
+		shard.pizzas = partitions[i].pizzas
+		shard.revisions = partitions[i].revisions
+	}
+
+	return nil
+}
+
+// importSnapshot validates a snapshot and converts it to the pizzas and revisions of a repository.
+// The pizza of every revision is validated with check as it will be stored, e.g. with the name it is stored by.
+func importSnapshot(snapshot *Snapshot, check func(*PizzaDto) error) (map[string]*Pizza, map[string][]*Revision, error) {
+	if snapshot.Version != SnapshotVersion {
+		return nil, nil, Errorf(ErrorTypeBadRequest, ErrSnapshotVersion, snapshot.Version, SnapshotVersion)
+	}
+
+	pizzas := map[string]*Pizza{}
+	revisions := map[string][]*Revision{}
+	for name, dtos := range snapshot.Revisions {
+		list := make([]*Revision, len(dtos))
+		for i, dto := range dtos {
+			if dto == nil || dto.Revision != i+1 {
+				return nil, nil, Errorf(ErrorTypeBadRequest, ErrSnapshotRevisions, name)
+			}
+			if !dto.Deleted && dto.Pizza == nil {
+				return nil, nil, Errorf(ErrorTypeBadRequest, ErrSnapshotPizza, dto.Revision, name)
+			}
+			// The pizza of a revision is derived from it, like in commit.
+			list[i] = dto.ConvertToModel()
+			if list[i].Deleted {
+				list[i].Pizza = nil
+			} else {
+				list[i].Pizza.Name = name
+				list[i].Pizza.Revision = list[i].Number
+				list[i].Pizza.UpdatedAt = list[i].CreatedAt
+				if err := check(list[i].Pizza.ConvertToDto()); err != nil {
+					return nil, nil, Errorf(ErrorTypeBadRequest, ErrSnapshotInvalidPizza, dto.Revision, name, err)
+				}
+			}
+		}
+		// FindAsOf searches the revisions by time.
+		if !sort.SliceIsSorted(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) }) {
+			return nil, nil, Errorf(ErrorTypeBadRequest, ErrSnapshotOrder, name)
+		}
+		if len(list) == 0 {
+			continue
+		}
+
+		revisions[name] = list
+		if latest := list[len(list)-1]; !latest.Deleted {
+			pizzas[name] = latest.Pizza.copy()
+		}
+	}
+
+	return pizzas, revisions, nil
+}
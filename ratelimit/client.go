@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ContextKeyClientIP is the key of the address of the client in the echo context.
+const ContextKeyClientIP = "ratelimit.clientIP"
+
+// errors
+var (
+	ErrInvalidTrustedProxy = "invalid trusted proxy '%s', expected an IP address or CIDR range"
+)
+
+// TrustedProxies are the address ranges of reverse proxies whose forwarding headers are honoured.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges, e.g. 10.0.0.1 or 10.0.0.0/8.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	trusted := TrustedProxies{}
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf(ErrInvalidTrustedProxy, proxy)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			proxy = fmt.Sprintf("%s/%d", proxy, bits)
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf(ErrInvalidTrustedProxy, proxy)
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted, nil
+}
+
+func (t TrustedProxies) contains(ip net.IP) bool {
+	for _, network := range t {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns a middleware that stores the address of the client in the context, see ClientKey.
+// The address is the peer of the connection. Only if the peer is a trusted proxy, the client is taken from
+// X-Forwarded-For, skipping trusted proxies from the right, or from X-Real-IP.
+func ClientIP(proxies TrustedProxies) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Set(ContextKeyClientIP, proxies.clientIP(ctx.Request()))
+			return next(ctx)
+		}
+	}
+}
+
+func (t TrustedProxies) clientIP(r *http.Request) string {
+	client := remoteIP(r)
+	if ip := net.ParseIP(client); ip == nil || !t.contains(ip) {
+		return client
+	}
+
+	forwarded := []string{}
+	for _, header := range r.Header[echo.HeaderXForwardedFor] {
+		forwarded = append(forwarded, strings.Split(header, ",")...)
+	}
+	if len(forwarded) == 0 {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(echo.HeaderXRealIP))); ip != nil {
+			return ip.String()
+		}
+		return client
+	}
+
+	// Each proxy appends the address of its peer, only the entries of trusted proxies can be believed.
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !t.contains(ip) {
+			break
+		}
+	}
+	return client
+}
+
+// remoteIP returns the host of the peer address of the connection.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		realIP    string
+		client    string
+	}{
+		{"direct client", "203.0.113.7:4711", nil, "", "203.0.113.7"},
+		{"untrusted peer cannot forward", "203.0.113.7:4711", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1:4711", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"spoofed entries left of the proxy are ignored", "10.0.0.1:4711", []string{"1.2.3.4, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chain of trusted proxies", "192.168.1.1:4711", []string{"198.51.100.1, 10.1.2.3", "10.0.0.2"}, "", "198.51.100.1"},
+		{"only trusted proxies", "10.0.0.1:4711", []string{"10.0.0.3"}, "", "10.0.0.3"},
+		{"malformed entry", "10.0.0.1:4711", []string{"198.51.100.1, garbage"}, "", "10.0.0.1"},
+		{"real ip of trusted proxy", "10.0.0.1:4711", nil, "198.51.100.1", "198.51.100.1"},
+		{"ipv6 peer", "[2001:db8::1]:4711", []string{"198.51.100.1"}, "", "2001:db8::1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remote
+			for _, value := range test.forwarded {
+				req.Header.Add(echo.HeaderXForwardedFor, value)
+			}
+			if test.realIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, test.realIP)
+			}
+
+			assert.Equal(t, test.client, proxies.clientIP(req))
+		})
+	}
+}
+
+func TestParseTrustedProxiesRejectsInvalidEntries(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = ParseTrustedProxies([]string{"proxy.example.com"})
+	assert.Error(t, err)
+}
+
+func TestClientKey(t *testing.T) {
+	key := func(setup func(echo.Context)) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.7:4711"
+		req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.1")
+		ctx := echo.New().NewContext(req, httptest.NewRecorder())
+		setup(ctx)
+		return ClientKey(ctx)
+	}
+
+	assert.Equal(t, "ip:203.0.113.7", key(func(echo.Context) {}))
+	assert.Equal(t, "ip:198.51.100.1", key(func(ctx echo.Context) { ctx.Set(ContextKeyClientIP, "198.51.100.1") }))
+	assert.Equal(t, "apikey:k1", key(func(ctx echo.Context) {
+		ctx.Set(apikey.ContextKeyAPIKey, &apikey.Key{ID: "k1", Owner: "alice"})
+		ctx.Set(auth.ContextKeyPrincipal, &auth.Principal{Method: auth.MethodAPIKey, Subject: "alice"})
+	}))
+
+	jwt := key(func(ctx echo.Context) {
+		ctx.Set(auth.ContextKeyPrincipal, &auth.Principal{Method: auth.MethodJWT, Subject: "alice"})
+	})
+	mtls := key(func(ctx echo.Context) {
+		ctx.Set(auth.ContextKeyPrincipal, &auth.Principal{Method: auth.MethodMTLS, Subject: "alice"})
+	})
+	assert.Equal(t, "jwt:alice", jwt)
+	assert.Equal(t, "mtls:alice", mtls)
+}
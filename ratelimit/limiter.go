@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+
+	. "golang-microservice-template/utils"
+)
+
+// Classes of routes with separate limits
+const (
+	ClassRead  = "read"
+	ClassWrite = "write"
+	// ClassAddress limits all requests of an IP address before the caller is authenticated.
+	ClassAddress = "address"
+)
+
+// Limiter enforces the limits of all route classes.
+type Limiter interface {
+	// Allow takes a token for the client from the bucket of the route class.
+	// Classes without a limit are not limited.
+	Allow(client, class string) (*Result, error)
+	// SetLimits replaces the limits of all route classes.
+	SetLimits(limits map[string]Limit)
+}
+
+type limiter struct {
+	store  Store
+	clock  Clock
+	limits map[string]Limit
+	sync.RWMutex
+}
+
+// NewLimiter creates a new Limiter that keeps its buckets in the given store.
+func NewLimiter(store Store, clock Clock, limits map[string]Limit) Limiter {
+	return &limiter{
+		store:  store,
+		clock:  clock,
+		limits: limits,
+	}
+}
+
+func (l *limiter) Allow(client, class string) (*Result, error) {
+	l.RLock()
+	limit, ok := l.limits[class]
+	l.RUnlock()
+
+	if !ok {
+		return &Result{Allowed: true}, nil
+	}
+
+	return l.store.Take(fmt.Sprintf("%s:%s", class, client), limit, l.clock.Now())
+}
+
+func (l *limiter) SetLimits(limits map[string]Limit) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.limits = limits
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"golang-microservice-template/apikey"
+	"golang-microservice-template/auth"
+	. "golang-microservice-template/utils"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Headers of the rate limit state, see the IETF draft "RateLimit Header Fields for HTTP".
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+)
+
+// errors
+var (
+	ErrRateLimitExceeded = "rate limit exceeded, retry in %d seconds"
+)
+
+// Middleware returns a middleware that limits the requests of each client to the routes of the given class.
+// If the store fails, requests are allowed.
+func Middleware(limiter Limiter, class string) echo.MiddlewareFunc {
+	return limit(limiter, class, ClientKey)
+}
+
+// AddressMiddleware returns a middleware that limits the requests of each IP address to the limit of ClassAddress.
+// It runs before the authentication, so failed authentication attempts use up the bucket of the address.
+func AddressMiddleware(limiter Limiter) echo.MiddlewareFunc {
+	return limit(limiter, ClassAddress, AddressKey)
+}
+
+func limit(limiter Limiter, class string, key func(ctx echo.Context) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			result, err := limiter.Allow(key(ctx), class)
+			if err != nil {
+				Log.Warnf("rate limit store failed: %v", err)
+				return next(ctx)
+			}
+
+			if result.Limit > 0 {
+				header := ctx.Response().Header()
+				header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+				header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+				header.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+			}
+
+			if !result.Allowed {
+				retryAfter := ceilSeconds(result.RetryAfter)
+				ctx.Response().Header().Set(HeaderRetryAfter, retryAfter)
+				return Errorf(ErrorTypeTooManyRequests, ErrRateLimitExceeded, int(math.Ceil(result.RetryAfter.Seconds())))
+			}
+
+			return next(ctx)
+		}
+	}
+}
+
+// ClientKey identifies the caller of a request by its API key, its authentication method and subject or
+// its IP address, see ClientIP. Subjects are prefixed with the method, so equal names of different methods differ.
+func ClientKey(ctx echo.Context) string {
+	if key, ok := ctx.Get(apikey.ContextKeyAPIKey).(*apikey.Key); ok {
+		return auth.MethodAPIKey + ":" + key.ID
+	}
+	if principal := auth.PrincipalFromContext(ctx); principal != nil {
+		return principal.Method + ":" + principal.Subject
+	}
+	return AddressKey(ctx)
+}
+
+// AddressKey identifies the caller of a request by its IP address, see ClientIP.
+func AddressKey(ctx echo.Context) string {
+	if ip, ok := ctx.Get(ContextKeyClientIP).(string); ok {
+		return "ip:" + ip
+	}
+	return "ip:" + remoteIP(ctx.Request())
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the number of takes after which idle buckets are removed from the memory store.
+const sweepInterval = 1000
+
+// Limit is the configuration of a token bucket.
+type Limit struct {
+	// Rate is the number of tokens added per second.
+	Rate float64
+	// Burst is the capacity of the bucket.
+	Burst int
+}
+
+// Result is the state of a bucket after a token was requested.
+type Result struct {
+	// Allowed is true if a token was taken.
+	Allowed bool
+	// Limit is the capacity of the bucket.
+	Limit int
+	// Remaining is the number of tokens left.
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available if the request was not allowed.
+	RetryAfter time.Duration
+}
+
+// Store holds the token buckets. Implementations backed by a shared database let multiple replicas
+// of the service enforce a common limit.
+type Store interface {
+	// Take removes a token from the bucket with the given key if one is available.
+	Take(key string, limit Limit, now time.Time) (*Result, error)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// fullAt is the time the bucket is full again if no further token is taken.
+	fullAt time.Time
+}
+
+type memoryStore struct {
+	buckets map[string]*bucket
+	takes   int
+	sync.Mutex
+}
+
+// NewMemoryStore creates a new Store that keeps the buckets in memory of a single replica.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (s *memoryStore) Take(key string, limit Limit, now time.Time) (*Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.takes++
+	if s.takes%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	return take(b, limit, now), nil
+}
+
+// sweep removes all buckets that are full again and therefore equal to a new bucket.
+func (s *memoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.After(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// take refills the bucket for the time passed since the last request and removes a token if available.
+func take(b *bucket, limit Limit, now time.Time) *Result {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	result := &Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+
+	result.Remaining = int(math.Floor(b.tokens))
+	result.Reset = seconds((float64(limit.Burst) - b.tokens) / limit.Rate)
+	b.fullAt = now.Add(result.Reset)
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,106 @@
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golang-microservice-template/audit"
+	. "golang-microservice-template/utils"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// FormFieldSnapshot is the multipart form field of an uploaded snapshot.
+const FormFieldSnapshot = "snapshot"
+
+// errors
+var (
+	ErrSnapshotTooLarge = "the uploaded snapshot is larger than %d bytes"
+)
+
+// Controller handles the admin requests to write, download and restore snapshots.
+type Controller interface {
+	// Get downloads a snapshot of all pizzas as JSON file.
+	Get(echo.Context) error
+	// Create writes a snapshot to the snapshot file.
+	Create(echo.Context) error
+	// Restore replaces all pizzas with an uploaded snapshot, either the JSON body or the multipart file "snapshot".
+	// Requests larger than the maximum upload size are rejected with 413.
+	Restore(echo.Context) error
+}
+
+type controller struct {
+	manager       Manager
+	clock         Clock
+	maxUploadSize int64
+}
+
+// NewController creates a new Controller for the snapshots of the given manager. Restore reads up to
+// maxUploadSize bytes of the request body.
+func NewController(manager Manager, clock Clock, maxUploadSize int64) Controller {
+	return &controller{manager: manager, clock: clock, maxUploadSize: maxUploadSize}
+}
+
+func (c *controller) Get(ctx echo.Context) error {
+	name := fmt.Sprintf("pizza-snapshot-%s.json", c.clock.Now().UTC().Format("20060102T150405Z"))
+	ctx.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	ctx.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+
+	_, err := c.manager.Write(ctx.Response())
+	return err
+}
+
+func (c *controller) Create(ctx echo.Context) error {
+	info, err := c.manager.Save()
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, info)
+}
+
+func (c *controller) Restore(ctx echo.Context) error {
+	request := ctx.Request()
+	request.Body = http.MaxBytesReader(ctx.Response(), request.Body, c.maxUploadSize)
+
+	var body io.Reader
+	if strings.HasPrefix(request.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		header, err := ctx.FormFile(FormFieldSnapshot)
+		if err != nil {
+			return c.uploadError(err, ErrorTypeBinding)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return Error(err, ErrorTypeBinding)
+		}
+		defer file.Close()
+		body = file
+	} else {
+		// The body is read here, the manager could not tell a too large upload from an invalid one.
+		data, err := ioutil.ReadAll(request.Body)
+		if err != nil {
+			return c.uploadError(err, ErrorTypeBadRequest)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	info, err := c.manager.Restore(audit.OriginFromContext(ctx), body)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, info)
+}
+
+// uploadError returns a 413 error if reading the upload exceeded the maximum size, else err as xtype.
+func (c *controller) uploadError(err error, xtype string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return Errorf(ErrorTypeRequestTooLarge, ErrSnapshotTooLarge, c.maxUploadSize)
+	}
+	return Error(err, xtype)
+}
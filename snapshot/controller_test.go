@@ -0,0 +1,169 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/config"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMaxUploadSize = 4096
+
+// restoreFixture is a snapshot controller of a service with the pizza margherita.
+type restoreFixture struct {
+	service    pizza.Service
+	controller Controller
+}
+
+func newRestoreFixture(t *testing.T) *restoreFixture {
+	service := pizza.NewService(pizza.WithConfig(config.PizzaConfig{MaxIngredients: 2}))
+	_, err := service.Create(audit.Origin{}, &pizza.PizzaDto{Name: "margherita", Ingredient: []pizza.IngredientDto{{Name: "basil", Count: 1}}})
+	require.NoError(t, err)
+
+	manager := NewManager(service, filepath.Join(t.TempDir(), "snapshot.json"), Log)
+	return &restoreFixture{service: service, controller: NewController(manager, SystemClock(), testMaxUploadSize)}
+}
+
+// restore sends the body with the content type to Restore and returns the response and the error of the handler.
+func (f *restoreFixture) restore(body []byte, contentType string) (*httptest.ResponseRecorder, error) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/admin/snapshots/restore", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	rec := httptest.NewRecorder()
+
+	return rec, f.controller.Restore(echo.New().NewContext(req, rec))
+}
+
+// assertUnchanged fails unless the service still has margherita and nothing else.
+func (f *restoreFixture) assertUnchanged(t *testing.T) {
+	pizzas, err := f.service.GetAll()
+	require.NoError(t, err)
+	require.Len(t, pizzas, 1)
+	assert.Equal(t, "margherita", pizzas[0].Name)
+}
+
+// snapshotOf returns a snapshot with a single revision of each given pizza.
+func snapshotOf(pizzas ...*pizza.PizzaDto) []byte {
+	snapshot := &pizza.Snapshot{Version: pizza.SnapshotVersion, CreatedAt: time.Now(), Revisions: map[string][]*pizza.RevisionDto{}}
+	for _, p := range pizzas {
+		snapshot.Revisions[p.Name] = []*pizza.RevisionDto{{Revision: 1, CreatedAt: time.Now(), Pizza: p}}
+	}
+	body, _ := json.Marshal(snapshot)
+	return body
+}
+
+func multipartUpload(t *testing.T, snapshot []byte) ([]byte, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	file, err := writer.CreateFormFile(FormFieldSnapshot, "snapshot.json")
+	require.NoError(t, err)
+	_, err = file.Write(snapshot)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func assertStatus(t *testing.T, status int, err error) {
+	if assert.Error(t, err) {
+		assert.Equal(t, status, err.(HasHTTPStatus).GetHTTPStatusCode(), err.Error())
+	}
+}
+
+func TestRestoreReplacesPizzas(t *testing.T) {
+	fixture := newRestoreFixture(t)
+	snapshot := snapshotOf(&pizza.PizzaDto{Name: "funghi", Ingredient: []pizza.IngredientDto{{Name: "mushroom", Count: 2}}})
+	upload, contentType := multipartUpload(t, snapshot)
+
+	for name, request := range map[string]struct {
+		body        []byte
+		contentType string
+	}{
+		"json":      {snapshot, echo.MIMEApplicationJSON},
+		"multipart": {upload, contentType},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec, err := fixture.restore(request.body, request.contentType)
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			pizzas, err := fixture.service.GetAll()
+			require.NoError(t, err)
+			require.Len(t, pizzas, 1)
+			assert.Equal(t, "funghi", pizzas[0].Name)
+		})
+	}
+}
+
+func TestRestoreRejectsTooLargeUploads(t *testing.T) {
+	padding := strings.Repeat("x", testMaxUploadSize)
+	snapshot := snapshotOf(&pizza.PizzaDto{Name: "funghi", Ingredient: []pizza.IngredientDto{{Name: "mushroom", Count: 2}}})
+	large := append(snapshot[:len(snapshot)-1:len(snapshot)-1], []byte(`,"padding":"`+padding+`"}`)...)
+	upload, contentType := multipartUpload(t, large)
+
+	t.Run("json", func(t *testing.T) {
+		fixture := newRestoreFixture(t)
+		_, err := fixture.restore(large, echo.MIMEApplicationJSON)
+
+		assertStatus(t, http.StatusRequestEntityTooLarge, err)
+		fixture.assertUnchanged(t)
+	})
+	t.Run("multipart", func(t *testing.T) {
+		fixture := newRestoreFixture(t)
+		_, err := fixture.restore(upload, contentType)
+
+		assertStatus(t, http.StatusRequestEntityTooLarge, err)
+		fixture.assertUnchanged(t)
+	})
+}
+
+func TestRestoreRejectsInvalidPizzas(t *testing.T) {
+	valid := &pizza.PizzaDto{Name: "funghi", Ingredient: []pizza.IngredientDto{{Name: "mushroom", Count: 2}}}
+	tests := map[string]*pizza.PizzaDto{
+		"empty name":            {Name: "", Ingredient: []pizza.IngredientDto{{Name: "basil", Count: 1}}},
+		"empty ingredient name": {Name: "salami", Ingredient: []pizza.IngredientDto{{Name: "", Count: 1}}},
+		"too long ingredient":   {Name: "salami", Ingredient: []pizza.IngredientDto{{Name: strings.Repeat("x", 256), Count: 1}}},
+		"too many ingredients":  {Name: "salami", Ingredient: []pizza.IngredientDto{{Name: "a"}, {Name: "b"}, {Name: "c"}}},
+	}
+
+	for name, invalid := range tests {
+		invalid := invalid
+		t.Run(name, func(t *testing.T) {
+			fixture := newRestoreFixture(t)
+			_, err := fixture.restore(snapshotOf(valid, invalid), echo.MIMEApplicationJSON)
+
+			assertStatus(t, http.StatusBadRequest, err)
+			fixture.assertUnchanged(t)
+		})
+	}
+}
+
+func TestRestoreValidatesDeletedPizzasOnlyUntilDeletion(t *testing.T) {
+	fixture := newRestoreFixture(t)
+	snapshot := &pizza.Snapshot{Version: pizza.SnapshotVersion, Revisions: map[string][]*pizza.RevisionDto{
+		"funghi": {
+			{Revision: 1, CreatedAt: time.Now(), Pizza: &pizza.PizzaDto{Name: "funghi", Ingredient: []pizza.IngredientDto{{Name: "mushroom"}}}},
+			{Revision: 2, CreatedAt: time.Now(), Deleted: true},
+		},
+	}}
+	body, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	_, err = fixture.restore(body, echo.MIMEApplicationJSON)
+
+	require.NoError(t, err)
+	pizzas, err := fixture.service.GetAll()
+	require.NoError(t, err)
+	assert.Empty(t, pizzas)
+}
@@ -0,0 +1,160 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/pizza"
+	. "golang-microservice-template/utils"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ActorSnapshot is the audit actor of the restore of the snapshot file on startup.
+const ActorSnapshot = "snapshot"
+
+// errors
+var (
+	ErrReadSnapshot   = "failed to read snapshot %s: %v"
+	ErrWriteSnapshot  = "failed to write snapshot %s: %v"
+	ErrEncodeSnapshot = "failed to encode snapshot: %v"
+	ErrDecodeSnapshot = "invalid snapshot: %v"
+)
+
+// Info describes a written or restored snapshot.
+type Info struct {
+	// File is the path of the snapshot file, empty for uploads and downloads.
+	File      string    `json:"file,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	Pizzas    int       `json:"pizzas"`
+}
+
+// Manager writes snapshots of all pizzas and restores them, so the in-memory repository survives restarts.
+type Manager interface {
+	// Load restores the snapshot file. It returns nil if the file does not exist.
+	Load() (*Info, error)
+	// Save writes a snapshot to the file. The file is replaced atomically, a failed write keeps the previous one.
+	Save() (*Info, error)
+	// Write writes a snapshot as JSON to w, e.g. a download.
+	Write(w io.Writer) (*Info, error)
+	// Restore replaces all pizzas with the JSON snapshot read from r, e.g. an upload.
+	Restore(origin audit.Origin, r io.Reader) (*Info, error)
+	// Run saves a snapshot at every interval until stop is closed. Failures are logged.
+	Run(interval time.Duration, stop <-chan struct{})
+}
+
+type manager struct {
+	service pizza.Service
+	file    string
+	log     LogWriter
+	// serializes writes of the file and restores
+	sync.Mutex
+}
+
+// NewManager creates a Manager that keeps the snapshots of the service in the given file.
+func NewManager(service pizza.Service, file string, log LogWriter) Manager {
+	return &manager{service: service, file: file, log: log}
+}
+
+func (m *manager) Load() (*Info, error) {
+	f, err := os.Open(m.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrReadSnapshot, m.file, err)
+	}
+	defer f.Close()
+
+	info, err := m.Restore(audit.Origin{Actor: ActorSnapshot}, f)
+	if err != nil {
+		return nil, err
+	}
+
+	info.File = m.file
+	return info, nil
+}
+
+func (m *manager) Save() (*Info, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	// The snapshot is written to a temporary file in the same directory and renamed, which is atomic.
+	dir := filepath.Dir(m.file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrWriteSnapshot, m.file, err)
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(m.file)+".*.tmp")
+	if err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrWriteSnapshot, m.file, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	info, err := m.Write(tmp)
+	if err != nil {
+		_ = tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return nil, Errorf(ErrorTypeInternalServer, ErrWriteSnapshot, m.file, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrWriteSnapshot, m.file, err)
+	}
+	if err := os.Rename(tmp.Name(), m.file); err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrWriteSnapshot, m.file, err)
+	}
+
+	info.File = m.file
+	return info, nil
+}
+
+func (m *manager) Write(w io.Writer) (*Info, error) {
+	snapshot, err := m.service.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		return nil, Errorf(ErrorTypeInternalServer, ErrEncodeSnapshot, err)
+	}
+
+	return &Info{CreatedAt: snapshot.CreatedAt, Pizzas: snapshot.Pizzas()}, nil
+}
+
+func (m *manager) Restore(origin audit.Origin, r io.Reader) (*Info, error) {
+	snapshot := &pizza.Snapshot{}
+	if err := json.NewDecoder(r).Decode(snapshot); err != nil {
+		return nil, Errorf(ErrorTypeBadRequest, ErrDecodeSnapshot, err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.service.Restore(origin, snapshot); err != nil {
+		return nil, err
+	}
+
+	return &Info{CreatedAt: snapshot.CreatedAt, Pizzas: snapshot.Pizzas()}, nil
+}
+
+func (m *manager) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if info, err := m.Save(); err != nil {
+				m.log.Errorf("snapshot failed: %v", err)
+			} else {
+				m.log.Debugf("saved snapshot of %d pizzas to %s", info.Pizzas, info.File)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package sse
+
+import (
+	"context"
+	"golang-microservice-template/events"
+	"sync"
+)
+
+const clientBufferSize = 64
+
+// Broker fans out events to the connected stream clients and keeps the latest events for resumption.
+type Broker interface {
+	// Handle forwards an event to all clients, it is subscribed to the events.Bus.
+	Handle(ctx context.Context, event *events.Event) error
+	// Subscribe connects a client. It returns the buffered events after lastEventID, or ok=false if
+	// lastEventID is set but no longer buffered, and a client that receives all further events.
+	Subscribe(lastEventID string) (replay []*events.Event, ok bool, client *Client)
+	// Unsubscribe disconnects a client.
+	Unsubscribe(client *Client)
+	// Close disconnects all clients and rejects new ones, e.g. during shutdown.
+	Close()
+}
+
+// Client is a connected stream client. Its channel is closed when the client is disconnected by the broker,
+// because the broker is closed or the client could not keep up.
+type Client struct {
+	Events <-chan *events.Event
+	events chan *events.Event
+}
+
+type broker struct {
+	clients map[*Client]bool
+	replay  []*events.Event
+	size    int
+	closed  bool
+	sync.Mutex
+}
+
+// NewBroker creates a Broker whose replay buffer holds the latest size events.
+func NewBroker(size int) Broker {
+	return &broker{
+		clients: make(map[*Client]bool),
+		replay:  []*events.Event{},
+		size:    size,
+	}
+}
+
+func (b *broker) Handle(_ context.Context, event *events.Event) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	for _, buffered := range b.replay {
+		// Events are delivered at least once, duplicates are not sent again.
+		if buffered.ID == event.ID {
+			return nil
+		}
+	}
+	if len(b.replay) >= b.size {
+		b.replay = append(b.replay[:0:0], b.replay[len(b.replay)-b.size+1:]...)
+	}
+	b.replay = append(b.replay, event)
+
+	for client := range b.clients {
+		select {
+		case client.events <- event:
+		default:
+			// A slow client is disconnected, it resumes with its last event id.
+			b.disconnect(client)
+		}
+	}
+
+	return nil
+}
+
+func (b *broker) Subscribe(lastEventID string) ([]*events.Event, bool, *Client) {
+	b.Lock()
+	defer b.Unlock()
+
+	ch := make(chan *events.Event, clientBufferSize)
+	client := &Client{Events: ch, events: ch}
+	if b.closed {
+		close(ch)
+		return nil, true, client
+	}
+	b.clients[client] = true
+
+	if lastEventID == "" {
+		return nil, true, client
+	}
+	for i, event := range b.replay {
+		if event.ID == lastEventID {
+			return append([]*events.Event{}, b.replay[i+1:]...), true, client
+		}
+	}
+	return nil, false, client
+}
+
+func (b *broker) Unsubscribe(client *Client) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.clients[client] {
+		b.disconnect(client)
+	}
+}
+
+func (b *broker) Close() {
+	b.Lock()
+	defer b.Unlock()
+
+	b.closed = true
+	for client := range b.clients {
+		b.disconnect(client)
+	}
+}
+
+// disconnect removes a client and closes its channel. The caller must hold the lock.
+func (b *broker) disconnect(client *Client) {
+	delete(b.clients, client)
+	close(client.events)
+}
@@ -0,0 +1,81 @@
+package sse
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-microservice-template/events"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// HeaderLastEventID is the request header that holds the id of the last event a reconnecting client received.
+	HeaderLastEventID = "Last-Event-ID"
+	// EventReset tells a resuming client that events were missed and it must reload the full state.
+	EventReset = "reset"
+
+	mimeEventStream = "text/event-stream"
+)
+
+// Handler returns a handler that streams the events of the broker as Server-Sent Events.
+// A comment is sent every heartbeat to keep idle connections open. The stream ends when the
+// client disconnects or the broker is closed.
+func Handler(broker Broker, heartbeat time.Duration) echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		replay, ok, client := broker.Subscribe(ctx.Request().Header.Get(HeaderLastEventID))
+		defer broker.Unsubscribe(client)
+
+		response := ctx.Response()
+		response.Header().Set(echo.HeaderContentType, mimeEventStream)
+		response.Header().Set("Cache-Control", "no-cache")
+		response.Header().Set("Connection", "keep-alive")
+		response.Header().Set("X-Accel-Buffering", "no")
+		response.WriteHeader(http.StatusOK)
+
+		if !ok {
+			if _, err := fmt.Fprintf(response, "event: %s\ndata: {}\n\n", EventReset); err != nil {
+				return nil
+			}
+		}
+		for _, event := range replay {
+			if err := write(response, event); err != nil {
+				return nil
+			}
+		}
+		response.Flush()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Request().Context().Done():
+				return nil
+			case <-ticker.C:
+				if _, err := fmt.Fprint(response, ": heartbeat\n\n"); err != nil {
+					return nil
+				}
+			case event, open := <-client.Events:
+				if !open {
+					return nil
+				}
+				if err := write(response, event); err != nil {
+					return nil
+				}
+			}
+			response.Flush()
+		}
+	}
+}
+
+// write sends an event with its id, so clients can resume after it.
+func write(response *echo.Response, event *events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(response, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,287 @@
+package sse
+
+import (
+	"bufio"
+	"context"
+	"golang-microservice-template/events"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// message is a block of the event stream, either an event or a comment.
+type message struct {
+	id      string
+	event   string
+	data    string
+	comment string
+}
+
+// stream is a connected client of the test server.
+type stream struct {
+	reader *bufio.Reader
+	body   io.Closer
+	cancel context.CancelFunc
+}
+
+// newServer serves the handler of the broker at /stream.
+func newServer(t *testing.T, broker Broker, heartbeat time.Duration) *httptest.Server {
+	e := echo.New()
+	e.GET("/stream", Handler(broker, heartbeat))
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// connect opens the stream with the given Last-Event-ID header, if any.
+func connect(t *testing.T, server *httptest.Server, lastEventID string) *stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, mimeEventStream, res.Header.Get(echo.HeaderContentType))
+	t.Cleanup(func() {
+		cancel()
+		res.Body.Close()
+	})
+
+	return &stream{reader: bufio.NewReader(res.Body), body: res.Body, cancel: cancel}
+}
+
+// next reads the next message, it fails the test if none arrives within a second.
+func (s *stream) next(t *testing.T) *message {
+	t.Helper()
+	messages := make(chan *message, 1)
+	errs := make(chan error, 1)
+	go func() {
+		m, err := s.read()
+		if err != nil {
+			errs <- err
+			return
+		}
+		messages <- m
+	}()
+
+	select {
+	case m := <-messages:
+		return m
+	case err := <-errs:
+		t.Fatalf("stream ended: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("no message within a second")
+	}
+	return nil
+}
+
+// nextEvent skips heartbeats and returns the next event.
+func (s *stream) nextEvent(t *testing.T) *message {
+	t.Helper()
+	for {
+		if m := s.next(t); m.comment == "" {
+			return m
+		}
+	}
+}
+
+// assertEnds fails unless the stream ends within a second, heartbeats and events before the end are skipped.
+func (s *stream) assertEnds(t *testing.T) {
+	done := make(chan error, 1)
+	go func() {
+		for {
+			if _, err := s.read(); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, io.EOF, err)
+	case <-time.After(time.Second):
+		t.Error("the stream did not end")
+	}
+}
+
+func (s *stream) read() (*message, error) {
+	m := &message{}
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return m, nil
+		case strings.HasPrefix(line, ": "):
+			m.comment = strings.TrimPrefix(line, ": ")
+		case strings.HasPrefix(line, "id: "):
+			m.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			m.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			m.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func newEvent(t *testing.T, revision int) *events.Event {
+	event, err := events.NewEvent("PizzaUpdated", "margherita", revision, time.Now(), map[string]int{"revision": revision})
+	require.NoError(t, err)
+	return event
+}
+
+// handle forwards the events to the broker.
+func handle(t *testing.T, broker Broker, events ...*events.Event) {
+	for _, event := range events {
+		require.NoError(t, broker.Handle(context.Background(), event))
+	}
+}
+
+// waitForClients waits until the broker has n connected clients.
+func waitForClients(t *testing.T, b Broker, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		b.(*broker).Lock()
+		connected := len(b.(*broker).clients)
+		b.(*broker).Unlock()
+		if connected == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d clients connected, expected %d", connected, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStreamSendsEvents(t *testing.T) {
+	broker := NewBroker(10)
+	s := connect(t, newServer(t, broker, time.Minute), "")
+	waitForClients(t, broker, 1)
+	event := newEvent(t, 1)
+
+	handle(t, broker, event)
+
+	m := s.nextEvent(t)
+	assert.Equal(t, event.ID, m.id)
+	assert.Equal(t, "PizzaUpdated", m.event)
+	assert.Contains(t, m.data, `"subject":"margherita"`)
+}
+
+func TestStreamSendsHeartbeats(t *testing.T) {
+	broker := NewBroker(10)
+	s := connect(t, newServer(t, broker, 10*time.Millisecond), "")
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "heartbeat", s.next(t).comment)
+	}
+}
+
+func TestStreamReplaysEventsAfterLastEventID(t *testing.T) {
+	broker := NewBroker(10)
+	server := newServer(t, broker, time.Minute)
+	first, second, third := newEvent(t, 1), newEvent(t, 2), newEvent(t, 3)
+	handle(t, broker, first, second, third)
+
+	s := connect(t, server, first.ID)
+
+	assert.Equal(t, second.ID, s.nextEvent(t).id)
+	assert.Equal(t, third.ID, s.nextEvent(t).id)
+	fourth := newEvent(t, 4)
+	handle(t, broker, fourth)
+	assert.Equal(t, fourth.ID, s.nextEvent(t).id, "the replay is followed by new events")
+}
+
+func TestStreamResetsClientsThatMissedEvents(t *testing.T) {
+	broker := NewBroker(2)
+	server := newServer(t, broker, time.Minute)
+	first := newEvent(t, 1)
+	handle(t, broker, first, newEvent(t, 2), newEvent(t, 3))
+
+	for name, lastEventID := range map[string]string{"dropped from the buffer": first.ID, "unknown": "unknown"} {
+		t.Run(name, func(t *testing.T) {
+			s := connect(t, server, lastEventID)
+
+			m := s.nextEvent(t)
+			assert.Equal(t, EventReset, m.event)
+			assert.Empty(t, m.id)
+		})
+	}
+}
+
+func TestStreamSkipsRedeliveredEvents(t *testing.T) {
+	broker := NewBroker(10)
+	s := connect(t, newServer(t, broker, time.Minute), "")
+	waitForClients(t, broker, 1)
+	first, second := newEvent(t, 1), newEvent(t, 2)
+
+	// the relay delivers at least once, e.g. again after a failed handler
+	handle(t, broker, first, first, second, first)
+
+	assert.Equal(t, first.ID, s.nextEvent(t).id)
+	assert.Equal(t, second.ID, s.nextEvent(t).id)
+	replay, ok, client := broker.Subscribe(first.ID)
+	broker.Unsubscribe(client)
+	assert.True(t, ok)
+	if assert.Len(t, replay, 1) {
+		assert.Equal(t, second.ID, replay[0].ID)
+	}
+}
+
+func TestStreamEndsWhenClientDisconnects(t *testing.T) {
+	broker := NewBroker(10)
+	s := connect(t, newServer(t, broker, time.Minute), "")
+	waitForClients(t, broker, 1)
+
+	s.cancel()
+
+	waitForClients(t, broker, 0)
+	handle(t, broker, newEvent(t, 1))
+}
+
+func TestStreamEndsWhenBrokerCloses(t *testing.T) {
+	broker := NewBroker(10)
+	server := newServer(t, broker, 10*time.Millisecond)
+	s := connect(t, server, "")
+	waitForClients(t, broker, 1)
+
+	broker.Close()
+
+	s.assertEnds(t)
+	connect(t, server, "").assertEnds(t)
+	waitForClients(t, broker, 0)
+}
+
+func TestSlowClientsAreDisconnected(t *testing.T) {
+	broker := NewBroker(clientBufferSize * 2)
+	_, _, client := broker.Subscribe("")
+
+	for i := 0; i <= clientBufferSize; i++ {
+		handle(t, broker, newEvent(t, i+1))
+	}
+
+	received := 0
+	for range client.Events {
+		received++
+	}
+	assert.Equal(t, clientBufferSize, received, "the channel is closed once the buffer of the client is full")
+	waitForClients(t, broker, 0)
+	broker.Unsubscribe(client)
+}
@@ -0,0 +1,98 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// errors
+var (
+	ErrReadClientCA         = "failed to read client CA bundle %s: %v"
+	ErrNoClientCA           = "client CA bundle %s contains no certificate"
+	ErrInvalidClientCert    = "invalid client certificate: %v"
+	ErrNoVerifiedConnection = "connection is not encrypted"
+)
+
+// ClientIdentity holds the names of a verified client certificate.
+type ClientIdentity struct {
+	// CommonName is the common name of the certificate subject.
+	CommonName string   `json:"commonName"`
+	DNSNames   []string `json:"dnsNames,omitempty"`
+	URIs       []string `json:"uris,omitempty"`
+	Emails     []string `json:"emails,omitempty"`
+}
+
+// Names returns the common name and all subject alternative names of the client.
+func (id *ClientIdentity) Names() []string {
+	names := []string{}
+	if id.CommonName != "" {
+		names = append(names, id.CommonName)
+	}
+	names = append(names, id.DNSNames...)
+	names = append(names, id.URIs...)
+	names = append(names, id.Emails...)
+	return names
+}
+
+// ClientVerifier verifies client certificates of TLS connections, independent of the protocol on top.
+type ClientVerifier interface {
+	// Verify checks the client certificate chain of the connection against the CA bundle.
+	// It returns nil without error if the client sent no certificate.
+	Verify(state *tls.ConnectionState) (*ClientIdentity, error)
+}
+
+type clientVerifier struct {
+	roots *x509.CertPool
+}
+
+// NewClientVerifier creates a ClientVerifier that trusts the certificates of the PEM encoded CA bundle.
+func NewClientVerifier(caFile string) (ClientVerifier, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf(ErrReadClientCA, caFile, err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf(ErrNoClientCA, caFile)
+	}
+
+	return &clientVerifier{roots: roots}, nil
+}
+
+func (v *clientVerifier) Verify(state *tls.ConnectionState) (*ClientIdentity, error) {
+	if state == nil {
+		return nil, errors.New(ErrNoVerifiedConnection)
+	}
+	if len(state.PeerCertificates) == 0 {
+		return nil, nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return nil, fmt.Errorf(ErrInvalidClientCert, err)
+	}
+
+	identity := &ClientIdentity{
+		CommonName: leaf.Subject.CommonName,
+		DNSNames:   leaf.DNSNames,
+		Emails:     leaf.EmailAddresses,
+	}
+	for _, uri := range leaf.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,111 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"golang-microservice-template/tlsutil/tlstest"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newVerifier returns a ClientVerifier that trusts the CA.
+func newVerifier(t *testing.T, ca *tlstest.Certificate) ClientVerifier {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, ca.CertPEM(), 0600))
+	verifier, err := NewClientVerifier(caFile)
+	require.NoError(t, err)
+	return verifier
+}
+
+func TestVerifyReturnsIdentityOfTrustedClients(t *testing.T) {
+	ca := tlstest.NewCA(t, "client CA")
+	verifier := newVerifier(t, ca)
+	uri, err := url.Parse("spiffe://example.com/ordering")
+	require.NoError(t, err)
+	client := ca.Issue(t, &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "ordering"},
+		DNSNames:       []string{"ordering.example.com"},
+		EmailAddresses: []string{"ordering@example.com"},
+		URIs:           []*url.URL{uri},
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+
+	identity, err := verifier.Verify(client.ConnectionState())
+
+	require.NoError(t, err)
+	assert.Equal(t, &ClientIdentity{
+		CommonName: "ordering",
+		DNSNames:   []string{"ordering.example.com"},
+		URIs:       []string{"spiffe://example.com/ordering"},
+		Emails:     []string{"ordering@example.com"},
+	}, identity)
+	assert.Equal(t, []string{"ordering", "ordering.example.com", "spiffe://example.com/ordering", "ordering@example.com"}, identity.Names())
+}
+
+func TestVerifyAcceptsChainsWithIntermediates(t *testing.T) {
+	ca := tlstest.NewCA(t, "client CA")
+	intermediate := ca.Issue(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "intermediate CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	})
+	client := intermediate.Client(t, "ordering")
+
+	identity, err := newVerifier(t, ca).Verify(client.ConnectionState(intermediate))
+
+	require.NoError(t, err)
+	assert.Equal(t, "ordering", identity.CommonName)
+}
+
+func TestVerifyWithoutCertificate(t *testing.T) {
+	verifier := newVerifier(t, tlstest.NewCA(t, "client CA"))
+
+	identity, err := verifier.Verify(&tls.ConnectionState{HandshakeComplete: true})
+	assert.NoError(t, err)
+	assert.Nil(t, identity, "clients without certificate are not identified")
+
+	_, err = verifier.Verify(nil)
+	assert.EqualError(t, err, ErrNoVerifiedConnection)
+}
+
+func TestVerifyRejectsInvalidCertificates(t *testing.T) {
+	ca := tlstest.NewCA(t, "client CA")
+	verifier := newVerifier(t, ca)
+
+	tests := map[string]*tlstest.Certificate{
+		"untrusted CA":    tlstest.NewCA(t, "other CA").Client(t, "ordering"),
+		"self-signed":     tlstest.NewCA(t, "ordering"),
+		"server usage":    ca.Server(t, "ordering.example.com"),
+		"no client usage": ca.Issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ordering"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}}),
+		"expired":         ca.Issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ordering"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, NotBefore: time.Now().Add(-2 * time.Hour), NotAfter: time.Now().Add(-time.Hour)}),
+		"not yet valid":   ca.Issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ordering"}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, NotBefore: time.Now().Add(time.Hour), NotAfter: time.Now().Add(2 * time.Hour)}),
+	}
+
+	for name, cert := range tests {
+		cert := cert
+		t.Run(name, func(t *testing.T) {
+			identity, err := verifier.Verify(cert.ConnectionState())
+
+			assert.Error(t, err)
+			assert.Nil(t, identity)
+		})
+	}
+}
+
+func TestNewClientVerifierRequiresCertificates(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(caFile, []byte("no certificate"), 0600))
+
+	_, err := NewClientVerifier(caFile)
+	assert.Error(t, err)
+	_, err = NewClientVerifier(filepath.Join(t.TempDir(), "missing.pem"))
+	assert.Error(t, err)
+}
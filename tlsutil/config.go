@@ -0,0 +1,27 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"golang-microservice-template/config"
+)
+
+// NewServerConfig creates the TLS configuration of the HTTPS listener with a certificate that is reloaded on change.
+// If requestClientCerts is set, clients are asked for a certificate during the handshake. The certificate is not
+// verified during the handshake but by a ClientVerifier, so failures can be answered like other authentication errors.
+func NewServerConfig(cfg config.TLSConfig, requestClientCerts bool) (*tls.Config, error) {
+	reloader, err := NewCertificateReloader(cfg.CertFile, cfg.KeyFile, cfg.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+	if requestClientCerts {
+		tlsConfig.ClientAuth = tls.RequestClientCert
+	}
+
+	return tlsConfig, nil
+}
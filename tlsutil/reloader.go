@@ -0,0 +1,95 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrLoadCertificate = "failed to load certificate %s: %v"
+)
+
+// CertificateReloader provides the server certificate and loads it again when its files change.
+type CertificateReloader interface {
+	// GetCertificate returns the current certificate, see tls.Config.GetCertificate.
+	GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+type certificateReloader struct {
+	certFile  string
+	keyFile   string
+	interval  time.Duration
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+	sync.Mutex
+}
+
+// NewCertificateReloader loads the certificate and its key. The files are checked for changes during
+// a handshake at most once per interval. If a changed certificate can not be loaded, the previous one is kept.
+func NewCertificateReloader(certFile, keyFile string, interval time.Duration) (CertificateReloader, error) {
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	if time.Since(r.checkedAt) >= r.interval {
+		r.checkedAt = time.Now()
+		if r.changed() {
+			if err := r.load(); err != nil {
+				Log.Errorf("keeping previous certificate: %v", err)
+			} else {
+				Log.Infof("reloaded certificate %s", r.certFile)
+			}
+		}
+	}
+
+	return r.cert, nil
+}
+
+// changed returns true if one of the files was modified since the certificate was loaded.
+func (r *certificateReloader) changed() bool {
+	return latestModTime(r.certFile, r.keyFile).After(r.modTime)
+}
+
+func (r *certificateReloader) load() error {
+	modTime := latestModTime(r.certFile, r.keyFile)
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf(ErrLoadCertificate, r.certFile, err)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func latestModTime(paths ...string) time.Time {
+	latest := time.Time{}
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
@@ -0,0 +1,72 @@
+package tlsutil
+
+import (
+	"crypto/x509"
+	"golang-microservice-template/tlsutil/tlstest"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// touch moves the modification time of the files forward, so a rewrite within the resolution
+// of the file system is detected.
+func touch(t *testing.T, paths ...string) {
+	next := time.Now().Add(time.Hour)
+	for _, path := range paths {
+		require.NoError(t, os.Chtimes(path, next, next))
+	}
+}
+
+func leaf(t *testing.T, reloader CertificateReloader) *x509.Certificate {
+	cert, err := reloader.GetCertificate(nil)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestCertificateReloaderLoadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test CA")
+	first := ca.Server(t, "pizza.example.com")
+	certFile, keyFile := first.WriteFiles(t, dir, "server")
+	reloader, err := NewCertificateReloader(certFile, keyFile, 20*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, first.Cert.SerialNumber, leaf(t, reloader).SerialNumber)
+
+	renewed := ca.Server(t, "pizza.example.com")
+	renewed.WriteFiles(t, dir, "server")
+	touch(t, certFile, keyFile)
+	assert.Equal(t, first.Cert.SerialNumber, leaf(t, reloader).SerialNumber, "the files are checked once per interval")
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, renewed.Cert.SerialNumber, leaf(t, reloader).SerialNumber)
+}
+
+func TestCertificateReloaderKeepsCertificateIfChangedFilesAreInvalid(t *testing.T) {
+	dir := t.TempDir()
+	ca := tlstest.NewCA(t, "test CA")
+	first := ca.Server(t, "pizza.example.com")
+	certFile, keyFile := first.WriteFiles(t, dir, "server")
+	reloader, err := NewCertificateReloader(certFile, keyFile, 0)
+	require.NoError(t, err)
+
+	// a renewal that replaced the certificate but not yet the key
+	require.NoError(t, ioutil.WriteFile(certFile, ca.Server(t, "pizza.example.com").CertPEM(), 0600))
+	touch(t, certFile)
+	assert.Equal(t, first.Cert.SerialNumber, leaf(t, reloader).SerialNumber)
+
+	require.NoError(t, ioutil.WriteFile(certFile, []byte("broken"), 0600))
+	touch(t, certFile)
+	assert.Equal(t, first.Cert.SerialNumber, leaf(t, reloader).SerialNumber)
+}
+
+func TestNewCertificateReloaderFailsWithoutFiles(t *testing.T) {
+	_, err := NewCertificateReloader("missing.pem", "missing-key.pem", time.Second)
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,138 @@
+// Package tlstest generates certificate authorities and certificates for tests.
+package tlstest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Certificate is a generated certificate together with its private key.
+type Certificate struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+// NewCA creates a self-signed certificate authority with the given common name.
+func NewCA(t testing.TB, commonName string) *Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	return create(t, template, nil)
+}
+
+// Issue creates a certificate signed by the CA. Serial number, validity and key usage are set if the template
+// leaves them empty, the extended key usage is taken as is.
+func (ca *Certificate) Issue(t testing.TB, template *x509.Certificate) *Certificate {
+	t.Helper()
+	if template.KeyUsage == 0 {
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+	}
+	return create(t, template, ca)
+}
+
+// Client creates a client certificate signed by the CA with the common name and DNS names.
+func (ca *Certificate) Client(t testing.TB, commonName string, dnsNames ...string) *Certificate {
+	t.Helper()
+	return ca.Issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    dnsNames,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+}
+
+// Server creates a server certificate signed by the CA for the DNS names.
+func (ca *Certificate) Server(t testing.TB, dnsNames ...string) *Certificate {
+	t.Helper()
+	return ca.Issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:    dnsNames,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+}
+
+// CertPEM returns the PEM encoded certificate.
+func (c *Certificate) CertPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+}
+
+// KeyPEM returns the PEM encoded private key.
+func (c *Certificate) KeyPEM(t testing.TB) []byte {
+	t.Helper()
+	der, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// WriteFiles writes the certificate and its key to name.pem and name-key.pem in dir and returns both paths.
+func (c *Certificate) WriteFiles(t testing.TB, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".pem")
+	keyFile = filepath.Join(dir, name+"-key.pem")
+	if err := ioutil.WriteFile(certFile, c.CertPEM(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, c.KeyPEM(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// ConnectionState returns the state of a TLS connection of a client that sent the certificate and the chain.
+func (c *Certificate) ConnectionState(chain ...*Certificate) *tls.ConnectionState {
+	peers := []*x509.Certificate{c.Cert}
+	for _, cert := range chain {
+		peers = append(peers, cert.Cert)
+	}
+	return &tls.ConnectionState{HandshakeComplete: true, PeerCertificates: peers}
+}
+
+func create(t testing.TB, template *x509.Certificate, issuer *Certificate) *Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if template.SerialNumber == nil {
+		if template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if template.NotBefore.IsZero() {
+		template.NotBefore = time.Now().Add(-time.Hour)
+	}
+	if template.NotAfter.IsZero() {
+		template.NotAfter = time.Now().Add(time.Hour)
+	}
+
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.Cert, issuer.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Certificate{Cert: cert, Key: key}
+}
@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"time"
+)
+
+// Backoff returns the delay before the given attempt of a retry, starting with base for the first
+// retry and doubling with every further attempt up to max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"time"
+)
+
+// Clock provides the current time, it can be replaced to control time in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+// SystemClock returns a Clock that uses the system time.
+func SystemClock() Clock {
+	return &systemClock{}
+}
+
+func (*systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -17,6 +17,12 @@ const (
 func Environment() string {
 	value, _ := os.LookupEnv("ENV")
 
+	return NormalizeEnvironment(value)
+}
+
+// NormalizeEnvironment maps an environment name or its abbreviation to one of the environment keywords.
+// Unknown names are treated as local environment.
+func NormalizeEnvironment(value string) string {
 	switch strings.ToLower(value) {
 	case ENV_PROD, "prod":
 		return ENV_PROD
@@ -19,18 +19,21 @@ const (
 
 // Keys for ErrorType
 const (
-	ErrorTypeBadRequest       = "BadRequest"
-	ErrorTypeBinding          = "Binding"
-	ErrorTypeValidation       = "Validation"
-	ErrorTypeResourceNotFound = "ResourceNotFound"
-	ErrorTypeURLNotFound      = "URLNotFound"
-	ErrorTypeDatabase         = "Database"
-	ErrorTypeInternalServer   = "InternalServer"
-	ErrorTypeBadGateway       = "BadGateway"
-	ErrorTypeUnauthorized     = "Unauthorized"
-	ErrorTypeForbidden        = "Forbidden"
-	ErrorTypeConflict         = "Conflict"
-	ErrorTypeTooManyRequests  = "TooManyRequests"
+	ErrorTypeBadRequest          = "BadRequest"
+	ErrorTypeBinding             = "Binding"
+	ErrorTypeValidation          = "Validation"
+	ErrorTypeResourceNotFound    = "ResourceNotFound"
+	ErrorTypeURLNotFound         = "URLNotFound"
+	ErrorTypeDatabase            = "Database"
+	ErrorTypeInternalServer      = "InternalServer"
+	ErrorTypeBadGateway          = "BadGateway"
+	ErrorTypeUnauthorized        = "Unauthorized"
+	ErrorTypeForbidden           = "Forbidden"
+	ErrorTypeConflict            = "Conflict"
+	ErrorTypeTooManyRequests     = "TooManyRequests"
+	ErrorTypeUnprocessableEntity = "UnprocessableEntity"
+	ErrorTypeServiceUnavailable  = "ServiceUnavailable"
+	ErrorTypeRequestTooLarge     = "RequestTooLarge"
 )
 
 // HasHTTPStatus Error Interface which contains an HTTP Status and a specific error type
@@ -119,6 +122,21 @@ type errorTooManyRequests struct {
 	CommonError
 }
 
+// errorServiceUnavailable Error for 503 Responses when the service temporarily rejects a request.
+type errorServiceUnavailable struct {
+	CommonError
+}
+
+// errorRequestTooLarge Error for 413 Responses when the request body exceeds a limit.
+type errorRequestTooLarge struct {
+	CommonError
+}
+
+// errorUnprocessableEntity Error for 422 Responses when a well-formed request cannot be processed.
+type errorUnprocessableEntity struct {
+	CommonError
+}
+
 func Errorf(xtype string, message string, args ...interface{}) error {
 	return Error(fmt.Sprintf(message, args...), xtype)
 }
@@ -157,6 +175,12 @@ func Error(i interface{}, xtype string) error {
 		return &errorConflict{CommonError{err, http.StatusConflict, xtype}}
 	case ErrorTypeTooManyRequests:
 		return &errorTooManyRequests{CommonError{err, http.StatusTooManyRequests, xtype}}
+	case ErrorTypeUnprocessableEntity:
+		return &errorUnprocessableEntity{CommonError{err, http.StatusUnprocessableEntity, xtype}}
+	case ErrorTypeServiceUnavailable:
+		return &errorServiceUnavailable{CommonError{err, http.StatusServiceUnavailable, xtype}}
+	case ErrorTypeRequestTooLarge:
+		return &errorRequestTooLarge{CommonError{err, http.StatusRequestEntityTooLarge, xtype}}
 	default:
 		return &errorInternalServer{CommonError{err, http.StatusInternalServerError, xtype}}
 	}
@@ -200,15 +224,20 @@ func HTTPErrorHandler(err error, c echo.Context) {
 
 	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
 
+	status, body := ErrorResponse(err, requestID)
+	err = c.JSON(status, body)
+}
+
+// ErrorResponse returns the HTTP status and the httpError body of an error, so other transports
+// can answer with the same errors as the REST handlers.
+func ErrorResponse(err error, requestID string) (int, error) {
 	switch err.(type) {
 	case *errorValidation:
-		err = c.JSON(err.(*errorValidation).Code, validationErrorToHTTPError(err.(*errorValidation), requestID))
+		return err.(*errorValidation).Code, validationErrorToHTTPError(err.(*errorValidation), requestID)
 	case HasHTTPStatus:
-		httpError := newHTTPError(err.(HasHTTPStatus), nil, requestID)
-		err = c.JSON(err.(HasHTTPStatus).GetHTTPStatusCode(), httpError)
+		return err.(HasHTTPStatus).GetHTTPStatusCode(), newHTTPError(err.(HasHTTPStatus), nil, requestID)
 	default:
-		httpError := newHTTPError(Error(err, ErrorTypeInternalServer).(HasHTTPStatus), nil, requestID)
-		err = c.JSON(http.StatusInternalServerError, httpError)
+		return http.StatusInternalServerError, newHTTPError(Error(err, ErrorTypeInternalServer).(HasHTTPStatus), nil, requestID)
 	}
 }
 
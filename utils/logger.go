@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 )
 
 type LogLevel string
@@ -16,16 +18,69 @@ const (
 	logLevelDebug LogLevel = "DEBUG"
 )
 
+// severities orders the log levels, messages below the configured level are dropped.
+var severities = map[LogLevel]int{
+	logLevelDebug: 0,
+	logLevelInfo:  1,
+	logLevelWarn:  2,
+	logLevelError: 3,
+}
+
+// errors
+var (
+	ErrUnknownLogLevel = "unknown log level %s"
+)
+
+// ParseLogLevel converts a case insensitive level name like "info" to a LogLevel.
+func ParseLogLevel(level string) (LogLevel, error) {
+	l := LogLevel(strings.ToUpper(level))
+	if _, ok := severities[l]; !ok {
+		return "", fmt.Errorf(ErrUnknownLogLevel, level)
+	}
+	return l, nil
+}
+
+// LogWriter is implemented by loggers that can be injected instead of the global Log instance.
+type LogWriter interface {
+	Debug(object interface{})
+	Debugf(message string, args ...interface{})
+	Info(object interface{})
+	Infof(message string, args ...interface{})
+	Warn(object interface{})
+	Warnf(message string, args ...interface{})
+	Error(object interface{})
+	Errorf(message string, args ...interface{})
+}
+
 // Log instance
 var Log = Logger()
 
 // Logger creates a new logger instance
 func Logger() *logInstance {
-	return &logInstance{}
+	return &logInstance{level: logLevelDebug}
 }
 
 // LogInstance model of logging instance
-type logInstance struct{}
+type logInstance struct {
+	level LogLevel
+	sync.RWMutex
+}
+
+// SetLevel sets the minimum level of messages that are written to the log.
+func (log *logInstance) SetLevel(level LogLevel) {
+	log.Lock()
+	defer log.Unlock()
+
+	log.level = level
+}
+
+// Level returns the minimum level of messages that are written to the log.
+func (log *logInstance) Level() LogLevel {
+	log.RLock()
+	defer log.RUnlock()
+
+	return log.level
+}
 
 // Warn prints a warning log message to stdout.
 func (log *logInstance) Warn(object interface{}) {
@@ -85,6 +140,10 @@ func (log *logInstance) Fatalf(message string, args ...interface{}) {
 }
 
 func (log *logInstance) writeToLog(loglevel LogLevel, object interface{}) {
+	if severities[loglevel] < severities[log.Level()] {
+		return
+	}
+
 	var logmap map[string]interface{}
 
 	switch object.(type) {
@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// errors
+var (
+	ErrForbiddenAddress  = "webhook url must not point to the internal address %s"
+	ErrUnresolvableHost  = "webhook host %s cannot be resolved: %v"
+	ErrInvalidDialTarget = "invalid webhook address %s"
+)
+
+// resolveTimeout bounds the lookup of a callback host when a subscription is created or changed.
+const resolveTimeout = 5 * time.Second
+
+// internalNetworks are the networks callbacks must not be delivered to unless private addresses are allowed:
+// loopback, link-local (e.g. cloud metadata endpoints), private, shared and unspecified addresses.
+var internalNetworks = parseNetworks(
+	"0.0.0.0/8",      // this network
+	"10.0.0.0/8",     // private
+	"100.64.0.0/10",  // shared address space of carrier-grade NAT
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local
+	"172.16.0.0/12",  // private
+	"192.168.0.0/16", // private
+	"224.0.0.0/4",    // multicast
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+	"ff00::/8",       // multicast
+)
+
+// lookupIPAddr resolves the host of a callback url, it is replaced in tests.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+// isInternal returns true if the address is in one of the internal networks, IPv4-mapped IPv6 addresses included.
+func isInternal(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost resolves the host of a callback url and fails if one of its addresses is internal.
+func checkHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternal(ip) {
+			return fmt.Errorf(ErrForbiddenAddress, ip)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	addresses, err := lookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf(ErrUnresolvableHost, host, err)
+	}
+	for _, address := range addresses {
+		if isInternal(address.IP) {
+			return fmt.Errorf(ErrForbiddenAddress, address.IP)
+		}
+	}
+	return nil
+}
+
+// checkDialAddress is the Control function of the dialer of the deliveries. It runs after the host was resolved,
+// so a host that resolves to an internal address after the subscription was checked is rejected as well.
+func checkDialAddress(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf(ErrInvalidDialTarget, address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf(ErrInvalidDialTarget, address)
+	}
+	if isInternal(ip) {
+		return fmt.Errorf(ErrForbiddenAddress, ip)
+	}
+	return nil
+}
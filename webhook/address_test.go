@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"golang-microservice-template/config"
+	"golang-microservice-template/events"
+	. "golang-microservice-template/utils"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHosts replaces the resolver of the callback hosts with the given hosts until the test ends.
+func fakeHosts(t *testing.T, hosts map[string][]string) {
+	previous := lookupIPAddr
+	lookupIPAddr = func(_ context.Context, host string) ([]net.IPAddr, error) {
+		ips, ok := hosts[host]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		addresses := []net.IPAddr{}
+		for _, ip := range ips {
+			addresses = append(addresses, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return addresses, nil
+	}
+	t.Cleanup(func() { lookupIPAddr = previous })
+}
+
+func TestIsInternal(t *testing.T) {
+	for ip, internal := range map[string]bool{
+		"127.0.0.1":        true,
+		"127.1.2.3":        true,
+		"10.1.2.3":         true,
+		"172.16.0.1":       true,
+		"172.31.255.255":   true,
+		"192.168.1.1":      true,
+		"169.254.169.254":  true,
+		"100.64.0.1":       true,
+		"0.0.0.0":          true,
+		"224.0.0.1":        true,
+		"::1":              true,
+		"::":               true,
+		"fe80::1":          true,
+		"fd00::1":          true,
+		"::ffff:127.0.0.1": true,
+		"::ffff:10.0.0.1":  true,
+		"172.32.0.1":       false,
+		"203.0.113.10":     false,
+		"8.8.8.8":          false,
+		"2001:db8::1":      false,
+	} {
+		assert.Equal(t, internal, isInternal(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestCreateRejectsInternalAddresses(t *testing.T) {
+	fakeHosts(t, map[string][]string{
+		"partner.example.com":  {"203.0.113.10"},
+		"localhost":            {"127.0.0.1", "::1"},
+		"metadata.internal":    {"169.254.169.254"},
+		"split.example.com":    {"203.0.113.10", "10.0.0.1"},
+		"intranet.example.com": {"192.168.1.1"},
+	})
+	service := NewService(NewMemoryStore(10), SystemClock(), nil, false, false)
+
+	tests := map[string]bool{
+		"http://partner.example.com/hook":      true,
+		"https://203.0.113.10:8443/hook":       true,
+		"http://[2001:db8::1]/hook":            true,
+		"http://localhost/hook":                false,
+		"http://127.0.0.1:8080/hook":           false,
+		"http://[::1]/hook":                    false,
+		"http://[::ffff:127.0.0.1]/hook":       false,
+		"http://169.254.169.254/latest":        false,
+		"http://metadata.internal/latest":      false,
+		"http://10.0.0.1/hook":                 false,
+		"http://split.example.com/hook":        false,
+		"http://intranet.example.com/hook":     false,
+		"http://unknown.example.com/hook":      false,
+		"http://0.0.0.0/hook":                  false,
+		"http://[fe80::1%25eth0]/hook":         false,
+		"http://partner.example.com@10.0.0.1/": false,
+	}
+
+	for url, allowed := range tests {
+		url, allowed := url, allowed
+		t.Run(url, func(t *testing.T) {
+			_, err := service.Create(url, nil)
+
+			if allowed {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, http.StatusBadRequest, err.(HasHTTPStatus).GetHTTPStatusCode(), err.Error())
+			}
+		})
+	}
+}
+
+func TestUpdateRejectsInternalAddresses(t *testing.T) {
+	store := NewMemoryStore(10)
+	service := NewService(store, SystemClock(), nil, false, false)
+	subscription, err := service.Create("http://203.0.113.10/hook", nil)
+	require.NoError(t, err)
+
+	url := "http://127.0.0.1/hook"
+	_, err = service.Update(subscription.ID, Changes{URL: &url})
+
+	assert.Error(t, err)
+	stored, err := store.FindByID(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "http://203.0.113.10/hook", stored.URL)
+}
+
+func TestPrivateAddressesCanBeAllowed(t *testing.T) {
+	service := NewService(NewMemoryStore(10), SystemClock(), nil, false, true)
+
+	_, err := service.Create("http://127.0.0.1:8080/hook", nil)
+
+	assert.NoError(t, err)
+}
+
+func TestDeliveriesRefuseInternalAddresses(t *testing.T) {
+	var requests int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	event, err := events.NewEvent("PizzaCreated", "margherita", 1, time.Now(), nil)
+	require.NoError(t, err)
+	// the subscription was saved while its host resolved to a public address
+	subscription := &Subscription{ID: "hook", URL: receiver.URL, Secret: "secret", Enabled: true}
+
+	for _, allowPrivate := range []bool{false, true} {
+		cfg := config.Default().Webhooks
+		cfg.AllowPrivateAddresses = allowPrivate
+		d := NewDispatcher(NewMemoryStore(10), SystemClock(), Log, cfg).(*dispatcher)
+
+		delivery, err := d.send(subscription, &job{subscriptionID: subscription.ID, event: event, body: []byte(`{}`), attempt: 1})
+
+		require.NoError(t, err)
+		assert.Equal(t, allowPrivate, delivery.Succeeded, delivery.Error)
+		if !allowPrivate {
+			assert.Contains(t, delivery.Error, "internal address 127.0.0.1")
+		}
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "only the allowed delivery reached the receiver")
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	. "golang-microservice-template/utils"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+const (
+	// PathParamID is the request path parameter that holds the subscription id.
+	PathParamID = "id"
+)
+
+// errors
+var (
+	ErrParamIDMissing = "missing webhook subscription id in path"
+)
+
+// Controller handles the requests to manage webhook subscriptions.
+type Controller interface {
+	// Create registers a new subscription and returns its signing secret once.
+	Create(echo.Context) error
+	// GetAll returns all subscriptions without their secrets.
+	GetAll(echo.Context) error
+	// GetByID returns a subscription without its secret.
+	GetByID(echo.Context) error
+	// Update changes the url, event types or enabled state of a subscription.
+	Update(echo.Context) error
+	// Delete removes a subscription.
+	Delete(echo.Context) error
+	// GetDeliveries returns the delivery log of a subscription, newest first.
+	GetDeliveries(echo.Context) error
+}
+
+type controller struct {
+	service Service
+}
+
+// NewController creates a new Controller that manages the subscriptions of the given service.
+func NewController(service Service) Controller {
+	return &controller{service: service}
+}
+
+func (c *controller) Create(ctx echo.Context) error {
+	dto := &CreateSubscriptionDto{}
+
+	if err := ctx.Bind(dto); err != nil {
+		return Error(err, ErrorTypeBinding)
+	}
+
+	if err := ctx.Validate(dto); err != nil {
+		return Error(err, ErrorTypeValidation)
+	}
+
+	subscription, err := c.service.Create(dto.URL, dto.EventTypes)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	result := subscription.ConvertToDto()
+	result.Secret = subscription.Secret
+
+	return ctx.JSON(http.StatusCreated, result)
+}
+
+func (c *controller) GetAll(ctx echo.Context) error {
+	subscriptions, err := c.service.List()
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	dtos := make([]*SubscriptionDto, len(subscriptions))
+	for i, subscription := range subscriptions {
+		dtos[i] = subscription.ConvertToDto()
+	}
+
+	return ctx.JSON(http.StatusOK, dtos)
+}
+
+func (c *controller) GetByID(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := c.service.Get(id)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return ctx.JSON(http.StatusOK, subscription.ConvertToDto())
+}
+
+func (c *controller) Update(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	dto := &UpdateSubscriptionDto{}
+	if err := ctx.Bind(dto); err != nil {
+		return Error(err, ErrorTypeBinding)
+	}
+
+	if err := ctx.Validate(dto); err != nil {
+		return Error(err, ErrorTypeValidation)
+	}
+
+	subscription, err := c.service.Update(id, Changes{URL: dto.URL, EventTypes: dto.EventTypes, Enabled: dto.Enabled})
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return ctx.JSON(http.StatusOK, subscription.ConvertToDto())
+}
+
+func (c *controller) Delete(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.service.Delete(id); err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *controller) GetDeliveries(ctx echo.Context) error {
+	id, err := checkIDInPath(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := c.service.Deliveries(id)
+	if err != nil {
+		return Error(err, ErrorTypeDatabase)
+	}
+
+	dtos := make([]*DeliveryDto, len(deliveries))
+	for i, delivery := range deliveries {
+		dtos[i] = delivery.ConvertToDto()
+	}
+
+	return ctx.JSON(http.StatusOK, dtos)
+}
+
+func checkIDInPath(ctx echo.Context) (string, error) {
+	id := ctx.Param(PathParamID)
+	if id == "" {
+		return "", Error(ErrParamIDMissing, ErrorTypeBadRequest)
+	}
+	return id, nil
+}
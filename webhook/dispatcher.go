@@ -0,0 +1,276 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-microservice-template/config"
+	"golang-microservice-template/events"
+	. "golang-microservice-template/utils"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	queueSize       = 1000
+	maxResponseSize = 64 * 1024
+	userAgent       = "pizza-service-webhooks"
+)
+
+// errors
+var (
+	ErrQueueFull         = "webhook delivery queue is full"
+	ErrDispatcherStopped = "webhook dispatcher is stopped"
+	ErrUnexpectedStatus  = "unexpected status %d"
+)
+
+// Dispatcher delivers events to the matching webhook subscriptions. Failed deliveries are retried with
+// exponential backoff, subscriptions are disabled after repeated consecutive failures.
+type Dispatcher interface {
+	// Handle queues the deliveries of an event to all matching subscriptions, it is subscribed to the events.Bus.
+	// An error means the event could not be queued for any subscription and must be handled again.
+	Handle(ctx context.Context, event *events.Event) error
+	// Start starts the delivery workers.
+	Start()
+	// Stop stops accepting events and waits for the running deliveries until the context is done.
+	// Queued deliveries and pending retries are dropped.
+	Stop(ctx context.Context) error
+}
+
+type job struct {
+	subscriptionID string
+	event          *events.Event
+	body           []byte
+	attempt        int
+}
+
+type dispatcher struct {
+	store   Store
+	client  *http.Client
+	clock   Clock
+	log     LogWriter
+	config  config.WebhookConfig
+	queue   chan *job
+	stop    chan struct{}
+	workers sync.WaitGroup
+	// enqueue serializes Handle, so the free capacity of the queue is known while an event is queued.
+	enqueue sync.Mutex
+}
+
+// NewDispatcher creates a Dispatcher that delivers events to the subscriptions of the store.
+// Connections to loopback, link-local and private addresses are refused unless the configuration allows them.
+func NewDispatcher(store Store, clock Clock, log LogWriter, cfg config.WebhookConfig) Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateAddresses {
+		dialer.Control = checkDialAddress
+	}
+
+	return &dispatcher{
+		store: store,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// No proxy is used, so the dialer sees the address of the receiver.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: cfg.Timeout,
+				MaxIdleConnsPerHost: cfg.Workers,
+			},
+			// Redirects are not followed, receivers must answer at the registered url.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		clock:  clock,
+		log:    log,
+		config: cfg,
+		queue:  make(chan *job, queueSize),
+		stop:   make(chan struct{}),
+	}
+}
+
+func (d *dispatcher) Handle(_ context.Context, event *events.Event) error {
+	subscriptions, err := d.store.FindAll()
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	jobs := []*job{}
+	for _, subscription := range subscriptions {
+		if subscription.Enabled && subscription.Matches(event.Type) {
+			jobs = append(jobs, &job{subscriptionID: subscription.ID, event: event, body: body, attempt: 1})
+		}
+	}
+
+	d.enqueue.Lock()
+	defer d.enqueue.Unlock()
+
+	// The event is queued for all subscriptions or none, a handled again event must not be delivered twice.
+	// Only retries can take the free capacity in between, then the sends wait for the workers.
+	if len(jobs) > cap(d.queue)-len(d.queue) {
+		return errors.New(ErrQueueFull)
+	}
+	for _, j := range jobs {
+		select {
+		case <-d.stop:
+			return errors.New(ErrDispatcherStopped)
+		case d.queue <- j:
+		}
+	}
+
+	return nil
+}
+
+func (d *dispatcher) Start() {
+	for i := 0; i < d.config.Workers; i++ {
+		d.workers.Add(1)
+		go func() {
+			defer d.workers.Done()
+			for {
+				select {
+				case <-d.stop:
+					return
+				case j := <-d.queue:
+					d.deliver(j)
+				}
+			}
+		}()
+	}
+}
+
+func (d *dispatcher) Stop(ctx context.Context) error {
+	close(d.stop)
+
+	done := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver sends an event to a subscription, records the attempt and schedules a retry on failure.
+func (d *dispatcher) deliver(j *job) {
+	subscription, err := d.store.FindByID(j.subscriptionID)
+	if err != nil || !subscription.Enabled {
+		return
+	}
+
+	delivery, err := d.send(subscription, j)
+	if err != nil {
+		d.log.Errorf("failed to deliver event %s to webhook %s: %v", j.event.ID, subscription.ID, err)
+		return
+	}
+	if err := d.store.AddDelivery(delivery); err != nil {
+		d.log.Debug(err)
+	}
+
+	if disabled := d.recordResult(subscription.ID, delivery.Succeeded); delivery.Succeeded || disabled {
+		return
+	}
+
+	if j.attempt >= d.config.MaxAttempts {
+		d.log.Warnf("giving up delivery of event %s to webhook %s after %d attempts", j.event.ID, subscription.ID, j.attempt)
+		return
+	}
+
+	retry := &job{subscriptionID: j.subscriptionID, event: j.event, body: j.body, attempt: j.attempt + 1}
+	delay := Backoff(j.attempt, d.config.RetryBaseDelay, d.config.RetryMaxDelay)
+	d.log.Infof("retrying delivery of event %s to webhook %s in %s: %s", j.event.ID, subscription.ID, delay, delivery.Error)
+	d.schedule(retry, delay)
+}
+
+// send makes a single delivery request. An error means no request could be created.
+func (d *dispatcher) send(subscription *Subscription, j *job) (*Delivery, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(j.body))
+	if err != nil {
+		return nil, err
+	}
+
+	start := d.clock.Now()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(HeaderSignature, Sign(subscription.Secret, start, j.body))
+	request.Header.Set(HeaderEventType, j.event.Type)
+	request.Header.Set(HeaderEventID, j.event.ID)
+	request.Header.Set(HeaderDeliveryID, id)
+
+	delivery := &Delivery{
+		ID:             id,
+		SubscriptionID: subscription.ID,
+		EventID:        j.event.ID,
+		EventType:      j.event.Type,
+		Attempt:        j.attempt,
+		CreatedAt:      start,
+	}
+
+	response, err := d.client.Do(request)
+	delivery.Duration = d.clock.Now().Sub(start)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery, nil
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, maxResponseSize))
+
+	delivery.StatusCode = response.StatusCode
+	delivery.Succeeded = response.StatusCode >= 200 && response.StatusCode < 300
+	if !delivery.Succeeded {
+		delivery.Error = fmt.Sprintf(ErrUnexpectedStatus, response.StatusCode)
+	}
+
+	return delivery, nil
+}
+
+// recordResult updates the failure count of a subscription and disables it after too many
+// consecutive failures. It returns true if the subscription is disabled.
+func (d *dispatcher) recordResult(id string, succeeded bool) bool {
+	subscription, err := d.store.Modify(id, func(subscription *Subscription) error {
+		if succeeded {
+			subscription.ConsecutiveFailures = 0
+			return nil
+		}
+
+		subscription.ConsecutiveFailures++
+		if subscription.ConsecutiveFailures >= d.config.DisableAfterFailures && subscription.Enabled {
+			now := d.clock.Now()
+			subscription.Enabled = false
+			subscription.DisabledAt = &now
+			d.log.Warnf("disabled webhook %s after %d consecutive failures", id, subscription.ConsecutiveFailures)
+		}
+		return nil
+	})
+	if err != nil {
+		return true
+	}
+	return !subscription.Enabled
+}
+
+// schedule queues a job after the delay unless the dispatcher is stopped before.
+func (d *dispatcher) schedule(j *job, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case <-d.stop:
+		case d.queue <- j:
+		}
+	})
+}
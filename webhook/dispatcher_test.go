@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	"context"
+	"golang-microservice-template/config"
+	"golang-microservice-template/events"
+	. "golang-microservice-template/utils"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(store Store) *dispatcher {
+	cfg := config.Default().Webhooks
+	cfg.DisableAfterFailures = 1000
+	return NewDispatcher(store, SystemClock(), Log, cfg).(*dispatcher)
+}
+
+func TestFailureCountsAndUpdatesDoNotOverwriteEachOther(t *testing.T) {
+	store := NewMemoryStore(10)
+	service := NewService(store, SystemClock(), []string{"PizzaCreated"}, false, false)
+	d := newTestDispatcher(store)
+	subscription, err := service.Create("http://203.0.113.10/hook", nil)
+	require.NoError(t, err)
+
+	const failures = 200
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < failures; i++ {
+			d.recordResult(subscription.ID, false)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < failures; i++ {
+			url := "http://203.0.113.10/hook"
+			_, err := service.Update(subscription.ID, Changes{URL: &url})
+			assert.NoError(t, err)
+		}
+		url := "http://203.0.113.10/moved"
+		_, err := service.Update(subscription.ID, Changes{URL: &url})
+		assert.NoError(t, err)
+	}()
+	wg.Wait()
+
+	stored, err := store.FindByID(subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, failures, stored.ConsecutiveFailures)
+	assert.Equal(t, "http://203.0.113.10/moved", stored.URL)
+}
+
+func TestRecordResultDisablesSubscription(t *testing.T) {
+	store := NewMemoryStore(10)
+	service := NewService(store, SystemClock(), nil, false, false)
+	d := newTestDispatcher(store)
+	d.config.DisableAfterFailures = 2
+	subscription, err := service.Create("http://203.0.113.10/hook", nil)
+	require.NoError(t, err)
+
+	assert.False(t, d.recordResult(subscription.ID, false))
+	assert.True(t, d.recordResult(subscription.ID, false))
+
+	stored, _ := store.FindByID(subscription.ID)
+	assert.False(t, stored.Enabled)
+	assert.NotNil(t, stored.DisabledAt)
+}
+
+func TestInvalidUpdateIsNotStored(t *testing.T) {
+	store := NewMemoryStore(10)
+	service := NewService(store, SystemClock(), nil, false, false)
+	subscription, err := service.Create("http://203.0.113.10/hook", nil)
+	require.NoError(t, err)
+
+	url := "ftp://example.com/hook"
+	_, err = service.Update(subscription.ID, Changes{URL: &url})
+
+	assert.Error(t, err)
+	stored, _ := store.FindByID(subscription.ID)
+	assert.Equal(t, "http://203.0.113.10/hook", stored.URL)
+}
+
+func TestHandleQueuesAllOrNoDeliveries(t *testing.T) {
+	store := NewMemoryStore(10)
+	service := NewService(store, SystemClock(), nil, false, false)
+	d := newTestDispatcher(store)
+	for i := 0; i < 3; i++ {
+		_, err := service.Create("http://203.0.113.10/hook", nil)
+		require.NoError(t, err)
+	}
+	event, err := events.NewEvent("PizzaCreated", "margherita", 1, time.Now(), nil)
+	require.NoError(t, err)
+
+	// two free slots for three subscriptions
+	for len(d.queue) < cap(d.queue)-2 {
+		d.queue <- &job{}
+	}
+	assert.EqualError(t, d.Handle(context.Background(), event), ErrQueueFull)
+	assert.Equal(t, cap(d.queue)-2, len(d.queue))
+
+	<-d.queue
+	require.NoError(t, d.Handle(context.Background(), event))
+	assert.Equal(t, cap(d.queue), len(d.queue))
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"time"
+)
+
+// SubscriptionDto represents the webhook subscription information that will be exposed from this service.
+type SubscriptionDto struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"events"`
+	Enabled             bool       `json:"enabled"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	// Secret is the signing secret, it is only returned when a subscription is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+// CreateSubscriptionDto is the request to create a new webhook subscription.
+type CreateSubscriptionDto struct {
+	URL        string   `json:"url" validate:"required,url,max=2048"`
+	EventTypes []string `json:"events" validate:"dive,required"`
+}
+
+// UpdateSubscriptionDto is the request to change a webhook subscription, missing fields are kept.
+type UpdateSubscriptionDto struct {
+	URL        *string   `json:"url" validate:"omitempty,url,max=2048"`
+	EventTypes *[]string `json:"events"`
+	Enabled    *bool     `json:"enabled"`
+}
+
+// DeliveryDto represents a delivery attempt that will be exposed from this service.
+type DeliveryDto struct {
+	ID         string    `json:"id"`
+	EventID    string    `json:"eventId"`
+	EventType  string    `json:"eventType"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Succeeded  bool      `json:"succeeded"`
+	DurationMs int64     `json:"durationMs"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// ConvertToDto converts a Subscription model to a Subscription dto without the secret.
+func (s *Subscription) ConvertToDto() *SubscriptionDto {
+	return &SubscriptionDto{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		EventTypes:          append([]string{}, s.EventTypes...),
+		Enabled:             s.Enabled,
+		CreatedAt:           s.CreatedAt,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+	}
+}
+
+// ConvertToDto converts a Delivery model to a Delivery dto.
+func (d *Delivery) ConvertToDto() *DeliveryDto {
+	return &DeliveryDto{
+		ID:         d.ID,
+		EventID:    d.EventID,
+		EventType:  d.EventType,
+		Attempt:    d.Attempt,
+		StatusCode: d.StatusCode,
+		Error:      d.Error,
+		Succeeded:  d.Succeeded,
+		DurationMs: d.Duration.Milliseconds(),
+		CreatedAt:  d.CreatedAt,
+	}
+}
@@ -0,0 +1,181 @@
+package webhook
+
+import (
+	"net/url"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrInvalidURL       = "webhook url must be an absolute %s url, got '%s'"
+	ErrUnknownEventType = "unknown event type '%s', expected one of %v"
+)
+
+// Changes holds the fields of a subscription to update, nil fields are kept.
+type Changes struct {
+	URL        *string
+	EventTypes *[]string
+	// Enabled re-enables a disabled subscription and resets its failure count.
+	Enabled *bool
+}
+
+// Service manages webhook subscriptions.
+type Service interface {
+	// Create registers a callback URL for the given event types, all events if empty.
+	// The subscription contains the generated signing secret.
+	Create(url string, eventTypes []string) (*Subscription, error)
+	// List returns all subscriptions.
+	List() ([]*Subscription, error)
+	// Get returns a subscription by its id.
+	Get(id string) (*Subscription, error)
+	// Update changes a subscription.
+	Update(id string, changes Changes) (*Subscription, error)
+	// Delete removes a subscription.
+	Delete(id string) error
+	// Deliveries returns the delivery log of a subscription, newest first.
+	Deliveries(id string) ([]*Delivery, error)
+}
+
+type service struct {
+	store                 Store
+	clock                 Clock
+	eventTypes            []string
+	requireHTTPS          bool
+	allowPrivateAddresses bool
+}
+
+// NewService creates a new Service that accepts subscriptions to the given event types.
+// If requireHTTPS is set, callback URLs must use HTTPS. Unless allowPrivateAddresses is set, callback hosts
+// are resolved and URLs that point to loopback, link-local or private addresses are rejected.
+func NewService(store Store, clock Clock, eventTypes []string, requireHTTPS, allowPrivateAddresses bool) Service {
+	return &service{
+		store:                 store,
+		clock:                 clock,
+		eventTypes:            eventTypes,
+		requireHTTPS:          requireHTTPS,
+		allowPrivateAddresses: allowPrivateAddresses,
+	}
+}
+
+func (s *service) Create(callbackURL string, eventTypes []string) (*Subscription, error) {
+	if err := s.validate(callbackURL, eventTypes); err != nil {
+		return nil, err
+	}
+	if err := s.checkAddress(callbackURL); err != nil {
+		return nil, err
+	}
+
+	id, err := generateID()
+	if err != nil {
+		return nil, Error(err, ErrorTypeInternalServer)
+	}
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, Error(err, ErrorTypeInternalServer)
+	}
+
+	subscription := &Subscription{
+		ID:         id,
+		URL:        callbackURL,
+		EventTypes: append([]string{}, eventTypes...),
+		Secret:     secret,
+		Enabled:    true,
+		CreatedAt:  s.clock.Now(),
+	}
+	if err := s.store.Save(subscription); err != nil {
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (s *service) List() ([]*Subscription, error) {
+	return s.store.FindAll()
+}
+
+func (s *service) Get(id string) (*Subscription, error) {
+	return s.store.FindByID(id)
+}
+
+func (s *service) Update(id string, changes Changes) (*Subscription, error) {
+	// The host is resolved before the store is locked, the url is validated again within the store.
+	if changes.URL != nil {
+		if err := s.checkAddress(*changes.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	// The dispatcher changes the failure count concurrently, so the changes are applied within the store.
+	return s.store.Modify(id, func(subscription *Subscription) error {
+		if changes.URL != nil {
+			subscription.URL = *changes.URL
+		}
+		if changes.EventTypes != nil {
+			subscription.EventTypes = append([]string{}, *changes.EventTypes...)
+		}
+		if changes.Enabled != nil {
+			subscription.Enabled = *changes.Enabled
+			if subscription.Enabled {
+				subscription.ConsecutiveFailures = 0
+				subscription.DisabledAt = nil
+			}
+		}
+
+		return s.validate(subscription.URL, subscription.EventTypes)
+	})
+}
+
+func (s *service) Delete(id string) error {
+	return s.store.Delete(id)
+}
+
+func (s *service) Deliveries(id string) ([]*Delivery, error) {
+	return s.store.FindDeliveries(id)
+}
+
+func (s *service) validate(callbackURL string, eventTypes []string) error {
+	scheme := "http(s)"
+	if s.requireHTTPS {
+		scheme = "https"
+	}
+
+	u, err := url.Parse(callbackURL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && (s.requireHTTPS || u.Scheme != "http")) {
+		return Errorf(ErrorTypeBadRequest, ErrInvalidURL, scheme, callbackURL)
+	}
+
+	for _, eventType := range eventTypes {
+		if !containsString(s.eventTypes, eventType) {
+			return Errorf(ErrorTypeBadRequest, ErrUnknownEventType, eventType, s.eventTypes)
+		}
+	}
+
+	return nil
+}
+
+// checkAddress rejects callback urls whose host is or resolves to an internal address. The deliveries check
+// the address they connect to again, since the host may resolve to another address later.
+func (s *service) checkAddress(callbackURL string) error {
+	if s.allowPrivateAddresses {
+		return nil
+	}
+
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return Errorf(ErrorTypeBadRequest, ErrInvalidURL, "http(s)", callbackURL)
+	}
+	if err := checkHost(u.Hostname()); err != nil {
+		return Error(err, ErrorTypeBadRequest)
+	}
+	return nil
+}
+
+func containsString(values []string, wanted string) bool {
+	for _, v := range values {
+		if v == wanted {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a delivery request
+const (
+	HeaderSignature  = "X-Webhook-Signature"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderEventID    = "X-Webhook-Event-ID"
+	HeaderDeliveryID = "X-Webhook-Delivery"
+)
+
+// errors
+var (
+	ErrMalformedSignature = "malformed webhook signature"
+	ErrSignatureMismatch  = "webhook signature does not match"
+	ErrSignatureExpired   = "webhook signature is too old"
+)
+
+// Sign returns the signature header value of a delivery body sent at the given time, in the
+// format t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>" with the secret>.
+// The timestamp is signed as well, so receivers can reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks the signature header of a delivery received at now. Signatures older than
+// tolerance are rejected, a tolerance of 0 disables the check.
+func Verify(secret, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var t, signature string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return errors.New(ErrMalformedSignature)
+		}
+		switch kv[0] {
+		case "t":
+			t = kv[1]
+		case "v1":
+			signature = kv[1]
+		}
+	}
+
+	seconds, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || signature == "" {
+		return errors.New(ErrMalformedSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(mac(secret, t, body))) {
+		return errors.New(ErrSignatureMismatch)
+	}
+	if tolerance > 0 && now.Sub(time.Unix(seconds, 0)) > tolerance {
+		return errors.New(ErrSignatureExpired)
+	}
+
+	return nil
+}
+
+func mac(secret, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"sort"
+	"sync"
+
+	. "golang-microservice-template/utils"
+)
+
+// errors
+var (
+	ErrSubscriptionNotFound = "webhook subscription %s not found"
+)
+
+// Store persists webhook subscriptions and their delivery log.
+type Store interface {
+	// FindAll returns all subscriptions ordered by creation time.
+	FindAll() ([]*Subscription, error)
+	// FindByID finds a subscription by its id.
+	FindByID(id string) (*Subscription, error)
+	// Save persists a new subscription.
+	Save(subscription *Subscription) error
+	// Update replaces an existing subscription.
+	Update(subscription *Subscription) error
+	// Modify applies change to a copy of a subscription and stores the copy, unless change returns an error.
+	// No other change of the subscription happens in between.
+	Modify(id string, change func(subscription *Subscription) error) (*Subscription, error)
+	// Delete removes a subscription and its delivery log.
+	Delete(id string) error
+	// AddDelivery appends a delivery to the log of its subscription.
+	AddDelivery(delivery *Delivery) error
+	// FindDeliveries returns the delivery log of a subscription, newest first.
+	FindDeliveries(subscriptionID string) ([]*Delivery, error)
+}
+
+type memoryStore struct {
+	subscriptions map[string]*Subscription
+	deliveries    map[string][]*Delivery
+	logSize       int
+	sync.RWMutex
+}
+
+// NewMemoryStore creates a store that keeps all subscriptions in memory and the latest
+// logSize deliveries of each subscription.
+func NewMemoryStore(logSize int) Store {
+	return &memoryStore{
+		subscriptions: make(map[string]*Subscription),
+		deliveries:    make(map[string][]*Delivery),
+		logSize:       logSize,
+	}
+}
+
+func (s *memoryStore) FindAll() ([]*Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	list := []*Subscription{}
+	for _, subscription := range s.subscriptions {
+		list = append(list, subscription.copy())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+
+	return list, nil
+}
+
+func (s *memoryStore) FindByID(id string) (*Subscription, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	subscription, ok := s.subscriptions[id]
+	if !ok {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, id)
+	}
+
+	return subscription.copy(), nil
+}
+
+func (s *memoryStore) Save(subscription *Subscription) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.subscriptions[subscription.ID] = subscription.copy()
+
+	return nil
+}
+
+func (s *memoryStore) Update(subscription *Subscription) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.subscriptions[subscription.ID]; !ok {
+		return Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, subscription.ID)
+	}
+	s.subscriptions[subscription.ID] = subscription.copy()
+
+	return nil
+}
+
+func (s *memoryStore) Modify(id string, change func(subscription *Subscription) error) (*Subscription, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	stored, ok := s.subscriptions[id]
+	if !ok {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, id)
+	}
+	subscription := stored.copy()
+	if err := change(subscription); err != nil {
+		return nil, err
+	}
+	s.subscriptions[id] = subscription.copy()
+
+	return subscription, nil
+}
+
+func (s *memoryStore) Delete(id string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.subscriptions[id]; !ok {
+		return Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, id)
+	}
+	delete(s.subscriptions, id)
+	delete(s.deliveries, id)
+
+	return nil
+}
+
+func (s *memoryStore) AddDelivery(delivery *Delivery) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if _, ok := s.subscriptions[delivery.SubscriptionID]; !ok {
+		return Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, delivery.SubscriptionID)
+	}
+
+	log := s.deliveries[delivery.SubscriptionID]
+	if len(log) >= s.logSize {
+		log = append(log[:0:0], log[len(log)-s.logSize+1:]...)
+	}
+	copied := *delivery
+	s.deliveries[delivery.SubscriptionID] = append(log, &copied)
+
+	return nil
+}
+
+func (s *memoryStore) FindDeliveries(subscriptionID string) ([]*Delivery, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if _, ok := s.subscriptions[subscriptionID]; !ok {
+		return nil, Errorf(ErrorTypeResourceNotFound, ErrSubscriptionNotFound, subscriptionID)
+	}
+
+	log := s.deliveries[subscriptionID]
+	list := make([]*Delivery, 0, len(log))
+	for i := len(log) - 1; i >= 0; i-- {
+		copied := *log[i]
+		list = append(list, &copied)
+	}
+
+	return list, nil
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	idSize       = 8
+	secretSize   = 32
+	secretPrefix = "whsec_"
+)
+
+// Subscription is a callback URL that receives the events of the given types.
+type Subscription struct {
+	ID  string
+	URL string
+	// EventTypes are the events that are delivered, all events if empty.
+	EventTypes []string
+	// Secret is the key of the HMAC signature of every delivery.
+	Secret    string
+	Enabled   bool
+	CreatedAt time.Time
+	// ConsecutiveFailures counts the failed delivery attempts since the last successful one.
+	ConsecutiveFailures int
+	// DisabledAt is set if the subscription was disabled automatically after repeated failures.
+	DisabledAt *time.Time
+}
+
+// Matches returns true if the subscription receives events of the given type.
+func (s *Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// copy returns a copy of the subscription that shares no data with it.
+func (s *Subscription) copy() *Subscription {
+	c := *s
+	c.EventTypes = append([]string(nil), s.EventTypes...)
+	if s.DisabledAt != nil {
+		disabledAt := *s.DisabledAt
+		c.DisabledAt = &disabledAt
+	}
+	return &c
+}
+
+// Delivery records an attempt to deliver an event to a subscription.
+type Delivery struct {
+	ID             string
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Attempt        int
+	// StatusCode is the HTTP status of the response, 0 if no response was received.
+	StatusCode int
+	Error      string
+	Succeeded  bool
+	Duration   time.Duration
+	CreatedAt  time.Time
+}
+
+// generateSecret returns a new random signing secret.
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateID returns a new random subscription or delivery id.
+func generateID() (string, error) {
+	b := make([]byte, idSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
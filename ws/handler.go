@@ -0,0 +1,378 @@
+package ws
+
+import (
+	"encoding/json"
+	"golang-microservice-template/audit"
+	"golang-microservice-template/auth"
+	"golang-microservice-template/events"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/ratelimit"
+	"golang-microservice-template/sse"
+	. "golang-microservice-template/utils"
+	"math"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+)
+
+const (
+	maxMessageSize = 64 * 1024
+	sendBufferSize = 64
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = pongWait * 9 / 10
+)
+
+// errors
+var (
+	ErrUnknownCommand = "unknown command type '%s'"
+	ErrMissingPizza   = "missing pizza in %s command"
+	ErrMissingName    = "missing pizza name in %s command"
+)
+
+// HandlerOption sets an optional dependency of the WebSocket handler.
+type HandlerOption func(*handler)
+
+// WithPolicies authorizes commands by type with the same policies as the REST routes.
+// Commands without policy are allowed, no command is authorized if unset.
+func WithPolicies(policies map[string]auth.Policy) HandlerOption {
+	return func(h *handler) {
+		h.policies = policies
+	}
+}
+
+// WithRateLimiter limits the commands of each client with the buckets of the REST routes.
+func WithRateLimiter(limiter ratelimit.Limiter) HandlerOption {
+	return func(h *handler) {
+		h.limiter = limiter
+	}
+}
+
+// WithOriginCheck allows browser clients of other origins than the service itself, e.g. the CORS origins.
+func WithOriginCheck(allowed func(origin string) bool) HandlerOption {
+	return func(h *handler) {
+		h.allowedOrigin = allowed
+	}
+}
+
+// WithLogger sets the logger, defaults to the global Log instance.
+func WithLogger(log LogWriter) HandlerOption {
+	return func(h *handler) {
+		h.log = log
+	}
+}
+
+type handler struct {
+	service       pizza.Service
+	broker        sse.Broker
+	policies      map[string]auth.Policy
+	limiter       ratelimit.Limiter
+	allowedOrigin func(origin string) bool
+	log           LogWriter
+	upgrader      websocket.Upgrader
+}
+
+// Handler returns a handler that upgrades requests to WebSocket connections. Clients subscribe to
+// pizza changes and send pizza commands that are executed by the service like REST requests.
+// Connections are closed when the broker is closed, e.g. during shutdown.
+func Handler(service pizza.Service, broker sse.Broker, options ...HandlerOption) echo.HandlerFunc {
+	h := &handler{
+		service: service,
+		broker:  broker,
+	}
+	for _, option := range options {
+		option(h)
+	}
+	if h.log == nil {
+		h.log = Log
+	}
+	h.upgrader = websocket.Upgrader{CheckOrigin: h.checkOrigin}
+
+	return h.serve
+}
+
+// checkOrigin allows clients without Origin header, of the same host and of the allowed origins.
+func (h *handler) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+	return h.allowedOrigin != nil && h.allowedOrigin(origin)
+}
+
+func (h *handler) serve(ctx echo.Context) error {
+	conn, err := h.upgrader.Upgrade(ctx.Response(), ctx.Request(), nil)
+	if err != nil {
+		// The upgrader already answered the request.
+		h.log.Debug(err)
+		return nil
+	}
+
+	_, _, client := h.broker.Subscribe("")
+	c := &connection{
+		handler:    h,
+		conn:       conn,
+		client:     client,
+		origin:     audit.OriginFromContext(ctx),
+		principal:  auth.PrincipalFromContext(ctx),
+		clientKey:  ratelimit.ClientKey(ctx),
+		send:       make(chan interface{}, sendBufferSize),
+		done:       make(chan struct{}),
+		writerDone: make(chan struct{}),
+		names:      map[string]bool{},
+	}
+	if claims := auth.ClaimsFromContext(ctx); claims != nil {
+		c.expiresAt = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	go c.writeLoop()
+	c.readLoop()
+	<-c.writerDone
+
+	h.broker.Unsubscribe(client)
+	return nil
+}
+
+// connection is a single WebSocket client. The read loop executes commands, the write loop is the only
+// writer of the socket and sends replies and notifications.
+type connection struct {
+	handler   *handler
+	conn      *websocket.Conn
+	client    *sse.Client
+	origin    audit.Origin
+	principal *auth.Principal
+	// expiresAt is the expiry of the token the connection was authenticated with, zero for other methods.
+	expiresAt time.Time
+	clientKey string
+	send      chan interface{}
+	// done is closed when the read loop ends, writerDone when the write loop ends.
+	done       chan struct{}
+	writerDone chan struct{}
+
+	// subscriptions
+	all   bool
+	names map[string]bool
+	sync.Mutex
+}
+
+func (c *connection) readLoop() {
+	defer close(c.done)
+
+	c.conn.SetReadLimit(maxMessageSize)
+	_ = c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				c.handler.log.Debug(err)
+			}
+			return
+		}
+
+		command := &Command{}
+		var reply *Reply
+		if err := json.Unmarshal(data, command); err != nil {
+			reply = c.reply(command, nil, Error(err, ErrorTypeBinding))
+		} else {
+			result, err := c.execute(command)
+			reply = c.reply(command, result, err)
+		}
+
+		// The write loop stops on write errors without reading further replies.
+		select {
+		case c.send <- reply:
+		case <-c.writerDone:
+			return
+		}
+	}
+}
+
+func (c *connection) writeLoop() {
+	ticker := time.NewTicker(pingPeriod)
+	// The connection is closed when the token expires, an idle client must not keep receiving notifications.
+	var expired <-chan time.Time
+	if !c.expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(c.expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		close(c.writerDone)
+	}()
+
+	for {
+		var message interface{}
+		select {
+		case <-c.done:
+			return
+		case message = <-c.send:
+		case event, open := <-c.client.Events:
+			if !open {
+				_ = c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "stream closed"), time.Now().Add(writeWait))
+				return
+			}
+			if !c.subscribed(event.Subject) {
+				continue
+			}
+			message = notification(event)
+		case <-expired:
+			_ = c.conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, auth.ErrTokenExpired), time.Now().Add(writeWait))
+			return
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+			continue
+		}
+
+		_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+		if err := c.conn.WriteJSON(message); err != nil {
+			return
+		}
+	}
+}
+
+// execute runs a command and returns its result.
+func (c *connection) execute(command *Command) (interface{}, error) {
+	if err := c.authorize(command.Type); err != nil {
+		return nil, err
+	}
+
+	switch command.Type {
+	case CommandSubscribe, CommandUnsubscribe:
+		c.subscribe(command.Type == CommandSubscribe, command.Names)
+		return nil, nil
+	case CommandList:
+		return c.handler.service.GetAll()
+	case CommandGet:
+		if command.Name == "" {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingName, command.Type)
+		}
+		return c.handler.service.Get(command.Name)
+	case CommandCreate:
+		if command.Pizza == nil {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingPizza, command.Type)
+		}
+		return c.handler.service.Create(c.origin, command.Pizza)
+	case CommandUpdate:
+		if command.Name == "" {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingName, command.Type)
+		}
+		if command.Pizza == nil {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingPizza, command.Type)
+		}
+		return c.handler.service.Update(c.origin, command.Name, command.Pizza)
+	case CommandDelete:
+		if command.Name == "" {
+			return nil, Errorf(ErrorTypeBadRequest, ErrMissingName, command.Type)
+		}
+		return nil, c.handler.service.Delete(c.origin, command.Name)
+	default:
+		return nil, Errorf(ErrorTypeBadRequest, ErrUnknownCommand, command.Type)
+	}
+}
+
+// authorize applies the policy and rate limit of a command like the REST middlewares do.
+// Commands are rejected once the token of the connection expired, even before the connection is closed.
+func (c *connection) authorize(commandType string) error {
+	if !c.expiresAt.IsZero() && !time.Now().Before(c.expiresAt) {
+		return Error(auth.ErrTokenExpired, ErrorTypeUnauthorized)
+	}
+
+	policy, ok := c.handler.policies[commandType]
+	if ok {
+		if err := policy.Authorize(c.principal, "ws "+commandType); err != nil {
+			return err
+		}
+	}
+
+	if c.handler.limiter == nil {
+		return nil
+	}
+	class := ratelimit.ClassRead
+	if commandType == CommandCreate || commandType == CommandUpdate || commandType == CommandDelete {
+		class = ratelimit.ClassWrite
+	}
+	result, err := c.handler.limiter.Allow(c.clientKey, class)
+	if err != nil {
+		// Like the middleware, the limit is not enforced if the store fails.
+		c.handler.log.Warnf("rate limiter failed, allowing command: %v", err)
+		return nil
+	}
+	if !result.Allowed {
+		return Errorf(ErrorTypeTooManyRequests, ratelimit.ErrRateLimitExceeded, int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+	return nil
+}
+
+func (c *connection) subscribe(subscribe bool, names []string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if len(names) == 0 {
+		c.all = subscribe
+		if !subscribe {
+			c.names = map[string]bool{}
+		}
+		return
+	}
+	for _, name := range names {
+		if subscribe {
+			c.names[name] = true
+		} else {
+			delete(c.names, name)
+		}
+	}
+}
+
+func (c *connection) subscribed(name string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.all || c.names[name]
+}
+
+// reply creates the reply of a command with the REST status and error body.
+func (c *connection) reply(command *Command, data interface{}, err error) *Reply {
+	if err != nil {
+		status, body := ErrorResponse(err, c.origin.RequestID)
+		return &Reply{ID: command.ID, Type: MessageError, Error: body, Status: status}
+	}
+
+	status := http.StatusOK
+	switch command.Type {
+	case CommandCreate:
+		status = http.StatusCreated
+	case CommandDelete:
+		status = http.StatusNoContent
+	}
+	return &Reply{ID: command.ID, Type: MessageResult, Data: data, Status: status}
+}
+
+func notification(event *events.Event) *Notification {
+	n := &Notification{
+		Type:     MessageEvent,
+		Event:    event.Type,
+		Name:     event.Subject,
+		Revision: event.Revision,
+	}
+	if len(event.Data) > 0 {
+		n.Pizza = event.Data
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+package ws
+
+import (
+	"golang-microservice-template/auth"
+	"golang-microservice-template/pizza"
+	"golang-microservice-template/sse"
+	. "golang-microservice-template/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dial(t *testing.T, server *httptest.Server) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	return conn
+}
+
+func TestCommandIsAnswered(t *testing.T) {
+	e := echo.New()
+	e.GET("/", Handler(pizza.NewService(), sse.NewBroker(10)))
+	server := httptest.NewServer(e)
+	defer server.Close()
+	conn := dial(t, server)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(&Command{ID: "1", Type: CommandList}))
+
+	reply := map[string]interface{}{}
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, "1", reply["id"])
+}
+
+func TestReadLoopStopsWhenWriteLoopStopped(t *testing.T) {
+	h := &handler{service: pizza.NewService(), log: Log}
+	stopped := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := h.upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		c := &connection{
+			handler:    h,
+			conn:       conn,
+			send:       make(chan interface{}),
+			done:       make(chan struct{}),
+			writerDone: make(chan struct{}),
+			names:      map[string]bool{},
+		}
+		// the write loop failed, nobody takes the reply from the send channel
+		close(c.writerDone)
+		c.readLoop()
+		close(stopped)
+	}))
+	defer server.Close()
+	conn := dial(t, server)
+	defer conn.Close()
+
+	require.NoError(t, conn.WriteJSON(&Command{ID: "1", Type: CommandList}))
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("read loop blocked on the send channel")
+	}
+}
+
+func TestConnectionIsClosedWhenTokenExpires(t *testing.T) {
+	e := echo.New()
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			ctx.Set(auth.ContextKeyClaims, &auth.Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Second).Unix()})
+			return next(ctx)
+		}
+	}
+	e.GET("/", Handler(pizza.NewService(), sse.NewBroker(10)), authenticate)
+	server := httptest.NewServer(e)
+	defer server.Close()
+	conn := dial(t, server)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, _, err := conn.ReadMessage()
+
+	closeErr, ok := err.(*websocket.CloseError)
+	if assert.True(t, ok, "expected a close message, got %v", err) {
+		assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+		assert.Equal(t, auth.ErrTokenExpired, closeErr.Text)
+	}
+}
+
+func TestCommandsAreRejectedAfterTokenExpired(t *testing.T) {
+	c := &connection{
+		handler:   &handler{service: pizza.NewService(), log: Log},
+		expiresAt: time.Now().Add(-time.Second),
+		names:     map[string]bool{},
+	}
+
+	_, err := c.execute(&Command{ID: "1", Type: CommandList})
+
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, err.(HasHTTPStatus).GetHTTPStatusCode())
+	}
+
+	c.expiresAt = time.Now().Add(time.Minute)
+	_, err = c.execute(&Command{ID: "2", Type: CommandList})
+	assert.NoError(t, err)
+}
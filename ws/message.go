@@ -0,0 +1,57 @@
+package ws
+
+import (
+	"encoding/json"
+	"golang-microservice-template/pizza"
+)
+
+// Commands sent by clients
+const (
+	CommandSubscribe   = "subscribe"
+	CommandUnsubscribe = "unsubscribe"
+	CommandList        = "list"
+	CommandGet         = "get"
+	CommandCreate      = "create"
+	CommandUpdate      = "update"
+	CommandDelete      = "delete"
+)
+
+// Messages sent by the server
+const (
+	MessageResult = "result"
+	MessageError  = "error"
+	MessageEvent  = "event"
+)
+
+// Command is a message from a client.
+type Command struct {
+	// ID is chosen by the client and returned in the reply to correlate it.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// Names selects the pizzas to subscribe to or unsubscribe from, all pizzas if empty.
+	Names []string `json:"names,omitempty"`
+	// Name is the pizza of the get, update and delete commands.
+	Name string `json:"name,omitempty"`
+	// Pizza is the pizza of the create and update commands.
+	Pizza *pizza.PizzaDto `json:"pizza,omitempty"`
+}
+
+// Reply answers a command with its result or an error in the httpError shape of the REST API.
+type Reply struct {
+	ID    string      `json:"id,omitempty"`
+	Type  string      `json:"type"`
+	Data  interface{} `json:"data,omitempty"`
+	Error interface{} `json:"error,omitempty"`
+	// Status is the HTTP status the REST API would answer with.
+	Status int `json:"status"`
+}
+
+// Notification tells subscribed clients about a pizza change.
+type Notification struct {
+	Type     string `json:"type"`
+	Event    string `json:"event"`
+	Name     string `json:"name"`
+	Revision int    `json:"revision"`
+	// Pizza is the changed pizza as PizzaDto, empty for deletions.
+	Pizza json.RawMessage `json:"pizza,omitempty"`
+}